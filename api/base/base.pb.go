@@ -27,6 +27,7 @@ type Port struct {
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                         // 端口名称，如 in/out1/pdf_out
 	Label         string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`                       // 人类可读的标签
 	PortType      string                 `protobuf:"bytes,3,opt,name=port_type,json=portType,proto3" json:"port_type,omitempty"` // 端口类型，如 connection/file
+	DataType      *DataType              `protobuf:"bytes,4,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"` // 可选，端口传递的数据类型
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -82,6 +83,74 @@ func (x *Port) GetPortType() string {
 	return ""
 }
 
+func (x *Port) GetDataType() *DataType {
+	if x != nil {
+		return x.DataType
+	}
+	return nil
+}
+
+// 端口数据类型
+type DataType struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`     // int/float/string/bool/json/file
+	Schema        string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"` // kind 为 json 时可选的 JSON Schema
+	Mime          string                 `protobuf:"bytes,3,opt,name=mime,proto3" json:"mime,omitempty"`     // kind 为 file 时可选的 MIME 类型
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataType) Reset() {
+	*x = DataType{}
+	mi := &file_api_base_base_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataType) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataType) ProtoMessage() {}
+
+func (x *DataType) ProtoReflect() protoreflect.Message {
+	mi := &file_api_base_base_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataType.ProtoReflect.Descriptor instead.
+func (*DataType) Descriptor() ([]byte, []int) {
+	return file_api_base_base_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DataType) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *DataType) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *DataType) GetMime() string {
+	if x != nil {
+		return x.Mime
+	}
+	return ""
+}
+
 // 端点定义
 type Endpoint struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -93,7 +162,7 @@ type Endpoint struct {
 
 func (x *Endpoint) Reset() {
 	*x = Endpoint{}
-	mi := &file_api_base_base_proto_msgTypes[1]
+	mi := &file_api_base_base_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -105,7 +174,7 @@ func (x *Endpoint) String() string {
 func (*Endpoint) ProtoMessage() {}
 
 func (x *Endpoint) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[1]
+	mi := &file_api_base_base_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -118,7 +187,7 @@ func (x *Endpoint) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Endpoint.ProtoReflect.Descriptor instead.
 func (*Endpoint) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{1}
+	return file_api_base_base_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *Endpoint) GetNodeId() string {
@@ -142,13 +211,15 @@ type NodeType struct {
 	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`                                                                               // 节点分类
 	Note          string                 `protobuf:"bytes,3,opt,name=note,proto3" json:"note,omitempty"`                                                                                       // 节点说明
 	Properties    map[string]*PortList   `protobuf:"bytes,4,rep,name=properties,proto3" json:"properties,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 属性映射，如 inputs/outputs
+	Retry         *RetryPolicy           `protobuf:"bytes,5,opt,name=retry,proto3" json:"retry,omitempty"`                                                                                     // 默认重试策略，可被工作流节点覆盖
+	Cache         *CachePolicy           `protobuf:"bytes,6,opt,name=cache,proto3" json:"cache,omitempty"`                                                                                     // 缓存策略，为空表示节点输出不可缓存
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *NodeType) Reset() {
 	*x = NodeType{}
-	mi := &file_api_base_base_proto_msgTypes[2]
+	mi := &file_api_base_base_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -160,7 +231,7 @@ func (x *NodeType) String() string {
 func (*NodeType) ProtoMessage() {}
 
 func (x *NodeType) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[2]
+	mi := &file_api_base_base_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -173,7 +244,7 @@ func (x *NodeType) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NodeType.ProtoReflect.Descriptor instead.
 func (*NodeType) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{2}
+	return file_api_base_base_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *NodeType) GetUid() string {
@@ -204,6 +275,158 @@ func (x *NodeType) GetProperties() map[string]*PortList {
 	return nil
 }
 
+func (x *NodeType) GetRetry() *RetryPolicy {
+	if x != nil {
+		return x.Retry
+	}
+	return nil
+}
+
+func (x *NodeType) GetCache() *CachePolicy {
+	if x != nil {
+		return x.Cache
+	}
+	return nil
+}
+
+// 缓存策略：相同节点类型版本、输入与相关变量的执行结果可以复用
+type CachePolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`           // 节点类型版本，实现变化时修改以使旧缓存失效
+	Vars          []string               `protobuf:"bytes,2,rep,name=vars,proto3" json:"vars,omitempty"`                 // 参与缓存键的变量名
+	TtlMs         int64                  `protobuf:"varint,3,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"` // 缓存有效期（毫秒），0 表示不过期
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CachePolicy) Reset() {
+	*x = CachePolicy{}
+	mi := &file_api_base_base_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CachePolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CachePolicy) ProtoMessage() {}
+
+func (x *CachePolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_api_base_base_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CachePolicy.ProtoReflect.Descriptor instead.
+func (*CachePolicy) Descriptor() ([]byte, []int) {
+	return file_api_base_base_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CachePolicy) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *CachePolicy) GetVars() []string {
+	if x != nil {
+		return x.Vars
+	}
+	return nil
+}
+
+func (x *CachePolicy) GetTtlMs() int64 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
+// 重试策略
+type RetryPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MaxAttempts   int32                  `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`         // 最大尝试次数（含首次）
+	BackoffBaseMs int64                  `protobuf:"varint,2,opt,name=backoff_base_ms,json=backoffBaseMs,proto3" json:"backoff_base_ms,omitempty"` // 退避基数（毫秒），按 2 的幂增长
+	BackoffMaxMs  int64                  `protobuf:"varint,3,opt,name=backoff_max_ms,json=backoffMaxMs,proto3" json:"backoff_max_ms,omitempty"`    // 退避上限（毫秒）
+	Jitter        float64                `protobuf:"fixed64,4,opt,name=jitter,proto3" json:"jitter,omitempty"`                                     // 抖动比例 0~1
+	RetryOn       []string               `protobuf:"bytes,5,rep,name=retry_on,json=retryOn,proto3" json:"retry_on,omitempty"`                      // 可重试的错误类别：transport/timeout/operation
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetryPolicy) Reset() {
+	*x = RetryPolicy{}
+	mi := &file_api_base_base_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetryPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryPolicy) ProtoMessage() {}
+
+func (x *RetryPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_api_base_base_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryPolicy.ProtoReflect.Descriptor instead.
+func (*RetryPolicy) Descriptor() ([]byte, []int) {
+	return file_api_base_base_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RetryPolicy) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetBackoffBaseMs() int64 {
+	if x != nil {
+		return x.BackoffBaseMs
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetBackoffMaxMs() int64 {
+	if x != nil {
+		return x.BackoffMaxMs
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetJitter() float64 {
+	if x != nil {
+		return x.Jitter
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetRetryOn() []string {
+	if x != nil {
+		return x.RetryOn
+	}
+	return nil
+}
+
 // 端口列表
 type PortList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -214,7 +437,7 @@ type PortList struct {
 
 func (x *PortList) Reset() {
 	*x = PortList{}
-	mi := &file_api_base_base_proto_msgTypes[3]
+	mi := &file_api_base_base_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -226,7 +449,7 @@ func (x *PortList) String() string {
 func (*PortList) ProtoMessage() {}
 
 func (x *PortList) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[3]
+	mi := &file_api_base_base_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -239,7 +462,7 @@ func (x *PortList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PortList.ProtoReflect.Descriptor instead.
 func (*PortList) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{3}
+	return file_api_base_base_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PortList) GetPorts() []*Port {
@@ -263,7 +486,7 @@ type ConnectionType struct {
 
 func (x *ConnectionType) Reset() {
 	*x = ConnectionType{}
-	mi := &file_api_base_base_proto_msgTypes[4]
+	mi := &file_api_base_base_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -275,7 +498,7 @@ func (x *ConnectionType) String() string {
 func (*ConnectionType) ProtoMessage() {}
 
 func (x *ConnectionType) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[4]
+	mi := &file_api_base_base_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -288,7 +511,7 @@ func (x *ConnectionType) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectionType.ProtoReflect.Descriptor instead.
 func (*ConnectionType) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{4}
+	return file_api_base_base_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ConnectionType) GetUid() string {
@@ -335,7 +558,7 @@ type GetNodeTypesRequest struct {
 
 func (x *GetNodeTypesRequest) Reset() {
 	*x = GetNodeTypesRequest{}
-	mi := &file_api_base_base_proto_msgTypes[5]
+	mi := &file_api_base_base_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -347,7 +570,7 @@ func (x *GetNodeTypesRequest) String() string {
 func (*GetNodeTypesRequest) ProtoMessage() {}
 
 func (x *GetNodeTypesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[5]
+	mi := &file_api_base_base_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -360,7 +583,7 @@ func (x *GetNodeTypesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetNodeTypesRequest.ProtoReflect.Descriptor instead.
 func (*GetNodeTypesRequest) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{5}
+	return file_api_base_base_proto_rawDescGZIP(), []int{8}
 }
 
 // GetNodeTypes 响应
@@ -373,7 +596,7 @@ type GetNodeTypesResponse struct {
 
 func (x *GetNodeTypesResponse) Reset() {
 	*x = GetNodeTypesResponse{}
-	mi := &file_api_base_base_proto_msgTypes[6]
+	mi := &file_api_base_base_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -385,7 +608,7 @@ func (x *GetNodeTypesResponse) String() string {
 func (*GetNodeTypesResponse) ProtoMessage() {}
 
 func (x *GetNodeTypesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[6]
+	mi := &file_api_base_base_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -398,7 +621,7 @@ func (x *GetNodeTypesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetNodeTypesResponse.ProtoReflect.Descriptor instead.
 func (*GetNodeTypesResponse) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{6}
+	return file_api_base_base_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *GetNodeTypesResponse) GetNodeTypes() []*NodeType {
@@ -417,7 +640,7 @@ type GetConnTypesRequest struct {
 
 func (x *GetConnTypesRequest) Reset() {
 	*x = GetConnTypesRequest{}
-	mi := &file_api_base_base_proto_msgTypes[7]
+	mi := &file_api_base_base_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -429,7 +652,7 @@ func (x *GetConnTypesRequest) String() string {
 func (*GetConnTypesRequest) ProtoMessage() {}
 
 func (x *GetConnTypesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[7]
+	mi := &file_api_base_base_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -442,7 +665,7 @@ func (x *GetConnTypesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetConnTypesRequest.ProtoReflect.Descriptor instead.
 func (*GetConnTypesRequest) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{7}
+	return file_api_base_base_proto_rawDescGZIP(), []int{10}
 }
 
 // GetConnTypes 响应
@@ -455,7 +678,7 @@ type GetConnTypesResponse struct {
 
 func (x *GetConnTypesResponse) Reset() {
 	*x = GetConnTypesResponse{}
-	mi := &file_api_base_base_proto_msgTypes[8]
+	mi := &file_api_base_base_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -467,7 +690,7 @@ func (x *GetConnTypesResponse) String() string {
 func (*GetConnTypesResponse) ProtoMessage() {}
 
 func (x *GetConnTypesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[8]
+	mi := &file_api_base_base_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -480,7 +703,7 @@ func (x *GetConnTypesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetConnTypesResponse.ProtoReflect.Descriptor instead.
 func (*GetConnTypesResponse) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{8}
+	return file_api_base_base_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *GetConnTypesResponse) GetConnectionTypes() []*ConnectionType {
@@ -493,16 +716,17 @@ func (x *GetConnTypesResponse) GetConnectionTypes() []*ConnectionType {
 // RunNode 请求
 type RunNodeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`                                                             // 要运行的节点ID
-	Inputs        map[string][]byte      `protobuf:"bytes,2,rep,name=inputs,proto3" json:"inputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 节点输入数据
-	Vars          map[string]string      `protobuf:"bytes,3,rep,name=vars,proto3" json:"vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`     // 变量映射
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`                                                                                       // 要运行的节点ID
+	Inputs        map[string][]byte      `protobuf:"bytes,2,rep,name=inputs,proto3" json:"inputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`                           // 节点输入数据
+	Vars          map[string]string      `protobuf:"bytes,3,rep,name=vars,proto3" json:"vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`                               // 变量映射
+	BlobInputs    map[string]*BlobRef    `protobuf:"bytes,4,rep,name=blob_inputs,json=blobInputs,proto3" json:"blob_inputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // file 端口以引用传递的输入
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RunNodeRequest) Reset() {
 	*x = RunNodeRequest{}
-	mi := &file_api_base_base_proto_msgTypes[9]
+	mi := &file_api_base_base_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -514,7 +738,7 @@ func (x *RunNodeRequest) String() string {
 func (*RunNodeRequest) ProtoMessage() {}
 
 func (x *RunNodeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[9]
+	mi := &file_api_base_base_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -527,7 +751,7 @@ func (x *RunNodeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunNodeRequest.ProtoReflect.Descriptor instead.
 func (*RunNodeRequest) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{9}
+	return file_api_base_base_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *RunNodeRequest) GetNodeId() string {
@@ -551,19 +775,27 @@ func (x *RunNodeRequest) GetVars() map[string]string {
 	return nil
 }
 
+func (x *RunNodeRequest) GetBlobInputs() map[string]*BlobRef {
+	if x != nil {
+		return x.BlobInputs
+	}
+	return nil
+}
+
 // RunNode 响应
 type RunNodeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Outputs       map[string][]byte      `protobuf:"bytes,1,rep,name=outputs,proto3" json:"outputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 节点输出数据
-	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`                                                                               // 节点执行状态
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`                                                                               // 错误信息（如果有）
+	Outputs       map[string][]byte      `protobuf:"bytes,1,rep,name=outputs,proto3" json:"outputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`                            // 节点输出数据
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`                                                                                                          // 节点执行状态
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`                                                                                                          // 错误信息（如果有）
+	BlobOutputs   map[string]*BlobRef    `protobuf:"bytes,4,rep,name=blob_outputs,json=blobOutputs,proto3" json:"blob_outputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // file 端口以引用传递的输出
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RunNodeResponse) Reset() {
 	*x = RunNodeResponse{}
-	mi := &file_api_base_base_proto_msgTypes[10]
+	mi := &file_api_base_base_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -575,7 +807,7 @@ func (x *RunNodeResponse) String() string {
 func (*RunNodeResponse) ProtoMessage() {}
 
 func (x *RunNodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_base_base_proto_msgTypes[10]
+	mi := &file_api_base_base_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -588,7 +820,7 @@ func (x *RunNodeResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunNodeResponse.ProtoReflect.Descriptor instead.
 func (*RunNodeResponse) Descriptor() ([]byte, []int) {
-	return file_api_base_base_proto_rawDescGZIP(), []int{10}
+	return file_api_base_base_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *RunNodeResponse) GetOutputs() map[string][]byte {
@@ -612,28 +844,288 @@ func (x *RunNodeResponse) GetError() string {
 	return ""
 }
 
+func (x *RunNodeResponse) GetBlobOutputs() map[string]*BlobRef {
+	if x != nil {
+		return x.BlobOutputs
+	}
+	return nil
+}
+
+// RunNodeStream 事件
+type RunNodeEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`         // 事件类型：log/progress/result
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`   // 日志内容或进度说明
+	Percent       float64                `protobuf:"fixed64,3,opt,name=percent,proto3" json:"percent,omitempty"` // type 为 progress 时的完成百分比 0~100
+	Result        *RunNodeResponse       `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"`     // type 为 result 时的执行结果，流中最后一个事件
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunNodeEvent) Reset() {
+	*x = RunNodeEvent{}
+	mi := &file_api_base_base_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunNodeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunNodeEvent) ProtoMessage() {}
+
+func (x *RunNodeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_base_base_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunNodeEvent.ProtoReflect.Descriptor instead.
+func (*RunNodeEvent) Descriptor() ([]byte, []int) {
+	return file_api_base_base_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RunNodeEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RunNodeEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RunNodeEvent) GetPercent() float64 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *RunNodeEvent) GetResult() *RunNodeResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+// blob 引用：内容寻址的大文件，保存在某个服务实例上，节点之间只传递引用
+type BlobRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`      // 内容的 sha256 十六进制摘要
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"` // 字节数
+	Mime          string                 `protobuf:"bytes,3,opt,name=mime,proto3" json:"mime,omitempty"`  // MIME 类型
+	Addr          string                 `protobuf:"bytes,4,opt,name=addr,proto3" json:"addr,omitempty"`  // 保存该 blob 的服务实例地址
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlobRef) Reset() {
+	*x = BlobRef{}
+	mi := &file_api_base_base_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlobRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlobRef) ProtoMessage() {}
+
+func (x *BlobRef) ProtoReflect() protoreflect.Message {
+	mi := &file_api_base_base_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlobRef.ProtoReflect.Descriptor instead.
+func (*BlobRef) Descriptor() ([]byte, []int) {
+	return file_api_base_base_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BlobRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BlobRef) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *BlobRef) GetMime() string {
+	if x != nil {
+		return x.Mime
+	}
+	return ""
+}
+
+func (x *BlobRef) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+// ReadBlob 请求
+type ReadBlobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // blob ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadBlobRequest) Reset() {
+	*x = ReadBlobRequest{}
+	mi := &file_api_base_base_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadBlobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadBlobRequest) ProtoMessage() {}
+
+func (x *ReadBlobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_base_base_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadBlobRequest.ProtoReflect.Descriptor instead.
+func (*ReadBlobRequest) Descriptor() ([]byte, []int) {
+	return file_api_base_base_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReadBlobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// blob 数据块
+type BlobChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mime          string                 `protobuf:"bytes,1,opt,name=mime,proto3" json:"mime,omitempty"` // 上传时第一块携带 MIME 类型
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"` // 数据
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlobChunk) Reset() {
+	*x = BlobChunk{}
+	mi := &file_api_base_base_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlobChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlobChunk) ProtoMessage() {}
+
+func (x *BlobChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_api_base_base_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlobChunk.ProtoReflect.Descriptor instead.
+func (*BlobChunk) Descriptor() ([]byte, []int) {
+	return file_api_base_base_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BlobChunk) GetMime() string {
+	if x != nil {
+		return x.Mime
+	}
+	return ""
+}
+
+func (x *BlobChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 var File_api_base_base_proto protoreflect.FileDescriptor
 
 const file_api_base_base_proto_rawDesc = "" +
 	"\n" +
-	"\x13api/base/base.proto\x12\x04base\"M\n" +
+	"\x13api/base/base.proto\x12\x04base\"z\n" +
 	"\x04Port\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
 	"\x05label\x18\x02 \x01(\tR\x05label\x12\x1b\n" +
-	"\tport_type\x18\x03 \x01(\tR\bportType\"@\n" +
+	"\tport_type\x18\x03 \x01(\tR\bportType\x12+\n" +
+	"\tdata_type\x18\x04 \x01(\v2\x0e.base.DataTypeR\bdataType\"J\n" +
+	"\bDataType\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x16\n" +
+	"\x06schema\x18\x02 \x01(\tR\x06schema\x12\x12\n" +
+	"\x04mime\x18\x03 \x01(\tR\x04mime\"@\n" +
 	"\bEndpoint\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1b\n" +
-	"\tport_name\x18\x02 \x01(\tR\bportName\"\xdb\x01\n" +
+	"\tport_name\x18\x02 \x01(\tR\bportName\"\xad\x02\n" +
 	"\bNodeType\x12\x10\n" +
 	"\x03uid\x18\x01 \x01(\tR\x03uid\x12\x1a\n" +
 	"\bcategory\x18\x02 \x01(\tR\bcategory\x12\x12\n" +
 	"\x04note\x18\x03 \x01(\tR\x04note\x12>\n" +
 	"\n" +
 	"properties\x18\x04 \x03(\v2\x1e.base.NodeType.PropertiesEntryR\n" +
-	"properties\x1aM\n" +
+	"properties\x12'\n" +
+	"\x05retry\x18\x05 \x01(\v2\x11.base.RetryPolicyR\x05retry\x12'\n" +
+	"\x05cache\x18\x06 \x01(\v2\x11.base.CachePolicyR\x05cache\x1aM\n" +
 	"\x0fPropertiesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12$\n" +
-	"\x05value\x18\x02 \x01(\v2\x0e.base.PortListR\x05value:\x028\x01\",\n" +
+	"\x05value\x18\x02 \x01(\v2\x0e.base.PortListR\x05value:\x028\x01\"R\n" +
+	"\vCachePolicy\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x12\n" +
+	"\x04vars\x18\x02 \x03(\tR\x04vars\x12\x15\n" +
+	"\x06ttl_ms\x18\x03 \x01(\x03R\x05ttlMs\"\xb1\x01\n" +
+	"\vRetryPolicy\x12!\n" +
+	"\fmax_attempts\x18\x01 \x01(\x05R\vmaxAttempts\x12&\n" +
+	"\x0fbackoff_base_ms\x18\x02 \x01(\x03R\rbackoffBaseMs\x12$\n" +
+	"\x0ebackoff_max_ms\x18\x03 \x01(\x03R\fbackoffMaxMs\x12\x16\n" +
+	"\x06jitter\x18\x04 \x01(\x01R\x06jitter\x12\x19\n" +
+	"\bretry_on\x18\x05 \x03(\tR\aretryOn\",\n" +
 	"\bPortList\x12 \n" +
 	"\x05ports\x18\x01 \x03(\v2\n" +
 	".base.PortR\x05ports\"\x9c\x01\n" +
@@ -649,28 +1141,55 @@ const file_api_base_base_proto_rawDesc = "" +
 	"node_types\x18\x01 \x03(\v2\x0e.base.NodeTypeR\tnodeTypes\"\x15\n" +
 	"\x13GetConnTypesRequest\"W\n" +
 	"\x14GetConnTypesResponse\x12?\n" +
-	"\x10connection_types\x18\x01 \x03(\v2\x14.base.ConnectionTypeR\x0fconnectionTypes\"\x8b\x02\n" +
+	"\x10connection_types\x18\x01 \x03(\v2\x14.base.ConnectionTypeR\x0fconnectionTypes\"\xa0\x03\n" +
 	"\x0eRunNodeRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x128\n" +
 	"\x06inputs\x18\x02 \x03(\v2 .base.RunNodeRequest.InputsEntryR\x06inputs\x122\n" +
-	"\x04vars\x18\x03 \x03(\v2\x1e.base.RunNodeRequest.VarsEntryR\x04vars\x1a9\n" +
+	"\x04vars\x18\x03 \x03(\v2\x1e.base.RunNodeRequest.VarsEntryR\x04vars\x12E\n" +
+	"\vblob_inputs\x18\x04 \x03(\v2$.base.RunNodeRequest.BlobInputsEntryR\n" +
+	"blobInputs\x1a9\n" +
 	"\vInputsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\x1a7\n" +
 	"\tVarsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb7\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aL\n" +
+	"\x0fBlobInputsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.base.BlobRefR\x05value:\x028\x01\"\xd1\x02\n" +
 	"\x0fRunNodeResponse\x12<\n" +
 	"\aoutputs\x18\x01 \x03(\v2\".base.RunNodeResponse.OutputsEntryR\aoutputs\x12\x14\n" +
 	"\x05state\x18\x02 \x01(\tR\x05state\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\x1a:\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12I\n" +
+	"\fblob_outputs\x18\x04 \x03(\v2&.base.RunNodeResponse.BlobOutputsEntryR\vblobOutputs\x1a:\n" +
 	"\fOutputsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x012\xd9\x01\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\x1aM\n" +
+	"\x10BlobOutputsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.base.BlobRefR\x05value:\x028\x01\"\x85\x01\n" +
+	"\fRunNodeEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x18\n" +
+	"\apercent\x18\x03 \x01(\x01R\apercent\x12-\n" +
+	"\x06result\x18\x04 \x01(\v2\x15.base.RunNodeResponseR\x06result\"U\n" +
+	"\aBlobRef\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04mime\x18\x03 \x01(\tR\x04mime\x12\x12\n" +
+	"\x04addr\x18\x04 \x01(\tR\x04addr\"!\n" +
+	"\x0fReadBlobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"3\n" +
+	"\tBlobChunk\x12\x12\n" +
+	"\x04mime\x18\x01 \x01(\tR\x04mime\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data2\x81\x03\n" +
 	"\vBaseService\x12G\n" +
 	"\fGetNodeTypes\x12\x19.base.GetNodeTypesRequest\x1a\x1a.base.GetNodeTypesResponse\"\x00\x12G\n" +
 	"\fGetConnTypes\x12\x19.base.GetConnTypesRequest\x1a\x1a.base.GetConnTypesResponse\"\x00\x128\n" +
-	"\aRunNode\x12\x14.base.RunNodeRequest\x1a\x15.base.RunNodeResponse\"\x00B\x0fZ\rapi/base;baseb\x06proto3"
+	"\aRunNode\x12\x14.base.RunNodeRequest\x1a\x15.base.RunNodeResponse\"\x00\x12=\n" +
+	"\rRunNodeStream\x12\x14.base.RunNodeRequest\x1a\x12.base.RunNodeEvent\"\x000\x01\x126\n" +
+	"\bReadBlob\x12\x15.base.ReadBlobRequest\x1a\x0f.base.BlobChunk\"\x000\x01\x12/\n" +
+	"\tWriteBlob\x12\x0f.base.BlobChunk\x1a\r.base.BlobRef\"\x00(\x01B\x0fZ\rapi/base;baseb\x06proto3"
 
 var (
 	file_api_base_base_proto_rawDescOnce sync.Once
@@ -684,44 +1203,67 @@ func file_api_base_base_proto_rawDescGZIP() []byte {
 	return file_api_base_base_proto_rawDescData
 }
 
-var file_api_base_base_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_api_base_base_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_api_base_base_proto_goTypes = []any{
 	(*Port)(nil),                 // 0: base.Port
-	(*Endpoint)(nil),             // 1: base.Endpoint
-	(*NodeType)(nil),             // 2: base.NodeType
-	(*PortList)(nil),             // 3: base.PortList
-	(*ConnectionType)(nil),       // 4: base.ConnectionType
-	(*GetNodeTypesRequest)(nil),  // 5: base.GetNodeTypesRequest
-	(*GetNodeTypesResponse)(nil), // 6: base.GetNodeTypesResponse
-	(*GetConnTypesRequest)(nil),  // 7: base.GetConnTypesRequest
-	(*GetConnTypesResponse)(nil), // 8: base.GetConnTypesResponse
-	(*RunNodeRequest)(nil),       // 9: base.RunNodeRequest
-	(*RunNodeResponse)(nil),      // 10: base.RunNodeResponse
-	nil,                          // 11: base.NodeType.PropertiesEntry
-	nil,                          // 12: base.RunNodeRequest.InputsEntry
-	nil,                          // 13: base.RunNodeRequest.VarsEntry
-	nil,                          // 14: base.RunNodeResponse.OutputsEntry
+	(*DataType)(nil),             // 1: base.DataType
+	(*Endpoint)(nil),             // 2: base.Endpoint
+	(*NodeType)(nil),             // 3: base.NodeType
+	(*CachePolicy)(nil),          // 4: base.CachePolicy
+	(*RetryPolicy)(nil),          // 5: base.RetryPolicy
+	(*PortList)(nil),             // 6: base.PortList
+	(*ConnectionType)(nil),       // 7: base.ConnectionType
+	(*GetNodeTypesRequest)(nil),  // 8: base.GetNodeTypesRequest
+	(*GetNodeTypesResponse)(nil), // 9: base.GetNodeTypesResponse
+	(*GetConnTypesRequest)(nil),  // 10: base.GetConnTypesRequest
+	(*GetConnTypesResponse)(nil), // 11: base.GetConnTypesResponse
+	(*RunNodeRequest)(nil),       // 12: base.RunNodeRequest
+	(*RunNodeResponse)(nil),      // 13: base.RunNodeResponse
+	(*RunNodeEvent)(nil),         // 14: base.RunNodeEvent
+	(*BlobRef)(nil),              // 15: base.BlobRef
+	(*ReadBlobRequest)(nil),      // 16: base.ReadBlobRequest
+	(*BlobChunk)(nil),            // 17: base.BlobChunk
+	nil,                          // 18: base.NodeType.PropertiesEntry
+	nil,                          // 19: base.RunNodeRequest.InputsEntry
+	nil,                          // 20: base.RunNodeRequest.VarsEntry
+	nil,                          // 21: base.RunNodeRequest.BlobInputsEntry
+	nil,                          // 22: base.RunNodeResponse.OutputsEntry
+	nil,                          // 23: base.RunNodeResponse.BlobOutputsEntry
 }
 var file_api_base_base_proto_depIdxs = []int32{
-	11, // 0: base.NodeType.properties:type_name -> base.NodeType.PropertiesEntry
-	0,  // 1: base.PortList.ports:type_name -> base.Port
-	2,  // 2: base.GetNodeTypesResponse.node_types:type_name -> base.NodeType
-	4,  // 3: base.GetConnTypesResponse.connection_types:type_name -> base.ConnectionType
-	12, // 4: base.RunNodeRequest.inputs:type_name -> base.RunNodeRequest.InputsEntry
-	13, // 5: base.RunNodeRequest.vars:type_name -> base.RunNodeRequest.VarsEntry
-	14, // 6: base.RunNodeResponse.outputs:type_name -> base.RunNodeResponse.OutputsEntry
-	3,  // 7: base.NodeType.PropertiesEntry.value:type_name -> base.PortList
-	5,  // 8: base.BaseService.GetNodeTypes:input_type -> base.GetNodeTypesRequest
-	7,  // 9: base.BaseService.GetConnTypes:input_type -> base.GetConnTypesRequest
-	9,  // 10: base.BaseService.RunNode:input_type -> base.RunNodeRequest
-	6,  // 11: base.BaseService.GetNodeTypes:output_type -> base.GetNodeTypesResponse
-	8,  // 12: base.BaseService.GetConnTypes:output_type -> base.GetConnTypesResponse
-	10, // 13: base.BaseService.RunNode:output_type -> base.RunNodeResponse
-	11, // [11:14] is the sub-list for method output_type
-	8,  // [8:11] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	1,  // 0: base.Port.data_type:type_name -> base.DataType
+	18, // 1: base.NodeType.properties:type_name -> base.NodeType.PropertiesEntry
+	5,  // 2: base.NodeType.retry:type_name -> base.RetryPolicy
+	4,  // 3: base.NodeType.cache:type_name -> base.CachePolicy
+	0,  // 4: base.PortList.ports:type_name -> base.Port
+	3,  // 5: base.GetNodeTypesResponse.node_types:type_name -> base.NodeType
+	7,  // 6: base.GetConnTypesResponse.connection_types:type_name -> base.ConnectionType
+	19, // 7: base.RunNodeRequest.inputs:type_name -> base.RunNodeRequest.InputsEntry
+	20, // 8: base.RunNodeRequest.vars:type_name -> base.RunNodeRequest.VarsEntry
+	21, // 9: base.RunNodeRequest.blob_inputs:type_name -> base.RunNodeRequest.BlobInputsEntry
+	22, // 10: base.RunNodeResponse.outputs:type_name -> base.RunNodeResponse.OutputsEntry
+	23, // 11: base.RunNodeResponse.blob_outputs:type_name -> base.RunNodeResponse.BlobOutputsEntry
+	13, // 12: base.RunNodeEvent.result:type_name -> base.RunNodeResponse
+	6,  // 13: base.NodeType.PropertiesEntry.value:type_name -> base.PortList
+	15, // 14: base.RunNodeRequest.BlobInputsEntry.value:type_name -> base.BlobRef
+	15, // 15: base.RunNodeResponse.BlobOutputsEntry.value:type_name -> base.BlobRef
+	8,  // 16: base.BaseService.GetNodeTypes:input_type -> base.GetNodeTypesRequest
+	10, // 17: base.BaseService.GetConnTypes:input_type -> base.GetConnTypesRequest
+	12, // 18: base.BaseService.RunNode:input_type -> base.RunNodeRequest
+	12, // 19: base.BaseService.RunNodeStream:input_type -> base.RunNodeRequest
+	16, // 20: base.BaseService.ReadBlob:input_type -> base.ReadBlobRequest
+	17, // 21: base.BaseService.WriteBlob:input_type -> base.BlobChunk
+	9,  // 22: base.BaseService.GetNodeTypes:output_type -> base.GetNodeTypesResponse
+	11, // 23: base.BaseService.GetConnTypes:output_type -> base.GetConnTypesResponse
+	13, // 24: base.BaseService.RunNode:output_type -> base.RunNodeResponse
+	14, // 25: base.BaseService.RunNodeStream:output_type -> base.RunNodeEvent
+	17, // 26: base.BaseService.ReadBlob:output_type -> base.BlobChunk
+	15, // 27: base.BaseService.WriteBlob:output_type -> base.BlobRef
+	22, // [22:28] is the sub-list for method output_type
+	16, // [16:22] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_api_base_base_proto_init() }
@@ -735,7 +1277,7 @@ func file_api_base_base_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_base_base_proto_rawDesc), len(file_api_base_base_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   15,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,9 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	BaseService_GetNodeTypes_FullMethodName = "/base.BaseService/GetNodeTypes"
-	BaseService_GetConnTypes_FullMethodName = "/base.BaseService/GetConnTypes"
-	BaseService_RunNode_FullMethodName      = "/base.BaseService/RunNode"
+	BaseService_GetNodeTypes_FullMethodName  = "/base.BaseService/GetNodeTypes"
+	BaseService_GetConnTypes_FullMethodName  = "/base.BaseService/GetConnTypes"
+	BaseService_RunNode_FullMethodName       = "/base.BaseService/RunNode"
+	BaseService_RunNodeStream_FullMethodName = "/base.BaseService/RunNodeStream"
+	BaseService_ReadBlob_FullMethodName      = "/base.BaseService/ReadBlob"
+	BaseService_WriteBlob_FullMethodName     = "/base.BaseService/WriteBlob"
 )
 
 // BaseServiceClient is the client API for BaseService service.
@@ -36,6 +39,12 @@ type BaseServiceClient interface {
 	GetConnTypes(ctx context.Context, in *GetConnTypesRequest, opts ...grpc.CallOption) (*GetConnTypesResponse, error)
 	// 运行指定节点
 	RunNode(ctx context.Context, in *RunNodeRequest, opts ...grpc.CallOption) (*RunNodeResponse, error)
+	// 运行指定节点，执行过程中发送日志与进度，最后发送结果
+	RunNodeStream(ctx context.Context, in *RunNodeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunNodeEvent], error)
+	// 按块读取本服务保存的 blob
+	ReadBlob(ctx context.Context, in *ReadBlobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BlobChunk], error)
+	// 按块上传 blob 到本服务，第一块携带 mime，返回 blob 引用
+	WriteBlob(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BlobChunk, BlobRef], error)
 }
 
 type baseServiceClient struct {
@@ -76,6 +85,57 @@ func (c *baseServiceClient) RunNode(ctx context.Context, in *RunNodeRequest, opt
 	return out, nil
 }
 
+func (c *baseServiceClient) RunNodeStream(ctx context.Context, in *RunNodeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunNodeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BaseService_ServiceDesc.Streams[0], BaseService_RunNodeStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RunNodeRequest, RunNodeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BaseService_RunNodeStreamClient = grpc.ServerStreamingClient[RunNodeEvent]
+
+func (c *baseServiceClient) ReadBlob(ctx context.Context, in *ReadBlobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BlobChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BaseService_ServiceDesc.Streams[1], BaseService_ReadBlob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadBlobRequest, BlobChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BaseService_ReadBlobClient = grpc.ServerStreamingClient[BlobChunk]
+
+func (c *baseServiceClient) WriteBlob(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BlobChunk, BlobRef], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BaseService_ServiceDesc.Streams[2], BaseService_WriteBlob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BlobChunk, BlobRef]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BaseService_WriteBlobClient = grpc.ClientStreamingClient[BlobChunk, BlobRef]
+
 // BaseServiceServer is the server API for BaseService service.
 // All implementations must embed UnimplementedBaseServiceServer
 // for forward compatibility.
@@ -88,6 +148,12 @@ type BaseServiceServer interface {
 	GetConnTypes(context.Context, *GetConnTypesRequest) (*GetConnTypesResponse, error)
 	// 运行指定节点
 	RunNode(context.Context, *RunNodeRequest) (*RunNodeResponse, error)
+	// 运行指定节点，执行过程中发送日志与进度，最后发送结果
+	RunNodeStream(*RunNodeRequest, grpc.ServerStreamingServer[RunNodeEvent]) error
+	// 按块读取本服务保存的 blob
+	ReadBlob(*ReadBlobRequest, grpc.ServerStreamingServer[BlobChunk]) error
+	// 按块上传 blob 到本服务，第一块携带 mime，返回 blob 引用
+	WriteBlob(grpc.ClientStreamingServer[BlobChunk, BlobRef]) error
 	mustEmbedUnimplementedBaseServiceServer()
 }
 
@@ -107,6 +173,15 @@ func (UnimplementedBaseServiceServer) GetConnTypes(context.Context, *GetConnType
 func (UnimplementedBaseServiceServer) RunNode(context.Context, *RunNodeRequest) (*RunNodeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RunNode not implemented")
 }
+func (UnimplementedBaseServiceServer) RunNodeStream(*RunNodeRequest, grpc.ServerStreamingServer[RunNodeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method RunNodeStream not implemented")
+}
+func (UnimplementedBaseServiceServer) ReadBlob(*ReadBlobRequest, grpc.ServerStreamingServer[BlobChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadBlob not implemented")
+}
+func (UnimplementedBaseServiceServer) WriteBlob(grpc.ClientStreamingServer[BlobChunk, BlobRef]) error {
+	return status.Errorf(codes.Unimplemented, "method WriteBlob not implemented")
+}
 func (UnimplementedBaseServiceServer) mustEmbedUnimplementedBaseServiceServer() {}
 func (UnimplementedBaseServiceServer) testEmbeddedByValue()                     {}
 
@@ -182,6 +257,35 @@ func _BaseService_RunNode_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BaseService_RunNodeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RunNodeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BaseServiceServer).RunNodeStream(m, &grpc.GenericServerStream[RunNodeRequest, RunNodeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BaseService_RunNodeStreamServer = grpc.ServerStreamingServer[RunNodeEvent]
+
+func _BaseService_ReadBlob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadBlobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BaseServiceServer).ReadBlob(m, &grpc.GenericServerStream[ReadBlobRequest, BlobChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BaseService_ReadBlobServer = grpc.ServerStreamingServer[BlobChunk]
+
+func _BaseService_WriteBlob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BaseServiceServer).WriteBlob(&grpc.GenericServerStream[BlobChunk, BlobRef]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BaseService_WriteBlobServer = grpc.ClientStreamingServer[BlobChunk, BlobRef]
+
 // BaseService_ServiceDesc is the grpc.ServiceDesc for BaseService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -202,6 +306,22 @@ var BaseService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _BaseService_RunNode_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RunNodeStream",
+			Handler:       _BaseService_RunNodeStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ReadBlob",
+			Handler:       _BaseService_ReadBlob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WriteBlob",
+			Handler:       _BaseService_WriteBlob_Handler,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "api/base/base.proto",
 }
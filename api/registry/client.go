@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// 注册中心地址，集群部署时为逗号分隔的多个节点地址，可以通过环境变量 ZFLOW_REGISTRY_ADDR 覆盖
+var SERVICE_REGISTRY_ADDR string = "127.0.0.1:50051"
+
+func init() {
+	if addr := os.Getenv("ZFLOW_REGISTRY_ADDR"); addr != "" {
+		SERVICE_REGISTRY_ADDR = addr
+	}
+}
+
+// registryServiceConfig 连接第一个可用的节点，节点不可用时请求自动重试，连接切换到其它节点
+const registryServiceConfig = `{
+	"loadBalancingConfig": [{"pick_first": {}}],
+	"methodConfig": [{
+		"name": [{"service": "registry.Registry"}],
+		"retryPolicy": {
+			"maxAttempts": 4,
+			"initialBackoff": "0.2s",
+			"maxBackoff": "2s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// Dial 连接注册中心，addrs 为逗号分隔的节点地址，当前节点不可用时切换到其它节点
+func Dial(addrs string) (*grpc.ClientConn, error) {
+	var list []resolver.Address
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			list = append(list, resolver.Address{Addr: addr})
+		}
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no registry address in %q", addrs)
+	}
+	r := manual.NewBuilderWithScheme("zflow-registry")
+	r.InitialState(resolver.State{Addresses: list})
+	return grpc.NewClient(r.Scheme()+":///registry",
+		grpc.WithResolvers(r),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(registryServiceConfig),
+	)
+}
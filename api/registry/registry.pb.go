@@ -22,13 +22,114 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// 实例健康状态
+type HealthStatus int32
+
+const (
+	HealthStatus_HEALTH_UNKNOWN HealthStatus = 0 // 尚未探测或未开启探测，视为可用
+	HealthStatus_HEALTHY        HealthStatus = 1
+	HealthStatus_UNHEALTHY      HealthStatus = 2 // 连续探测失败，消费者应排除该实例
+)
+
+// Enum value maps for HealthStatus.
+var (
+	HealthStatus_name = map[int32]string{
+		0: "HEALTH_UNKNOWN",
+		1: "HEALTHY",
+		2: "UNHEALTHY",
+	}
+	HealthStatus_value = map[string]int32{
+		"HEALTH_UNKNOWN": 0,
+		"HEALTHY":        1,
+		"UNHEALTHY":      2,
+	}
+)
+
+func (x HealthStatus) Enum() *HealthStatus {
+	p := new(HealthStatus)
+	*p = x
+	return p
+}
+
+func (x HealthStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HealthStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_registry_registry_proto_enumTypes[0].Descriptor()
+}
+
+func (HealthStatus) Type() protoreflect.EnumType {
+	return &file_api_registry_registry_proto_enumTypes[0]
+}
+
+func (x HealthStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HealthStatus.Descriptor instead.
+func (HealthStatus) EnumDescriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{0}
+}
+
+// 实例变更类型
+type EventType int32
+
+const (
+	EventType_ADDED   EventType = 0
+	EventType_UPDATED EventType = 1
+	EventType_REMOVED EventType = 2
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "ADDED",
+		1: "UPDATED",
+		2: "REMOVED",
+	}
+	EventType_value = map[string]int32{
+		"ADDED":   0,
+		"UPDATED": 1,
+		"REMOVED": 2,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_registry_registry_proto_enumTypes[1].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_api_registry_registry_proto_enumTypes[1]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{1}
+}
+
 type ServiceInstance struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // 服务名
 	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`     // 实例唯一 ID（IP:Port 或 UUID）
 	Addr          string                 `protobuf:"bytes,3,opt,name=addr,proto3" json:"addr,omitempty"` // 访问地址
 	Meta          map[string]string      `protobuf:"bytes,4,rep,name=meta,proto3" json:"meta,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	TtlSec        int32                  `protobuf:"varint,5,opt,name=ttl_sec,json=ttlSec,proto3" json:"ttl_sec,omitempty"` // 首次租约 TTL
+	TtlSec        int32                  `protobuf:"varint,5,opt,name=ttl_sec,json=ttlSec,proto3" json:"ttl_sec,omitempty"`              // 首次租约 TTL
+	Health        HealthStatus           `protobuf:"varint,6,opt,name=health,proto3,enum=registry.HealthStatus" json:"health,omitempty"` // 注册中心主动探测的健康状态，注册时忽略
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -98,6 +199,13 @@ func (x *ServiceInstance) GetTtlSec() int32 {
 	return 0
 }
 
+func (x *ServiceInstance) GetHealth() HealthStatus {
+	if x != nil {
+		return x.Health
+	}
+	return HealthStatus_HEALTH_UNKNOWN
+}
+
 type Lease struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -160,7 +268,8 @@ func (x *Lease) GetExpireUnix() int64 {
 
 type Query struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // 为空则返回全部
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`          // 为空则返回全部
+	Revision      int64                  `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"` // Watch 从该版本之后续订，0 表示先推送全量快照
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -202,9 +311,17 @@ func (x *Query) GetName() string {
 	return ""
 }
 
+func (x *Query) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
 type Services struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Instances     []*ServiceInstance     `protobuf:"bytes,1,rep,name=instances,proto3" json:"instances,omitempty"`
+	Revision      int64                  `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"` // 查询时注册中心的版本
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -246,17 +363,640 @@ func (x *Services) GetInstances() []*ServiceInstance {
 	return nil
 }
 
+func (x *Services) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+// 实例变更事件
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=registry.EventType" json:"type,omitempty"`
+	Instance      *ServiceInstance       `protobuf:"bytes,2,opt,name=instance,proto3" json:"instance,omitempty"`
+	Revision      int64                  `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"` // 该变更产生的版本，单调递增
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_api_registry_registry_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WatchEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_ADDED
+}
+
+func (x *WatchEvent) GetInstance() *ServiceInstance {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+// Watch 推送
+type WatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot      bool                   `protobuf:"varint,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // 为 true 时 events 为当前全部实例（均为 ADDED），客户端应先清空本地状态
+	Events        []*WatchEvent          `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	Revision      int64                  `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"` // 本次推送后已同步到的版本，重连时作为 Query.revision
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_api_registry_registry_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchResponse) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *WatchResponse) GetEvents() []*WatchEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+type VoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	CandidateId   string                 `protobuf:"bytes,2,opt,name=candidate_id,json=candidateId,proto3" json:"candidate_id,omitempty"`
+	LastLogIndex  int64                  `protobuf:"varint,3,opt,name=last_log_index,json=lastLogIndex,proto3" json:"last_log_index,omitempty"`
+	LastLogTerm   int64                  `protobuf:"varint,4,opt,name=last_log_term,json=lastLogTerm,proto3" json:"last_log_term,omitempty"`
+	PreVote       bool                   `protobuf:"varint,5,opt,name=pre_vote,json=preVote,proto3" json:"pre_vote,omitempty"` // 预投票：只询问是否会投票，不改变任期与投票，避免重新加入的节点打断现有 leader
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoteRequest) Reset() {
+	*x = VoteRequest{}
+	mi := &file_api_registry_registry_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteRequest) ProtoMessage() {}
+
+func (x *VoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteRequest.ProtoReflect.Descriptor instead.
+func (*VoteRequest) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *VoteRequest) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetCandidateId() string {
+	if x != nil {
+		return x.CandidateId
+	}
+	return ""
+}
+
+func (x *VoteRequest) GetLastLogIndex() int64 {
+	if x != nil {
+		return x.LastLogIndex
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetLastLogTerm() int64 {
+	if x != nil {
+		return x.LastLogTerm
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetPreVote() bool {
+	if x != nil {
+		return x.PreVote
+	}
+	return false
+}
+
+type VoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Granted       bool                   `protobuf:"varint,2,opt,name=granted,proto3" json:"granted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoteResponse) Reset() {
+	*x = VoteResponse{}
+	mi := &file_api_registry_registry_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteResponse) ProtoMessage() {}
+
+func (x *VoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteResponse.ProtoReflect.Descriptor instead.
+func (*VoteResponse) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *VoteResponse) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *VoteResponse) GetGranted() bool {
+	if x != nil {
+		return x.Granted
+	}
+	return false
+}
+
+// 复制日志中的一条注册表变更
+type LogEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Term          int64                  `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	Command       []byte                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"` // JSON 编码的变更
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_api_registry_registry_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LogEntry) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *LogEntry) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *LogEntry) GetCommand() []byte {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+type AppendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,2,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	PrevLogIndex  int64                  `protobuf:"varint,3,opt,name=prev_log_index,json=prevLogIndex,proto3" json:"prev_log_index,omitempty"`
+	PrevLogTerm   int64                  `protobuf:"varint,4,opt,name=prev_log_term,json=prevLogTerm,proto3" json:"prev_log_term,omitempty"`
+	Entries       []*LogEntry            `protobuf:"bytes,5,rep,name=entries,proto3" json:"entries,omitempty"`
+	LeaderCommit  int64                  `protobuf:"varint,6,opt,name=leader_commit,json=leaderCommit,proto3" json:"leader_commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendRequest) Reset() {
+	*x = AppendRequest{}
+	mi := &file_api_registry_registry_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendRequest) ProtoMessage() {}
+
+func (x *AppendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendRequest.ProtoReflect.Descriptor instead.
+func (*AppendRequest) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AppendRequest) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendRequest) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *AppendRequest) GetPrevLogIndex() int64 {
+	if x != nil {
+		return x.PrevLogIndex
+	}
+	return 0
+}
+
+func (x *AppendRequest) GetPrevLogTerm() int64 {
+	if x != nil {
+		return x.PrevLogTerm
+	}
+	return 0
+}
+
+func (x *AppendRequest) GetEntries() []*LogEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *AppendRequest) GetLeaderCommit() int64 {
+	if x != nil {
+		return x.LeaderCommit
+	}
+	return 0
+}
+
+type AppendResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	LastIndex     int64                  `protobuf:"varint,3,opt,name=last_index,json=lastIndex,proto3" json:"last_index,omitempty"` // 跟随者最后一条日志的序号，失败时 leader 据此回退
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendResponse) Reset() {
+	*x = AppendResponse{}
+	mi := &file_api_registry_registry_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendResponse) ProtoMessage() {}
+
+func (x *AppendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendResponse.ProtoReflect.Descriptor instead.
+func (*AppendResponse) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AppendResponse) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AppendResponse) GetLastIndex() int64 {
+	if x != nil {
+		return x.LastIndex
+	}
+	return 0
+}
+
+type SnapshotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,2,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	LastIndex     int64                  `protobuf:"varint,3,opt,name=last_index,json=lastIndex,proto3" json:"last_index,omitempty"` // 快照包含的最后一条日志
+	LastTerm      int64                  `protobuf:"varint,4,opt,name=last_term,json=lastTerm,proto3" json:"last_term,omitempty"`
+	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"` // JSON 编码的注册表快照
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	mi := &file_api_registry_registry_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SnapshotRequest) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *SnapshotRequest) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *SnapshotRequest) GetLastIndex() int64 {
+	if x != nil {
+		return x.LastIndex
+	}
+	return 0
+}
+
+func (x *SnapshotRequest) GetLastTerm() int64 {
+	if x != nil {
+		return x.LastTerm
+	}
+	return 0
+}
+
+func (x *SnapshotRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ClusterStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"` // leader / follower / candidate
+	Term          int64                  `protobuf:"varint,3,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,4,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	CommitIndex   int64                  `protobuf:"varint,5,opt,name=commit_index,json=commitIndex,proto3" json:"commit_index,omitempty"`
+	AppliedIndex  int64                  `protobuf:"varint,6,opt,name=applied_index,json=appliedIndex,proto3" json:"applied_index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterStatus) Reset() {
+	*x = ClusterStatus{}
+	mi := &file_api_registry_registry_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterStatus) ProtoMessage() {}
+
+func (x *ClusterStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_api_registry_registry_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterStatus.ProtoReflect.Descriptor instead.
+func (*ClusterStatus) Descriptor() ([]byte, []int) {
+	return file_api_registry_registry_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ClusterStatus) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ClusterStatus) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ClusterStatus) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *ClusterStatus) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *ClusterStatus) GetCommitIndex() int64 {
+	if x != nil {
+		return x.CommitIndex
+	}
+	return 0
+}
+
+func (x *ClusterStatus) GetAppliedIndex() int64 {
+	if x != nil {
+		return x.AppliedIndex
+	}
+	return 0
+}
+
 var File_api_registry_registry_proto protoreflect.FileDescriptor
 
 const file_api_registry_registry_proto_rawDesc = "" +
 	"\n" +
-	"\x1bapi/registry/registry.proto\x12\bregistry\x1a\x1bgoogle/protobuf/empty.proto\"\xd4\x01\n" +
+	"\x1bapi/registry/registry.proto\x12\bregistry\x1a\x1bgoogle/protobuf/empty.proto\"\x84\x02\n" +
 	"\x0fServiceInstance\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
 	"\x02id\x18\x02 \x01(\tR\x02id\x12\x12\n" +
 	"\x04addr\x18\x03 \x01(\tR\x04addr\x127\n" +
 	"\x04meta\x18\x04 \x03(\v2#.registry.ServiceInstance.MetaEntryR\x04meta\x12\x17\n" +
-	"\attl_sec\x18\x05 \x01(\x05R\x06ttlSec\x1a7\n" +
+	"\attl_sec\x18\x05 \x01(\x05R\x06ttlSec\x12.\n" +
+	"\x06health\x18\x06 \x01(\x0e2\x16.registry.HealthStatusR\x06health\x1a7\n" +
 	"\tMetaEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"L\n" +
@@ -264,18 +1004,81 @@ const file_api_registry_registry_proto_rawDesc = "" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
 	"\x02id\x18\x02 \x01(\tR\x02id\x12\x1f\n" +
 	"\vexpire_unix\x18\x03 \x01(\x03R\n" +
-	"expireUnix\"\x1b\n" +
+	"expireUnix\"7\n" +
 	"\x05Query\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\"C\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x03R\brevision\"_\n" +
 	"\bServices\x127\n" +
-	"\tinstances\x18\x01 \x03(\v2\x19.registry.ServiceInstanceR\tinstances2\x93\x02\n" +
+	"\tinstances\x18\x01 \x03(\v2\x19.registry.ServiceInstanceR\tinstances\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x03R\brevision\"\x88\x01\n" +
+	"\n" +
+	"WatchEvent\x12'\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x13.registry.EventTypeR\x04type\x125\n" +
+	"\binstance\x18\x02 \x01(\v2\x19.registry.ServiceInstanceR\binstance\x12\x1a\n" +
+	"\brevision\x18\x03 \x01(\x03R\brevision\"u\n" +
+	"\rWatchResponse\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\bR\bsnapshot\x12,\n" +
+	"\x06events\x18\x02 \x03(\v2\x14.registry.WatchEventR\x06events\x12\x1a\n" +
+	"\brevision\x18\x03 \x01(\x03R\brevision\"\xa9\x01\n" +
+	"\vVoteRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12!\n" +
+	"\fcandidate_id\x18\x02 \x01(\tR\vcandidateId\x12$\n" +
+	"\x0elast_log_index\x18\x03 \x01(\x03R\flastLogIndex\x12\"\n" +
+	"\rlast_log_term\x18\x04 \x01(\x03R\vlastLogTerm\x12\x19\n" +
+	"\bpre_vote\x18\x05 \x01(\bR\apreVote\"<\n" +
+	"\fVoteResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x18\n" +
+	"\agranted\x18\x02 \x01(\bR\agranted\"N\n" +
+	"\bLogEntry\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x03R\x04term\x12\x18\n" +
+	"\acommand\x18\x03 \x01(\fR\acommand\"\xdd\x01\n" +
+	"\rAppendRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x02 \x01(\tR\bleaderId\x12$\n" +
+	"\x0eprev_log_index\x18\x03 \x01(\x03R\fprevLogIndex\x12\"\n" +
+	"\rprev_log_term\x18\x04 \x01(\x03R\vprevLogTerm\x12,\n" +
+	"\aentries\x18\x05 \x03(\v2\x12.registry.LogEntryR\aentries\x12#\n" +
+	"\rleader_commit\x18\x06 \x01(\x03R\fleaderCommit\"]\n" +
+	"\x0eAppendResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x1d\n" +
+	"\n" +
+	"last_index\x18\x03 \x01(\x03R\tlastIndex\"\x92\x01\n" +
+	"\x0fSnapshotRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x02 \x01(\tR\bleaderId\x12\x1d\n" +
+	"\n" +
+	"last_index\x18\x03 \x01(\x03R\tlastIndex\x12\x1b\n" +
+	"\tlast_term\x18\x04 \x01(\x03R\blastTerm\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\"\xac\x01\n" +
+	"\rClusterStatus\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12\x12\n" +
+	"\x04term\x18\x03 \x01(\x03R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x04 \x01(\tR\bleaderId\x12!\n" +
+	"\fcommit_index\x18\x05 \x01(\x03R\vcommitIndex\x12#\n" +
+	"\rapplied_index\x18\x06 \x01(\x03R\fappliedIndex*>\n" +
+	"\fHealthStatus\x12\x12\n" +
+	"\x0eHEALTH_UNKNOWN\x10\x00\x12\v\n" +
+	"\aHEALTHY\x10\x01\x12\r\n" +
+	"\tUNHEALTHY\x10\x02*0\n" +
+	"\tEventType\x12\t\n" +
+	"\x05ADDED\x10\x00\x12\v\n" +
+	"\aUPDATED\x10\x01\x12\v\n" +
+	"\aREMOVED\x10\x022\x98\x02\n" +
 	"\bRegistry\x128\n" +
 	"\bRegister\x12\x19.registry.ServiceInstance\x1a\x0f.registry.Lease\"\x00\x12/\n" +
 	"\tKeepAlive\x12\x0f.registry.Lease\x1a\x0f.registry.Lease\"\x00\x127\n" +
 	"\n" +
 	"Deregister\x12\x0f.registry.Lease\x1a\x16.google.protobuf.Empty\"\x00\x121\n" +
-	"\bDiscover\x12\x0f.registry.Query\x1a\x12.registry.Services\"\x00\x120\n" +
-	"\x05Watch\x12\x0f.registry.Query\x1a\x12.registry.Services\"\x000\x01B\x14Z\x12zflow/api/registryb\x06proto3"
+	"\bDiscover\x12\x0f.registry.Query\x1a\x12.registry.Services\"\x00\x125\n" +
+	"\x05Watch\x12\x0f.registry.Query\x1a\x17.registry.WatchResponse\"\x000\x012\x96\x02\n" +
+	"\aCluster\x12>\n" +
+	"\vRequestVote\x12\x15.registry.VoteRequest\x1a\x16.registry.VoteResponse\"\x00\x12D\n" +
+	"\rAppendEntries\x12\x17.registry.AppendRequest\x1a\x18.registry.AppendResponse\"\x00\x12H\n" +
+	"\x0fInstallSnapshot\x12\x19.registry.SnapshotRequest\x1a\x18.registry.AppendResponse\"\x00\x12;\n" +
+	"\x06Status\x12\x16.google.protobuf.Empty\x1a\x17.registry.ClusterStatus\"\x00B\x14Z\x12zflow/api/registryb\x06proto3"
 
 var (
 	file_api_registry_registry_proto_rawDescOnce sync.Once
@@ -289,33 +1092,58 @@ func file_api_registry_registry_proto_rawDescGZIP() []byte {
 	return file_api_registry_registry_proto_rawDescData
 }
 
-var file_api_registry_registry_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_registry_registry_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_api_registry_registry_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_api_registry_registry_proto_goTypes = []any{
-	(*ServiceInstance)(nil), // 0: registry.ServiceInstance
-	(*Lease)(nil),           // 1: registry.Lease
-	(*Query)(nil),           // 2: registry.Query
-	(*Services)(nil),        // 3: registry.Services
-	nil,                     // 4: registry.ServiceInstance.MetaEntry
-	(*emptypb.Empty)(nil),   // 5: google.protobuf.Empty
+	(HealthStatus)(0),       // 0: registry.HealthStatus
+	(EventType)(0),          // 1: registry.EventType
+	(*ServiceInstance)(nil), // 2: registry.ServiceInstance
+	(*Lease)(nil),           // 3: registry.Lease
+	(*Query)(nil),           // 4: registry.Query
+	(*Services)(nil),        // 5: registry.Services
+	(*WatchEvent)(nil),      // 6: registry.WatchEvent
+	(*WatchResponse)(nil),   // 7: registry.WatchResponse
+	(*VoteRequest)(nil),     // 8: registry.VoteRequest
+	(*VoteResponse)(nil),    // 9: registry.VoteResponse
+	(*LogEntry)(nil),        // 10: registry.LogEntry
+	(*AppendRequest)(nil),   // 11: registry.AppendRequest
+	(*AppendResponse)(nil),  // 12: registry.AppendResponse
+	(*SnapshotRequest)(nil), // 13: registry.SnapshotRequest
+	(*ClusterStatus)(nil),   // 14: registry.ClusterStatus
+	nil,                     // 15: registry.ServiceInstance.MetaEntry
+	(*emptypb.Empty)(nil),   // 16: google.protobuf.Empty
 }
 var file_api_registry_registry_proto_depIdxs = []int32{
-	4, // 0: registry.ServiceInstance.meta:type_name -> registry.ServiceInstance.MetaEntry
-	0, // 1: registry.Services.instances:type_name -> registry.ServiceInstance
-	0, // 2: registry.Registry.Register:input_type -> registry.ServiceInstance
-	1, // 3: registry.Registry.KeepAlive:input_type -> registry.Lease
-	1, // 4: registry.Registry.Deregister:input_type -> registry.Lease
-	2, // 5: registry.Registry.Discover:input_type -> registry.Query
-	2, // 6: registry.Registry.Watch:input_type -> registry.Query
-	1, // 7: registry.Registry.Register:output_type -> registry.Lease
-	1, // 8: registry.Registry.KeepAlive:output_type -> registry.Lease
-	5, // 9: registry.Registry.Deregister:output_type -> google.protobuf.Empty
-	3, // 10: registry.Registry.Discover:output_type -> registry.Services
-	3, // 11: registry.Registry.Watch:output_type -> registry.Services
-	7, // [7:12] is the sub-list for method output_type
-	2, // [2:7] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	15, // 0: registry.ServiceInstance.meta:type_name -> registry.ServiceInstance.MetaEntry
+	0,  // 1: registry.ServiceInstance.health:type_name -> registry.HealthStatus
+	2,  // 2: registry.Services.instances:type_name -> registry.ServiceInstance
+	1,  // 3: registry.WatchEvent.type:type_name -> registry.EventType
+	2,  // 4: registry.WatchEvent.instance:type_name -> registry.ServiceInstance
+	6,  // 5: registry.WatchResponse.events:type_name -> registry.WatchEvent
+	10, // 6: registry.AppendRequest.entries:type_name -> registry.LogEntry
+	2,  // 7: registry.Registry.Register:input_type -> registry.ServiceInstance
+	3,  // 8: registry.Registry.KeepAlive:input_type -> registry.Lease
+	3,  // 9: registry.Registry.Deregister:input_type -> registry.Lease
+	4,  // 10: registry.Registry.Discover:input_type -> registry.Query
+	4,  // 11: registry.Registry.Watch:input_type -> registry.Query
+	8,  // 12: registry.Cluster.RequestVote:input_type -> registry.VoteRequest
+	11, // 13: registry.Cluster.AppendEntries:input_type -> registry.AppendRequest
+	13, // 14: registry.Cluster.InstallSnapshot:input_type -> registry.SnapshotRequest
+	16, // 15: registry.Cluster.Status:input_type -> google.protobuf.Empty
+	3,  // 16: registry.Registry.Register:output_type -> registry.Lease
+	3,  // 17: registry.Registry.KeepAlive:output_type -> registry.Lease
+	16, // 18: registry.Registry.Deregister:output_type -> google.protobuf.Empty
+	5,  // 19: registry.Registry.Discover:output_type -> registry.Services
+	7,  // 20: registry.Registry.Watch:output_type -> registry.WatchResponse
+	9,  // 21: registry.Cluster.RequestVote:output_type -> registry.VoteResponse
+	12, // 22: registry.Cluster.AppendEntries:output_type -> registry.AppendResponse
+	12, // 23: registry.Cluster.InstallSnapshot:output_type -> registry.AppendResponse
+	14, // 24: registry.Cluster.Status:output_type -> registry.ClusterStatus
+	16, // [16:25] is the sub-list for method output_type
+	7,  // [7:16] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_api_registry_registry_proto_init() }
@@ -328,13 +1156,14 @@ func file_api_registry_registry_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_registry_registry_proto_rawDesc), len(file_api_registry_registry_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   5,
+			NumEnums:      2,
+			NumMessages:   14,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_api_registry_registry_proto_goTypes,
 		DependencyIndexes: file_api_registry_registry_proto_depIdxs,
+		EnumInfos:         file_api_registry_registry_proto_enumTypes,
 		MessageInfos:      file_api_registry_registry_proto_msgTypes,
 	}.Build()
 	File_api_registry_registry_proto = out.File
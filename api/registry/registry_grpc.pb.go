@@ -39,8 +39,8 @@ type RegistryClient interface {
 	Deregister(ctx context.Context, in *Lease, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// 一次性拉取
 	Discover(ctx context.Context, in *Query, opts ...grpc.CallOption) (*Services, error)
-	// 长连接订阅；服务器发现变化即推送
-	Watch(ctx context.Context, in *Query, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Services], error)
+	// 长连接订阅；先推送全量快照或从 Query.revision 之后续订，之后随注册、注销、过期推送变更事件
+	Watch(ctx context.Context, in *Query, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
 }
 
 type registryClient struct {
@@ -91,13 +91,13 @@ func (c *registryClient) Discover(ctx context.Context, in *Query, opts ...grpc.C
 	return out, nil
 }
 
-func (c *registryClient) Watch(ctx context.Context, in *Query, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Services], error) {
+func (c *registryClient) Watch(ctx context.Context, in *Query, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &Registry_ServiceDesc.Streams[0], Registry_Watch_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &grpc.GenericClientStream[Query, Services]{ClientStream: stream}
+	x := &grpc.GenericClientStream[Query, WatchResponse]{ClientStream: stream}
 	if err := x.ClientStream.SendMsg(in); err != nil {
 		return nil, err
 	}
@@ -108,7 +108,7 @@ func (c *registryClient) Watch(ctx context.Context, in *Query, opts ...grpc.Call
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type Registry_WatchClient = grpc.ServerStreamingClient[Services]
+type Registry_WatchClient = grpc.ServerStreamingClient[WatchResponse]
 
 // RegistryServer is the server API for Registry service.
 // All implementations must embed UnimplementedRegistryServer
@@ -122,8 +122,8 @@ type RegistryServer interface {
 	Deregister(context.Context, *Lease) (*emptypb.Empty, error)
 	// 一次性拉取
 	Discover(context.Context, *Query) (*Services, error)
-	// 长连接订阅；服务器发现变化即推送
-	Watch(*Query, grpc.ServerStreamingServer[Services]) error
+	// 长连接订阅；先推送全量快照或从 Query.revision 之后续订，之后随注册、注销、过期推送变更事件
+	Watch(*Query, grpc.ServerStreamingServer[WatchResponse]) error
 	mustEmbedUnimplementedRegistryServer()
 }
 
@@ -146,7 +146,7 @@ func (UnimplementedRegistryServer) Deregister(context.Context, *Lease) (*emptypb
 func (UnimplementedRegistryServer) Discover(context.Context, *Query) (*Services, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Discover not implemented")
 }
-func (UnimplementedRegistryServer) Watch(*Query, grpc.ServerStreamingServer[Services]) error {
+func (UnimplementedRegistryServer) Watch(*Query, grpc.ServerStreamingServer[WatchResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
 }
 func (UnimplementedRegistryServer) mustEmbedUnimplementedRegistryServer() {}
@@ -247,11 +247,11 @@ func _Registry_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(RegistryServer).Watch(m, &grpc.GenericServerStream[Query, Services]{ServerStream: stream})
+	return srv.(RegistryServer).Watch(m, &grpc.GenericServerStream[Query, WatchResponse]{ServerStream: stream})
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type Registry_WatchServer = grpc.ServerStreamingServer[Services]
+type Registry_WatchServer = grpc.ServerStreamingServer[WatchResponse]
 
 // Registry_ServiceDesc is the grpc.ServiceDesc for Registry service.
 // It's only intended for direct use with grpc.RegisterService,
@@ -286,3 +286,231 @@ var Registry_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "api/registry/registry.proto",
 }
+
+const (
+	Cluster_RequestVote_FullMethodName     = "/registry.Cluster/RequestVote"
+	Cluster_AppendEntries_FullMethodName   = "/registry.Cluster/AppendEntries"
+	Cluster_InstallSnapshot_FullMethodName = "/registry.Cluster/InstallSnapshot"
+	Cluster_Status_FullMethodName          = "/registry.Cluster/Status"
+)
+
+// ClusterClient is the client API for Cluster service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 集群节点之间的选举与日志复制
+type ClusterClient interface {
+	// 候选者请求投票
+	RequestVote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteResponse, error)
+	// leader 复制日志，entries 为空时作为心跳
+	AppendEntries(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendResponse, error)
+	// 跟随者落后于 leader 保留的日志时，直接安装 leader 的注册表快照
+	InstallSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*AppendResponse, error)
+	// 查询节点的集群状态
+	Status(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ClusterStatus, error)
+}
+
+type clusterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClusterClient(cc grpc.ClientConnInterface) ClusterClient {
+	return &clusterClient{cc}
+}
+
+func (c *clusterClient) RequestVote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VoteResponse)
+	err := c.cc.Invoke(ctx, Cluster_RequestVote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) AppendEntries(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendResponse)
+	err := c.cc.Invoke(ctx, Cluster_AppendEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) InstallSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*AppendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendResponse)
+	err := c.cc.Invoke(ctx, Cluster_InstallSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) Status(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ClusterStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterStatus)
+	err := c.cc.Invoke(ctx, Cluster_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClusterServer is the server API for Cluster service.
+// All implementations must embed UnimplementedClusterServer
+// for forward compatibility.
+//
+// 集群节点之间的选举与日志复制
+type ClusterServer interface {
+	// 候选者请求投票
+	RequestVote(context.Context, *VoteRequest) (*VoteResponse, error)
+	// leader 复制日志，entries 为空时作为心跳
+	AppendEntries(context.Context, *AppendRequest) (*AppendResponse, error)
+	// 跟随者落后于 leader 保留的日志时，直接安装 leader 的注册表快照
+	InstallSnapshot(context.Context, *SnapshotRequest) (*AppendResponse, error)
+	// 查询节点的集群状态
+	Status(context.Context, *emptypb.Empty) (*ClusterStatus, error)
+	mustEmbedUnimplementedClusterServer()
+}
+
+// UnimplementedClusterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClusterServer struct{}
+
+func (UnimplementedClusterServer) RequestVote(context.Context, *VoteRequest) (*VoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestVote not implemented")
+}
+func (UnimplementedClusterServer) AppendEntries(context.Context, *AppendRequest) (*AppendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendEntries not implemented")
+}
+func (UnimplementedClusterServer) InstallSnapshot(context.Context, *SnapshotRequest) (*AppendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
+func (UnimplementedClusterServer) Status(context.Context, *emptypb.Empty) (*ClusterStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedClusterServer) mustEmbedUnimplementedClusterServer() {}
+func (UnimplementedClusterServer) testEmbeddedByValue()                 {}
+
+// UnsafeClusterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClusterServer will
+// result in compilation errors.
+type UnsafeClusterServer interface {
+	mustEmbedUnimplementedClusterServer()
+}
+
+func RegisterClusterServer(s grpc.ServiceRegistrar, srv ClusterServer) {
+	// If the following call pancis, it indicates UnimplementedClusterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Cluster_ServiceDesc, srv)
+}
+
+func _Cluster_RequestVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).RequestVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_RequestVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).RequestVote(ctx, req.(*VoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_AppendEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).AppendEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_AppendEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).AppendEntries(ctx, req.(*AppendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_InstallSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).InstallSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_InstallSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).InstallSnapshot(ctx, req.(*SnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).Status(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Cluster_ServiceDesc is the grpc.ServiceDesc for Cluster service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Cluster_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "registry.Cluster",
+	HandlerType: (*ClusterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RequestVote",
+			Handler:    _Cluster_RequestVote_Handler,
+		},
+		{
+			MethodName: "AppendEntries",
+			Handler:    _Cluster_AppendEntries_Handler,
+		},
+		{
+			MethodName: "InstallSnapshot",
+			Handler:    _Cluster_InstallSnapshot_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _Cluster_Status_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/registry/registry.proto",
+}
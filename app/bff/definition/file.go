@@ -0,0 +1,246 @@
+package definition
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore 基于本地目录的定义存储，每个版本保存为 <root>/<id>/v<version>.json
+type FileStore struct {
+	root string
+	mu   sync.RWMutex
+}
+
+// NewFileStore 创建文件存储，目录不存在时自动创建
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create definition dir %s: %v", root, err)
+	}
+	return &FileStore{root: root}, nil
+}
+
+// Create 实现 Store 接口
+func (s *FileStore) Create(def Definition) (Definition, error) {
+	if err := ValidateID(def.ID); err != nil {
+		return Definition{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions, err := s.versionNumbers(def.ID)
+	if err != nil {
+		return Definition{}, err
+	}
+	if len(versions) > 0 {
+		return Definition{}, fmt.Errorf("workflow %s: %w", def.ID, ErrExists)
+	}
+	if err := os.MkdirAll(filepath.Join(s.root, def.ID), 0o755); err != nil {
+		return Definition{}, fmt.Errorf("failed to create definition dir: %v", err)
+	}
+
+	def.Version = 1
+	def.CreatedAt = time.Now()
+	return def, s.write(def)
+}
+
+// Update 实现 Store 接口
+func (s *FileStore) Update(def Definition) (Definition, error) {
+	if err := ValidateID(def.ID); err != nil {
+		return Definition{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions, err := s.versionNumbers(def.ID)
+	if err != nil {
+		return Definition{}, err
+	}
+	if len(versions) == 0 {
+		return Definition{}, fmt.Errorf("workflow %s: %w", def.ID, ErrNotFound)
+	}
+
+	def.Version = versions[len(versions)-1] + 1
+	def.CreatedAt = time.Now()
+	return def, s.write(def)
+}
+
+// Get 实现 Store 接口
+func (s *FileStore) Get(id string, version int) (Definition, error) {
+	if err := ValidateID(id); err != nil {
+		return Definition{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if version == 0 {
+		versions, err := s.versionNumbers(id)
+		if err != nil {
+			return Definition{}, err
+		}
+		if len(versions) == 0 {
+			return Definition{}, fmt.Errorf("workflow %s: %w", id, ErrNotFound)
+		}
+		version = versions[len(versions)-1]
+	}
+	return s.read(id, version)
+}
+
+// Versions 实现 Store 接口
+func (s *FileStore) Versions(id string) ([]Definition, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions, err := s.versionNumbers(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("workflow %s: %w", id, ErrNotFound)
+	}
+	defs := make([]Definition, 0, len(versions))
+	for _, v := range versions {
+		def, err := s.read(id, v)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// List 实现 Store 接口
+func (s *FileStore) List() ([]Summary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read definition dir: %v", err)
+	}
+	summaries := make([]Summary, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || ValidateID(entry.Name()) != nil {
+			continue
+		}
+		versions, err := s.versionNumbers(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			continue
+		}
+		first, err := s.read(entry.Name(), versions[0])
+		if err != nil {
+			return nil, err
+		}
+		latest, err := s.read(entry.Name(), versions[len(versions)-1])
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, Summary{
+			ID:            latest.ID,
+			Name:          latest.Name,
+			Description:   latest.Description,
+			LatestVersion: latest.Version,
+			CreatedAt:     first.CreatedAt,
+			UpdatedAt:     latest.CreatedAt,
+		})
+	}
+	// ReadDir 已按文件名排序
+	return summaries, nil
+}
+
+// Delete 实现 Store 接口
+func (s *FileStore) Delete(id string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions, err := s.versionNumbers(id)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("workflow %s: %w", id, ErrNotFound)
+	}
+	if err := os.RemoveAll(filepath.Join(s.root, id)); err != nil {
+		return fmt.Errorf("failed to delete workflow %s: %v", id, err)
+	}
+	return nil
+}
+
+// versionNumbers 升序返回定义已保存的版本号，定义不存在时返回空。调用方需持有 s.mu。
+func (s *FileStore) versionNumbers(id string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.root, id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow %s: %v", id, err)
+	}
+	var versions []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "v") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		v, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "v"), ".json"))
+		if err != nil || v <= 0 {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// path 版本文件路径
+func (s *FileStore) path(id string, version int) string {
+	return filepath.Join(s.root, id, fmt.Sprintf("v%d.json", version))
+}
+
+// read 读取一个版本。调用方需持有 s.mu。
+func (s *FileStore) read(id string, version int) (Definition, error) {
+	data, err := os.ReadFile(s.path(id, version))
+	if os.IsNotExist(err) {
+		return Definition{}, fmt.Errorf("workflow %s version %d: %w", id, version, ErrNotFound)
+	}
+	if err != nil {
+		return Definition{}, fmt.Errorf("failed to read workflow %s version %d: %v", id, version, err)
+	}
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return Definition{}, fmt.Errorf("failed to decode workflow %s version %d: %v", id, version, err)
+	}
+	return def, nil
+}
+
+// write 先写临时文件再改名，避免留下写了一半的版本。调用方需持有 s.mu。
+func (s *FileStore) write(def Definition) error {
+	data, err := json.MarshalIndent(def, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode workflow %s: %v", def.ID, err)
+	}
+	path := s.path(def.ID, def.Version)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write workflow %s: %v", def.ID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write workflow %s: %v", def.ID, err)
+	}
+	return nil
+}
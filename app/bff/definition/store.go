@@ -0,0 +1,80 @@
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"zflow/app/bff/model"
+)
+
+var (
+	// ErrNotFound 定义或版本不存在
+	ErrNotFound = model.ErrDefinitionNotFound
+	// ErrExists 创建时定义 ID 已存在
+	ErrExists = errors.New("workflow definition already exists")
+	// ErrInvalidID 定义 ID 不合法
+	ErrInvalidID = errors.New("invalid workflow definition id")
+)
+
+// idPattern 定义 ID 只允许字母、数字和 _ . -，且不以符号开头，可直接用作文件名
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// Definition 工作流定义的一个版本，保存后不可修改
+type Definition struct {
+	ID          string            `json:"id"`
+	Version     int               `json:"version"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Workflow    model.RawWorkflow `json:"workflow"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// Summary 工作流定义的概要，名称与描述取自最新版本
+type Summary struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	LatestVersion int       `json:"latest_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Store 工作流定义存储。版本号从 1 开始递增，已保存的版本不可修改。
+type Store interface {
+	// Create 以版本 1 创建定义，ID 已存在时返回 ErrExists
+	Create(def Definition) (Definition, error)
+	// Update 保存为定义的下一个版本，定义不存在时返回 ErrNotFound
+	Update(def Definition) (Definition, error)
+	// Get 获取指定版本，version 为 0 时返回最新版本
+	Get(id string, version int) (Definition, error)
+	// Versions 按版本号升序返回定义的所有版本
+	Versions(id string) ([]Definition, error)
+	// List 按 ID 排序返回所有定义的概要
+	List() ([]Summary, error)
+	// Delete 删除定义的所有版本
+	Delete(id string) error
+}
+
+// ValidateID 校验定义 ID
+func ValidateID(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("%q: %w", id, ErrInvalidID)
+	}
+	return nil
+}
+
+// Source 将 Store 适配为 model.DefinitionSource，供子工作流节点加载定义
+type Source struct {
+	Store Store
+}
+
+// GetDefinition 实现 model.DefinitionSource 接口
+func (s Source) GetDefinition(id string, version int) (model.RawWorkflow, error) {
+	def, err := s.Store.Get(id, version)
+	if err != nil {
+		return model.RawWorkflow{}, err
+	}
+	return def.Workflow, nil
+}
@@ -1,6 +1,8 @@
 package global
 
 import (
+	"time"
+
 	"zflow/utils/cache"
 	"zflow/utils/selector"
 )
@@ -11,6 +13,44 @@ var Cache *cache.Cache
 // LoadBalance 负载均衡
 var LoadBalance *selector.LocalLB
 
+// 节点并发限制
+var (
+	MaxConcurrency = 16 // 全局同时运行的节点数
+	MaxPerService  = 4  // 单个服务同时运行的节点数
+)
+
+// DefinitionDir 工作流定义的本地存储目录
+var DefinitionDir = "data/workflow_definitions"
+
+// HistoryDir 运行记录的本地存储目录
+var HistoryDir = "data/run_history"
+
+// 节点输出缓存
+var (
+	MemoBackend        = "memory"          // memory / file，为空时不缓存
+	MemoDir            = "data/node_cache" // file 后端的存储目录
+	MemoMaxBytes int64 = 256 << 20         // 缓存总大小上限，超过后按最近最少使用淘汰
+)
+
+// 密钥
+var (
+	SecretFile      = "data/secrets/secrets.json" // 加密的密钥文件，为空时不使用文件存储
+	SecretKeyFile   = "data/secrets/secret.key"   // 密钥文件的加密密钥，不存在时自动生成
+	SecretKeyEnv    = "ZFLOW_SECRET_KEY"          // 设置该环境变量（base64 编码的 32 字节）时代替 SecretKeyFile
+	SecretEnvPrefix = "ZFLOW_SECRET_"             // 从环境变量读取密钥的前缀，为空时不读取环境变量
+)
+
+// bff 主动健康检查，HealthInterval 为 0 时只使用注册中心探测的健康状态
+var (
+	HealthInterval          time.Duration = 0           // 探测间隔
+	HealthTimeout                         = time.Second // 单次探测超时
+	HealthFailureThreshold                = 3           // 连续失败多少次排除该实例
+	HealthRecoveryThreshold               = 2           // 被排除的实例连续成功多少次恢复
+)
+
+// RunRetention 已结束的运行在内存中保留的时长，运行记录持久化后仍可查询
+var RunRetention = time.Hour
+
 func init() {
 	// Cache 初始化缓存
 	Cache = cache.NewCache()
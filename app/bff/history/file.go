@@ -0,0 +1,145 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// FileStore 基于本地目录的运行记录存储，每个运行保存为 <root>/<run_id>.json，
+// 打开时加载所有概要到内存中用于列表与过滤，无法读取的记录改名为 <run_id>.json.corrupt 隔离
+type FileStore struct {
+	root string
+
+	mu        sync.RWMutex
+	summaries map[string]Summary
+}
+
+// corruptSuffix 隔离的损坏记录的文件名后缀
+const corruptSuffix = ".corrupt"
+
+// NewFileStore 打开文件存储，目录不存在时自动创建
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create run history dir %s: %v", root, err)
+	}
+	s := &FileStore{root: root, summaries: make(map[string]Summary)}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history dir: %v", err)
+	}
+	for _, entry := range entries {
+		runID, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || validRunID(runID) != nil {
+			continue
+		}
+		// 单条记录损坏不影响启动，保留原文件便于排查
+		rec, err := s.read(runID)
+		if err != nil {
+			log.Printf("隔离无法读取的运行记录: %v", err)
+			if err := os.Rename(s.path(runID), s.path(runID)+corruptSuffix); err != nil {
+				log.Printf("隔离运行记录 %s 失败: %v", runID, err)
+			}
+			continue
+		}
+		s.summaries[runID] = rec.Summary()
+	}
+	return s, nil
+}
+
+// Save 实现 Store 接口
+func (s *FileStore) Save(rec Record) error {
+	if err := validRunID(rec.RunID); err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode run %s: %v", rec.RunID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 先写临时文件再改名，避免留下写了一半的记录
+	path := s.path(rec.RunID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write run %s: %v", rec.RunID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write run %s: %v", rec.RunID, err)
+	}
+	s.summaries[rec.RunID] = rec.Summary()
+	return nil
+}
+
+// Get 实现 Store 接口
+func (s *FileStore) Get(runID string) (Record, error) {
+	if validRunID(runID) != nil {
+		return Record{}, fmt.Errorf("run %s: %w", runID, ErrNotFound)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.read(runID)
+}
+
+// List 实现 Store 接口
+func (s *FileStore) List(filter Filter) ([]Summary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Summary, 0)
+	for _, summary := range s.summaries {
+		if filter.Match(summary) {
+			list = append(list, summary)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].RunID < list[j].RunID
+	})
+	if filter.Limit > 0 && len(list) > filter.Limit {
+		list = list[:filter.Limit]
+	}
+	return list, nil
+}
+
+// path 运行记录文件路径
+func (s *FileStore) path(runID string) string {
+	return filepath.Join(s.root, runID+".json")
+}
+
+// read 读取运行记录
+func (s *FileStore) read(runID string) (Record, error) {
+	data, err := os.ReadFile(s.path(runID))
+	if os.IsNotExist(err) {
+		return Record{}, fmt.Errorf("run %s: %w", runID, ErrNotFound)
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to read run %s: %v", runID, err)
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Record{}, fmt.Errorf("failed to decode run %s: %v", runID, err)
+	}
+	return rec, nil
+}
+
+// validRunID 运行 ID 由 uuid 生成，校验后可直接用作文件名
+func validRunID(runID string) error {
+	if _, err := uuid.Parse(runID); err != nil {
+		return fmt.Errorf("invalid run id %q", runID)
+	}
+	return nil
+}
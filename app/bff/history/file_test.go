@@ -0,0 +1,40 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewFileStoreQuarantinesCorruptRecords(t *testing.T) {
+	dir := t.TempDir()
+	good, bad := uuid.NewString(), uuid.NewString()
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(Record{RunID: good, WorkflowID: "wf", Status: "success"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, bad+".json"), []byte(`{"run_id":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	list, _ := s.List(Filter{})
+	if len(list) != 1 || list[0].RunID != good {
+		t.Fatalf("List() = %+v, want only %s", list, good)
+	}
+	if _, err := s.Get(bad); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(bad) error = %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, bad+".json"+corruptSuffix)); err != nil {
+		t.Fatalf("corrupt record not quarantined: %v", err)
+	}
+}
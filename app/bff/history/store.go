@@ -0,0 +1,105 @@
+package history
+
+import (
+	"errors"
+	"time"
+
+	"zflow/app/bff/model"
+)
+
+// ErrNotFound 运行记录不存在
+var ErrNotFound = errors.New("run record not found")
+
+// LogEntry 运行期间通过 Context.Log 输出的一条日志
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	NodeID  string    `json:"node_id,omitempty"` // 节点日志所属的节点，运行日志为空
+	Message string    `json:"message"`
+}
+
+// Record 一次运行的完整记录
+type Record struct {
+	RunID       string                            `json:"run_id"`
+	WorkflowID  string                            `json:"workflow_id"`
+	Version     int                               `json:"version,omitempty"`
+	ResumedFrom string                            `json:"resumed_from,omitempty"` // 续跑的原运行 ID
+	Definition  model.RawWorkflow                 `json:"definition"`             // 运行时的工作流定义快照
+	Vars        map[string]interface{}            `json:"vars,omitempty"`
+	Status      string                            `json:"status"`
+	Error       string                            `json:"error,omitempty"`
+	CreatedAt   time.Time                         `json:"created_at"`
+	StartedAt   *time.Time                        `json:"started_at,omitempty"`
+	FinishedAt  *time.Time                        `json:"finished_at,omitempty"`
+	Nodes       map[string]map[string]interface{} `json:"nodes,omitempty"` // 同 CollectWorkflowResults 的 nodes
+	Logs        []LogEntry                        `json:"logs,omitempty"`
+	Outputs     map[string]map[string][]byte      `json:"raw_outputs,omitempty"`    // 成功节点的原始输出，续跑时复用；nodes 中的输出已隐藏密钥且转为文本
+	Redacted    []string                          `json:"redacted_nodes,omitempty"` // 输出含有密钥值的成功节点，不保存原始输出，续跑时重新执行
+}
+
+// Summary 运行记录的概要，用于列表
+type Summary struct {
+	RunID       string     `json:"run_id"`
+	WorkflowID  string     `json:"workflow_id"`
+	Version     int        `json:"version,omitempty"`
+	ResumedFrom string     `json:"resumed_from,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Summary 返回记录的概要
+func (r *Record) Summary() Summary {
+	return Summary{
+		RunID:       r.RunID,
+		WorkflowID:  r.WorkflowID,
+		Version:     r.Version,
+		ResumedFrom: r.ResumedFrom,
+		Status:      r.Status,
+		Error:       r.Error,
+		CreatedAt:   r.CreatedAt,
+		StartedAt:   r.StartedAt,
+		FinishedAt:  r.FinishedAt,
+	}
+}
+
+// Filter 列出运行记录的过滤条件，零值表示不过滤
+type Filter struct {
+	WorkflowID  string
+	Status      string
+	ResumedFrom string    // 续跑自指定运行
+	From        time.Time // 创建时间不早于 From
+	To          time.Time // 创建时间早于 To
+	Limit       int       // 最多返回的条数，<=0 时不限制
+}
+
+// Match 判断概要是否满足过滤条件
+func (f Filter) Match(s Summary) bool {
+	if f.WorkflowID != "" && s.WorkflowID != f.WorkflowID {
+		return false
+	}
+	if f.Status != "" && s.Status != f.Status {
+		return false
+	}
+	if f.ResumedFrom != "" && s.ResumedFrom != f.ResumedFrom {
+		return false
+	}
+	if !f.From.IsZero() && s.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !s.CreatedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Store 运行记录存储
+type Store interface {
+	// Save 保存运行记录，同一运行重复保存时覆盖
+	Save(rec Record) error
+	// Get 获取运行记录，不存在时返回 ErrNotFound
+	Get(runID string) (Record, error)
+	// List 按创建时间倒序返回满足条件的运行概要
+	List(filter Filter) ([]Summary, error)
+}
@@ -0,0 +1,171 @@
+package memo
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keyPattern 缓存键为 sha256 的十六进制摘要，校验后可直接用作文件名
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// FileStore 基于本地目录的缓存存储，每个缓存项保存为 <root>/<key>.json，
+// 打开时加载索引到内存中，已过期或无法读取的缓存项直接删除
+type FileStore struct {
+	root string
+
+	mu    sync.Mutex
+	index *index
+}
+
+// NewFileStore 打开文件缓存，目录不存在时自动创建，maxBytes<=0 时不限制大小
+func NewFileStore(root string, maxBytes int64) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create node cache dir %s: %v", root, err)
+	}
+	s := &FileStore{root: root, index: newIndex(maxBytes)}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node cache dir: %v", err)
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		key, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || !keyPattern.MatchString(key) {
+			continue
+		}
+		// 缓存可以重新计算，损坏的缓存项不影响启动
+		e, err := s.read(key)
+		if err != nil {
+			log.Printf("删除无法读取的节点缓存: %v", err)
+			os.Remove(s.path(key))
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			log.Printf("删除无法读取的节点缓存: %v", err)
+			os.Remove(s.path(key))
+			continue
+		}
+		m := &meta{nodeType: e.NodeType, size: e.Size(), expiresAt: e.ExpiresAt, lastUsed: info.ModTime()}
+		if m.expired(now) {
+			os.Remove(s.path(key))
+			continue
+		}
+		s.index.add(key, m)
+	}
+	return s, nil
+}
+
+// Get 实现 Store 接口
+func (s *FileStore) Get(key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	m, ok := s.index.entries[key]
+	if !ok || m.expired(now) {
+		if ok {
+			s.index.remove(key)
+			os.Remove(s.path(key))
+		}
+		s.index.misses++
+		return Entry{}, fmt.Errorf("key %s: %w", key, ErrNotFound)
+	}
+
+	e, err := s.read(key)
+	if err != nil {
+		return Entry{}, err
+	}
+	m.lastUsed = now
+	s.index.hits++
+	// 更新修改时间，重启后仍按最近使用时间淘汰
+	os.Chtimes(s.path(key), now, now)
+	return e, nil
+}
+
+// Put 实现 Store 接口
+func (s *FileStore) Put(e Entry) error {
+	if !keyPattern.MatchString(e.Key) {
+		return fmt.Errorf("invalid cache key %q", e.Key)
+	}
+	size := e.Size()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry %s: %v", e.Key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.index.fits(size) {
+		return fmt.Errorf("key %s (%d bytes): %w", e.Key, size, ErrTooLarge)
+	}
+	now := time.Now()
+	s.index.remove(e.Key)
+	for _, key := range s.index.evict(size, now) {
+		os.Remove(s.path(key))
+	}
+
+	// 先写临时文件再改名，避免留下写了一半的缓存
+	path := s.path(e.Key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cache entry %s: %v", e.Key, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write cache entry %s: %v", e.Key, err)
+	}
+	s.index.add(e.Key, &meta{nodeType: e.NodeType, size: size, expiresAt: e.ExpiresAt, lastUsed: now})
+	return nil
+}
+
+// Purge 实现 Store 接口
+func (s *FileStore) Purge(f Filter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := s.index.purge(f)
+	for _, key := range purged {
+		if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to remove cache entry %s: %v", key, err)
+		}
+	}
+	return len(purged), nil
+}
+
+// Stats 实现 Store 接口
+func (s *FileStore) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index.stats()
+}
+
+// path 缓存项文件路径
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.root, key+".json")
+}
+
+// read 读取缓存项
+func (s *FileStore) read(key string) (Entry, error) {
+	data, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return Entry{}, fmt.Errorf("key %s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to read cache entry %s: %v", key, err)
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, fmt.Errorf("failed to decode cache entry %s: %v", key, err)
+	}
+	return e, nil
+}
@@ -0,0 +1,39 @@
+package memo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFileStoreSkipsCorruptEntries(t *testing.T) {
+	dir := t.TempDir()
+	good := strings.Repeat("a", 64)
+	bad := strings.Repeat("b", 64)
+	s, err := NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(Entry{Key: good, NodeType: "t", Outputs: map[string][]byte{"out": []byte("1")}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, bad+".json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if _, err := s.Get(good); err != nil {
+		t.Fatalf("Get(good) error = %v", err)
+	}
+	if _, err := s.Get(bad); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(bad) error = %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, bad+".json")); !os.IsNotExist(err) {
+		t.Fatalf("corrupt entry not removed: %v", err)
+	}
+}
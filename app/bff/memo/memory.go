@@ -0,0 +1,78 @@
+package memo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryStore 基于内存的缓存存储，进程重启后清空
+type MemoryStore struct {
+	mu      sync.Mutex
+	index   *index
+	outputs map[string]Entry
+}
+
+// NewMemoryStore 创建内存缓存，maxBytes<=0 时不限制大小
+func NewMemoryStore(maxBytes int64) *MemoryStore {
+	return &MemoryStore{index: newIndex(maxBytes), outputs: make(map[string]Entry)}
+}
+
+// Get 实现 Store 接口
+func (s *MemoryStore) Get(key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	m, ok := s.index.entries[key]
+	if !ok || m.expired(now) {
+		if ok {
+			s.index.remove(key)
+			delete(s.outputs, key)
+		}
+		s.index.misses++
+		return Entry{}, fmt.Errorf("key %s: %w", key, ErrNotFound)
+	}
+	m.lastUsed = now
+	s.index.hits++
+	return s.outputs[key], nil
+}
+
+// Put 实现 Store 接口
+func (s *MemoryStore) Put(e Entry) error {
+	size := e.Size()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.index.fits(size) {
+		return fmt.Errorf("key %s (%d bytes): %w", e.Key, size, ErrTooLarge)
+	}
+	now := time.Now()
+	s.index.remove(e.Key)
+	for _, key := range s.index.evict(size, now) {
+		delete(s.outputs, key)
+	}
+	s.index.add(e.Key, &meta{nodeType: e.NodeType, size: size, expiresAt: e.ExpiresAt, lastUsed: now})
+	s.outputs[e.Key] = e
+	return nil
+}
+
+// Purge 实现 Store 接口
+func (s *MemoryStore) Purge(f Filter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := s.index.purge(f)
+	for _, key := range purged {
+		delete(s.outputs, key)
+	}
+	return len(purged), nil
+}
+
+// Stats 实现 Store 接口
+func (s *MemoryStore) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index.stats()
+}
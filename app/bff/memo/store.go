@@ -0,0 +1,175 @@
+package memo
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrNotFound 缓存不存在或已过期
+	ErrNotFound = errors.New("cache entry not found")
+	// ErrTooLarge 缓存项超过了缓存总大小上限
+	ErrTooLarge = errors.New("cache entry exceeds size limit")
+)
+
+// Entry 一个节点执行结果的缓存，以缓存键寻址
+type Entry struct {
+	Key       string            `json:"key"`
+	NodeType  string            `json:"node_type"`
+	Outputs   map[string][]byte `json:"outputs"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at,omitempty"` // 零值表示不过期
+}
+
+// Size 缓存项占用的字节数，按端口名与输出数据计算
+func (e *Entry) Size() int64 {
+	var size int64
+	for port, data := range e.Outputs {
+		size += int64(len(port) + len(data))
+	}
+	return size
+}
+
+// Filter 清理缓存的条件，零值表示清理全部
+type Filter struct {
+	NodeType string
+	Key      string
+}
+
+// Stats 缓存使用情况
+type Stats struct {
+	Entries  int   `json:"entries"`
+	Bytes    int64 `json:"bytes"`
+	MaxBytes int64 `json:"max_bytes"`
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+}
+
+// Store 节点输出缓存存储
+type Store interface {
+	// Get 获取缓存，不存在或已过期时返回 ErrNotFound
+	Get(key string) (Entry, error)
+	// Put 保存缓存，总大小超过上限时按最近最少使用淘汰
+	Put(e Entry) error
+	// Purge 清理满足条件的缓存，返回清理的条数
+	Purge(f Filter) (int, error)
+	// Stats 返回缓存使用情况
+	Stats() Stats
+}
+
+// meta 缓存项的索引信息，两种存储共用
+type meta struct {
+	nodeType  string
+	size      int64
+	expiresAt time.Time
+	lastUsed  time.Time
+}
+
+// expired 判断缓存项是否已过期
+func (m *meta) expired(now time.Time) bool {
+	return !m.expiresAt.IsZero() && !now.Before(m.expiresAt)
+}
+
+// match 判断缓存项是否满足清理条件
+func (f Filter) match(key string, m *meta) bool {
+	if f.Key != "" && key != f.Key {
+		return false
+	}
+	return f.NodeType == "" || m.nodeType == f.NodeType
+}
+
+// index 缓存项的内存索引，负责大小统计与淘汰，调用方负责加锁
+type index struct {
+	maxBytes int64
+	bytes    int64
+	entries  map[string]*meta
+	hits     int64
+	misses   int64
+}
+
+// newIndex 创建索引，maxBytes<=0 时不限制大小
+func newIndex(maxBytes int64) *index {
+	return &index{maxBytes: maxBytes, entries: make(map[string]*meta)}
+}
+
+// add 加入或替换缓存项
+func (ix *index) add(key string, m *meta) {
+	ix.remove(key)
+	ix.entries[key] = m
+	ix.bytes += m.size
+}
+
+// remove 移除缓存项
+func (ix *index) remove(key string) {
+	if m, ok := ix.entries[key]; ok {
+		ix.bytes -= m.size
+		delete(ix.entries, key)
+	}
+}
+
+// fits 判断大小为 size 的缓存项能否放入缓存
+func (ix *index) fits(size int64) bool {
+	return ix.maxBytes <= 0 || size <= ix.maxBytes
+}
+
+// evict 为大小为 size 的新缓存项腾出空间：先淘汰已过期的，再按最近最少使用淘汰，返回被淘汰的键
+func (ix *index) evict(size int64, now time.Time) []string {
+	var evicted []string
+	for key, m := range ix.entries {
+		if m.expired(now) {
+			evicted = append(evicted, key)
+		}
+	}
+	for _, key := range evicted {
+		ix.remove(key)
+	}
+	if ix.maxBytes <= 0 || ix.bytes+size <= ix.maxBytes {
+		return evicted
+	}
+
+	keys := make([]string, 0, len(ix.entries))
+	for key := range ix.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := ix.entries[keys[i]], ix.entries[keys[j]]
+		if !a.lastUsed.Equal(b.lastUsed) {
+			return a.lastUsed.Before(b.lastUsed)
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		if ix.bytes+size <= ix.maxBytes {
+			break
+		}
+		ix.remove(key)
+		evicted = append(evicted, key)
+	}
+	return evicted
+}
+
+// purge 移除满足条件的缓存项，返回被移除的键
+func (ix *index) purge(f Filter) []string {
+	var purged []string
+	for key, m := range ix.entries {
+		if f.match(key, m) {
+			purged = append(purged, key)
+		}
+	}
+	for _, key := range purged {
+		ix.remove(key)
+	}
+	return purged
+}
+
+// stats 返回缓存使用情况
+func (ix *index) stats() Stats {
+	return Stats{
+		Entries:  len(ix.entries),
+		Bytes:    ix.bytes,
+		MaxBytes: ix.maxBytes,
+		Hits:     ix.hits,
+		Misses:   ix.misses,
+	}
+}
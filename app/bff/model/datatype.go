@@ -0,0 +1,149 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 端口数据类型
+const (
+	DataKindInt    = "int"
+	DataKindFloat  = "float"
+	DataKindString = "string"
+	DataKindBool   = "bool"
+	DataKindJSON   = "json"
+	DataKindFile   = "file"
+)
+
+// DataType 端口上传递的数据类型，端口未声明数据类型时可以连接任意端口
+type DataType struct {
+	Kind   string `json:"kind"`             // int / float / string / bool / json / file
+	Schema string `json:"schema,omitempty"` // kind 为 json 时可选的 JSON Schema，支持的关键字见 schema.go
+	MIME   string `json:"mime,omitempty"`   // kind 为 file 时可选的 MIME 类型，支持 image/* 形式
+}
+
+// Validate 校验数据类型定义
+func (t *DataType) Validate() error {
+	switch t.Kind {
+	case DataKindInt, DataKindFloat, DataKindString, DataKindBool:
+	case DataKindJSON:
+		if t.Schema != "" {
+			if _, err := parseSchema(t.Schema); err != nil {
+				return err
+			}
+		}
+	case DataKindFile:
+		if t.MIME != "" && !strings.Contains(t.MIME, "/") {
+			return fmt.Errorf("invalid mime type %s", t.MIME)
+		}
+	default:
+		return fmt.Errorf("unknown data type %s", t.Kind)
+	}
+	if t.Schema != "" && t.Kind != DataKindJSON {
+		return fmt.Errorf("schema is only allowed for json data type")
+	}
+	if t.MIME != "" && t.Kind != DataKindFile {
+		return fmt.Errorf("mime is only allowed for file data type")
+	}
+	return nil
+}
+
+// String 返回类型的可读形式
+func (t *DataType) String() string {
+	if t == nil {
+		return "any"
+	}
+	if t.MIME != "" {
+		return t.Kind + "(" + t.MIME + ")"
+	}
+	return t.Kind
+}
+
+// Check 校验数据是否符合类型，用于节点上预设的输入；json 类型声明了 Schema 时同时按 Schema 校验
+func (t *DataType) Check(data []byte) error {
+	if t == nil {
+		return nil
+	}
+	s := strings.TrimSpace(string(data))
+	var err error
+	switch t.Kind {
+	case DataKindInt:
+		_, err = strconv.ParseInt(s, 10, 64)
+	case DataKindFloat:
+		_, err = strconv.ParseFloat(s, 64)
+	case DataKindBool:
+		_, err = strconv.ParseBool(s)
+	case DataKindJSON:
+		var value interface{}
+		if json.Unmarshal(data, &value) != nil {
+			err = fmt.Errorf("invalid JSON")
+			break
+		}
+		if t.Schema != "" {
+			var schema interface{}
+			if schema, err = parseSchema(t.Schema); err == nil {
+				err = matchSchema(schema, value, "$")
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("value is not %s: %v", t.Kind, err)
+	}
+	return nil
+}
+
+// AssignableTo 判断 t 类型的输出能否连接到 target 类型的输入。
+// 任一端未声明类型时总是兼容；int 可以连接 float；
+// int / float / bool 的文本同时是合法 JSON，可以连接没有 Schema 的 json；
+// 两端都声明 Schema 时要求 Schema 一致；file 按 MIME 匹配。
+func (t *DataType) AssignableTo(target *DataType) bool {
+	if t == nil || target == nil {
+		return true
+	}
+	switch {
+	case t.Kind == target.Kind:
+	case t.Kind == DataKindInt && target.Kind == DataKindFloat:
+		return true
+	case target.Kind == DataKindJSON && target.Schema == "" &&
+		(t.Kind == DataKindInt || t.Kind == DataKindFloat || t.Kind == DataKindBool):
+		return true
+	default:
+		return false
+	}
+
+	switch t.Kind {
+	case DataKindJSON:
+		return t.Schema == "" || target.Schema == "" || sameJSON(t.Schema, target.Schema)
+	case DataKindFile:
+		return mimeMatch(t.MIME, target.MIME)
+	}
+	return true
+}
+
+// sameJSON 判断两个 JSON 文本是否语义相同
+func sameJSON(a, b string) bool {
+	if bytes.Equal([]byte(a), []byte(b)) {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// mimeMatch 判断 source 的 MIME 类型是否被 target 接受，target 可以是 type/* 或 */*
+func mimeMatch(source, target string) bool {
+	// 未声明 MIME 的一端视为任意文件
+	if source == "" || target == "" || target == "*/*" || source == target {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(target, "/*"); ok {
+		return strings.HasPrefix(source, prefix+"/")
+	}
+	return false
+}
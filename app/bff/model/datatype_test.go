@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDataTypeCheckSchema(t *testing.T) {
+	const schema = `{"type": "object", "required": ["name"], "additionalProperties": false, "properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"tags": {"type": "array", "items": {"enum": ["a", "b"]}, "maxItems": 2}
+	}}`
+	dt := &DataType{Kind: DataKindJSON, Schema: schema}
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"符合 Schema", `{"name": "bob", "age": 3, "tags": ["a"]}`, ""},
+		{"缺少必填属性", `{"age": 3}`, "missing required property name"},
+		{"类型不符", `{"name": "bob", "age": 1.5}`, "$.age should be integer"},
+		{"小于最小值", `{"name": "bob", "age": -1}`, "$.age should be at least 0"},
+		{"不在枚举中", `{"name": "bob", "tags": ["c"]}`, "$.tags[0] is not one of the enum values"},
+		{"数组过长", `{"name": "bob", "tags": ["a", "b", "a"]}`, "$.tags length should be at most 2"},
+		{"多余的属性", `{"name": "bob", "extra": 1}`, "$.extra is not allowed"},
+		{"字符串过短", `{"name": ""}`, "$.name length should be at least 1"},
+		{"不是对象", `[1]`, "$ should be object"},
+		{"不是 JSON", `{`, "invalid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dt.Check([]byte(tt.data))
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Fatalf("Check(%s) error = %v, want %q", tt.data, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataTypeValidateSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"合法", `{"type": ["string", "null"], "pattern": "^a"}`, ""},
+		{"布尔 Schema", `true`, ""},
+		{"不是 JSON", `{`, "not valid JSON"},
+		{"不是对象", `[]`, "must be an object or boolean"},
+		{"未知类型", `{"properties": {"a": {"type": "date"}}}`, "$.properties.a.type: unknown type date"},
+		{"非法正则", `{"pattern": "("}`, "$.pattern"},
+		{"required 不是字符串数组", `{"required": [1]}`, "array of strings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&DataType{Kind: DataKindJSON, Schema: tt.schema}).Validate()
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// 执行事件类型
+const (
+	EventRunStarted    = "run_started"
+	EventRunFinished   = "run_finished"
+	EventNodeQueued    = "node_queued"
+	EventNodeRunning   = "node_running"
+	EventNodeRetrying  = "node_retrying"
+	EventNodeSucceeded = "node_succeeded"
+	EventNodeFailed    = "node_failed" // 包括 failed / timeout / cancelled，具体见 State
+	EventNodeSkipped   = "node_skipped"
+	EventNodeProgress  = "node_progress"
+	EventLog           = "log"
+)
+
+// Event 执行过程中的结构化事件
+type Event struct {
+	Seq     int64     `json:"seq"` // 运行内从 1 开始递增，由事件的接收方分配
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	RunID   string    `json:"run_id,omitempty"`
+	NodeID  string    `json:"node_id,omitempty"` // 子工作流中的节点为 父节点ID/节点ID
+	State   string    `json:"state,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Percent *float64  `json:"percent,omitempty"` // node_progress 事件的完成百分比
+}
+
+// Progress 节点最近一次上报的进度
+type Progress struct {
+	Percent float64 `json:"percent"`
+	Message string  `json:"message,omitempty"`
+}
+
+// EventSink 可选接口，Context 实现该接口时调度器通过它发出节点事件
+type EventSink interface {
+	Emit(e Event)
+}
+
+// nodeEventTypes 节点状态对应的事件类型
+var nodeEventTypes = map[string]string{
+	"queued":    EventNodeQueued,
+	"running":   EventNodeRunning,
+	"retrying":  EventNodeRetrying,
+	"success":   EventNodeSucceeded,
+	"failed":    EventNodeFailed,
+	"timeout":   EventNodeFailed,
+	"cancelled": EventNodeFailed,
+	"skipped":   EventNodeSkipped,
+}
+
+// emitNodeEvent 发出节点状态变化事件，调用方不能持有 wf.mu
+func (wf *Workflow) emitNodeEvent(ctx Context, nodeID, state, errMsg string) {
+	sink, ok := ctx.(EventSink)
+	if !ok {
+		return
+	}
+	sink.Emit(Event{
+		Type:   nodeEventTypes[state],
+		Time:   time.Now(),
+		NodeID: wf.path + nodeID,
+		State:  state,
+		Error:  errMsg,
+	})
+}
+
+// nodeLogger 可选接口，Context 实现该接口时节点日志带上节点 ID
+type nodeLogger interface {
+	logNode(nodeID, msg string)
+}
+
+type nodeScopeKey struct{}
+
+// nodeScope 正在执行的节点，随 context.Context 传给 runFunc，用于归属节点的日志与进度
+type nodeScope struct {
+	wf   *Workflow
+	node *Node
+}
+
+// withNodeScope 标记 ctx 属于 wf 中的节点 node
+func withNodeScope(ctx context.Context, wf *Workflow, node *Node) context.Context {
+	return context.WithValue(ctx, nodeScopeKey{}, nodeScope{wf: wf, node: node})
+}
+
+// nodeLog 输出 runCtx 所属节点的日志，runCtx 不属于任何节点时作为运行日志输出
+func nodeLog(ctx Context, runCtx context.Context, msg string) {
+	scope, ok := runCtx.Value(nodeScopeKey{}).(nodeScope)
+	logger, isNodeLogger := ctx.(nodeLogger)
+	if !ok || !isNodeLogger {
+		ctx.Log(msg)
+		return
+	}
+	logger.logNode(scope.wf.path+scope.node.ID, msg)
+}
+
+// nodeProgress 记录 runCtx 所属节点的进度并发出 node_progress 事件，percent 限制在 0~100
+func nodeProgress(ctx Context, runCtx context.Context, percent float64, msg string) {
+	percent = min(max(percent, 0), 100)
+	e := Event{Type: EventNodeProgress, Time: time.Now(), Message: msg, Percent: &percent}
+	if scope, ok := runCtx.Value(nodeScopeKey{}).(nodeScope); ok {
+		scope.wf.mu.Lock()
+		scope.node.Progress = &Progress{Percent: percent, Message: msg}
+		scope.wf.mu.Unlock()
+		e.NodeID = scope.wf.path + scope.node.ID
+	}
+	if sink, ok := ctx.(EventSink); ok {
+		sink.Emit(e)
+	}
+}
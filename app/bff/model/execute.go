@@ -1,12 +1,30 @@
 package model
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "zflow/api/base"
+	"zflow/app/bff/global"
+	"zflow/app/bff/memo"
+	"zflow/app/bff/secret"
+	"zflow/utils/blob"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 // Operation 与 Node 一一对应，真正执行工作。
+// 分支节点通过只输出部分端口来选择激活哪些下游分支。
 type Operation interface {
 	Execute(context Context, inputs map[string][]byte, vars map[string]interface{}) (map[string][]byte, error)
 }
@@ -14,96 +32,137 @@ type Operation interface {
 // Context 是运行期给 Operation 的最少上下文
 type Context interface {
 	Log(msg string)
+	// Progress 上报节点的完成百分比（0~100）与说明
+	Progress(percent float64, msg string)
+	// Context 返回本次执行的 context.Context，Operation 应在其 Done 后尽快返回
+	Context() context.Context
 }
 
-// ExecutionContext 实现 Context 接口，提供完整的执行上下文
+// ExecutionContext 实现 Context 接口，提供完整的执行上下文，节点由本地注入的 Operation 执行；
+// ExecutionGRPCContext 嵌入它并改为分发到远程服务
 type ExecutionContext struct {
 	Workflow *Workflow
+	RunID    string // 运行 ID，表达式中以 ${run.id} 引用
 	Logger   func(msg string)
+	OnEvent  func(e Event) // 接收执行事件，日志也作为 log 事件发出
 	Vars     map[string]interface{}
+	Limits   Limits
+	Ctx      context.Context
+	Memo     memo.Store       // 可缓存节点的输出缓存，为空时不缓存
+	Secrets  secret.Provider  // ${secrets.名称} 引用的密钥来源，为空时不能引用密钥
+	Redactor *secret.Redactor // 记录解析过的密钥值，由调用方在对外输出前隐藏
 }
 
 func (ctx *ExecutionContext) Log(msg string) {
+	ctx.logNode("", msg)
+}
+
+// logNode 实现 nodeLogger 接口，nodeID 为空时为运行日志
+func (ctx *ExecutionContext) logNode(nodeID, msg string) {
 	if ctx.Logger != nil {
-		ctx.Logger(msg)
+		ctx.Logger(prefixNode(nodeID, msg))
 	}
+	ctx.Emit(Event{Type: EventLog, Time: time.Now(), NodeID: nodeID, Message: msg})
 }
 
-// ExecuteWorkflow 执行整个工作流
-func (wf *Workflow) ExecuteWorkflow(ctx *ExecutionContext) error {
-	// 1. 获取拓扑排序
-	order, err := wf.TopologicalSort()
-	if err != nil {
-		return fmt.Errorf("failed to sort workflow: %v", err)
+// Progress 实现 Context 接口，在服务内执行时由 RunNodeStream 将进度转发给 bff
+func (ctx *ExecutionContext) Progress(percent float64, msg string) {
+	nodeProgress(ctx, ctx.Context(), percent, msg)
+}
+
+// Emit 实现 EventSink 接口
+func (ctx *ExecutionContext) Emit(e Event) {
+	if ctx.OnEvent != nil {
+		ctx.OnEvent(e)
 	}
+}
 
-	ctx.Log(fmt.Sprintf("工作流执行顺序: %v", order))
+// memoizer 实现 memoSource 接口
+func (ctx *ExecutionContext) memoizer() *memoizer {
+	if ctx.Memo == nil {
+		return nil
+	}
+	return &memoizer{store: ctx.Memo, vars: ctx.Vars, redactor: ctx.Redactor}
+}
 
-	// 2. 按顺序执行节点
-	for _, nodeID := range order {
-		node := wf.Dag.Nodes[nodeID]
-		nodeType := wf.NodeTypes[node.TypeID]
+// exprEnv 实现 exprSource 接口
+func (ctx *ExecutionContext) exprEnv() *exprEnv {
+	return newExprEnv(ctx.Workflow, ctx.RunID, ctx.Vars, ctx.Secrets, ctx.Redactor)
+}
 
-		ctx.Log(fmt.Sprintf("开始执行节点 %s (%s)", nodeID, node.Label))
+// Context 实现 Context 接口
+func (ctx *ExecutionContext) Context() context.Context {
+	if ctx.Ctx == nil {
+		return context.Background()
+	}
+	return ctx.Ctx
+}
 
-		// 收集输入
-		err := wf.collectNodeInputs(nodeID)
-		if err != nil {
-			node.State = "failed"
-			return fmt.Errorf("failed to collect inputs for node %s: %v", nodeID, err)
-		}
+// nodeContext 为单个节点替换 context.Context，用于节点级超时
+type nodeContext struct {
+	parent Context
+	ctx    context.Context
+}
 
-		// 执行操作
-		outputs, err := nodeType.Operation.Execute(ctx, node.Inputs, ctx.Vars)
-		if err != nil {
-			node.State = "failed"
-			return fmt.Errorf("node %s execution failed: %v", nodeID, err)
-		}
+// Log 实现 Context 接口，日志归属到当前节点
+func (nc *nodeContext) Log(msg string) {
+	nodeLog(nc.parent, nc.ctx, msg)
+}
 
-		// 存储输出
-		node.Outputs = outputs
-		node.State = "success"
-		ctx.Log(fmt.Sprintf("节点 %s 执行完成，状态: %s", nodeID, node.State))
-	}
+// Progress 实现 Context 接口
+func (nc *nodeContext) Progress(percent float64, msg string) {
+	nodeProgress(nc.parent, nc.ctx, percent, msg)
+}
 
-	return nil
+// Context 实现 Context 接口
+func (nc *nodeContext) Context() context.Context {
+	return nc.ctx
+}
+
+// ExecuteWorkflow 使用本地注入的 Operation 执行整个工作流
+func (wf *Workflow) ExecuteWorkflow(ctx *ExecutionContext) error {
+	return wf.schedule(ctx, ctx.Limits, func(runCtx context.Context, nodeType NodeType, node *Node, inputs map[string][]byte) (map[string][]byte, error) {
+		if nodeType.Operation == nil {
+			return nil, fmt.Errorf("node type %s has no operation", node.TypeID)
+		}
+		outputs, err := nodeType.Operation.Execute(&nodeContext{parent: ctx, ctx: runCtx}, inputs, varsOf(runCtx, ctx.Vars))
+		if err != nil {
+			return nil, &OperationError{Err: err}
+		}
+		return outputs, nil
+	})
 }
 
 // collectNodeInputs 收集节点的所有输入
 func (wf *Workflow) collectNodeInputs(nodeID string) error {
 	node := wf.Dag.Nodes[nodeID]
-	nodeType := wf.NodeTypes[node.TypeID]
-
-	// 获取输入端口列表
-	inputPorts, exists := nodeType.Properties["inputs"]
-	if !exists || len(inputPorts) == 0 {
-		// 如果没有输入端口，直接返回
-		return nil
-	}
 
-	// 收集每个输入端口的数据
-	for _, port := range inputPorts {
+	// 按连接收集上游节点的输出
+	for _, conn := range wf.Dag.Connections {
+		if conn.To.NodeID != nodeID || wf.isControl(conn) {
+			continue
+		}
 		// 如果节点已经有预设的输入数据，则跳过
-		if _, exists := node.Inputs[port.Name]; exists {
+		if _, exists := node.Inputs[conn.To.PortName]; exists {
 			continue
 		}
-
-		// 查找连接到该端口的连接
-		found := false
-		for _, conn := range wf.Dag.Connections {
-			if conn.To.NodeID == nodeID && conn.To.PortName == port.Name {
-				// 从源节点获取输出数据
-				sourceNode := wf.Dag.Nodes[conn.From.NodeID]
-				if sourceNode.State == "success" {
-					if output, exists := sourceNode.Outputs[conn.From.PortName]; exists && output != nil {
-						node.Inputs[port.Name] = output
-						found = true
-						break
-					}
-				}
-			}
+		// 从源节点获取输出数据
+		sourceNode := wf.Dag.Nodes[conn.From.NodeID]
+		if sourceNode == nil || sourceNode.State != "success" {
+			continue
+		}
+		if output, exists := sourceNode.Outputs[conn.From.PortName]; exists && output != nil {
+			node.Inputs[conn.To.PortName] = output
 		}
-		if !found {
+	}
+
+	// 节点类型已知时，校验每个输入端口都已拿到数据
+	nodeType, exists := wf.lookupNodeType(node)
+	if !exists {
+		return nil
+	}
+	for _, port := range nodeType.Properties["inputs"] {
+		if _, exists := node.Inputs[port.Name]; !exists {
 			return fmt.Errorf("node %s 的输入端口 %s 没有找到对应的连接或源节点未执行完成", nodeID, port.Name)
 		}
 	}
@@ -113,6 +172,9 @@ func (wf *Workflow) collectNodeInputs(nodeID string) error {
 
 // CollectWorkflowResults 收集工作流执行结果
 func (wf *Workflow) CollectWorkflowResults() map[string]interface{} {
+	wf.mu.RLock()
+	defer wf.mu.RUnlock()
+
 	result := map[string]interface{}{
 		"workflow_id": wf.ID,
 		"status":      "success",
@@ -126,21 +188,44 @@ func (wf *Workflow) CollectWorkflowResults() map[string]interface{} {
 			"label": node.Label,
 			"state": node.State,
 		}
+		if node.Error != "" {
+			nodeResult["error"] = node.Error
+		}
+		if node.Reused {
+			nodeResult["reused"] = true
+		}
+		if node.Cached {
+			nodeResult["cached"] = true
+		}
+		if node.Progress != nil {
+			nodeResult["progress"] = node.Progress
+		}
 
 		// 收集输入数据
 		if len(node.Inputs) > 0 {
 			inputs := make(map[string]string)
 			for port, data := range node.Inputs {
-				inputs[port] = string(data)
+				inputs[port] = blob.Text(data)
 			}
 			nodeResult["inputs"] = inputs
 		}
 
+		// 收集执行尝试记录
+		if len(node.Attempts) > 0 {
+			nodeResult["attempts"] = node.Attempts
+		}
+		if node.Map != nil {
+			nodeResult["elements"] = node.Elements
+		}
+		if node.Child != nil {
+			nodeResult["subworkflow"] = node.Child.CollectWorkflowResults()
+		}
+
 		// 收集输出数据
 		if len(node.Outputs) > 0 {
 			outputs := make(map[string]string)
 			for port, data := range node.Outputs {
-				outputs[port] = string(data)
+				outputs[port] = blob.Text(data)
 			}
 			nodeResult["outputs"] = outputs
 		}
@@ -151,28 +236,262 @@ func (wf *Workflow) CollectWorkflowResults() map[string]interface{} {
 	return result
 }
 
-// ExecutionGRPCContext 实现 Context 接口，提供完整的执行上下文
+// prefixNode 节点日志写入运行日志时带上节点 ID
+func prefixNode(nodeID, msg string) string {
+	if nodeID == "" {
+		return msg
+	}
+	return fmt.Sprintf("[%s] %s", nodeID, msg)
+}
+
+// NodeStatus 节点运行状态快照
+type NodeStatus struct {
+	ID       string       `json:"id"`
+	Label    string       `json:"label"`
+	State    string       `json:"state"`
+	Error    string       `json:"error,omitempty"`
+	Reused   bool         `json:"reused,omitempty"`   // 续跑时复用了上一次运行的输出
+	Cached   bool         `json:"cached,omitempty"`   // 输出来自节点缓存
+	Progress *Progress    `json:"progress,omitempty"` // 最近一次上报的进度
+	Nodes    []NodeStatus `json:"nodes,omitempty"`    // 子工作流节点的状态
+}
+
+// NodeOutputs 并发安全地返回成功节点的原始输出，没有输出的成功节点对应空 map，用于续跑时复用
+func (wf *Workflow) NodeOutputs() map[string]map[string][]byte {
+	wf.mu.RLock()
+	defer wf.mu.RUnlock()
+
+	outputs := make(map[string]map[string][]byte)
+	for nodeID, node := range wf.Dag.Nodes {
+		if node.State != "success" {
+			continue
+		}
+		out := make(map[string][]byte, len(node.Outputs))
+		for port, data := range node.Outputs {
+			out[port] = data
+		}
+		outputs[nodeID] = out
+	}
+	return outputs
+}
+
+// NodeStatuses 并发安全地返回所有节点的状态，按节点 ID 排序
+func (wf *Workflow) NodeStatuses() []NodeStatus {
+	wf.mu.RLock()
+	defer wf.mu.RUnlock()
+
+	statuses := make([]NodeStatus, 0, len(wf.Dag.Nodes))
+	for nodeID, node := range wf.Dag.Nodes {
+		state := node.State
+		if state == "" {
+			state = "pending"
+		}
+		status := NodeStatus{ID: nodeID, Label: node.Label, State: state, Error: node.Error, Reused: node.Reused, Cached: node.Cached, Progress: node.Progress}
+		if node.Child != nil {
+			status.Nodes = node.Child.NodeStatuses()
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].ID < statuses[j].ID })
+	return statuses
+}
+
+// ExecutionGRPCContext 实现 Context 接口，通过 gRPC 将节点分发到远程服务执行。
+// 运行配置、日志、事件与表达式求值沿用 ExecutionContext，只增加到服务实例的连接
 type ExecutionGRPCContext struct {
-	Workflow *Workflow
-	Logger   func(msg string)
-	Vars     map[string]interface{}
-	client   *grpc.ClientConn
+	ExecutionContext
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn // 实例地址 -> 连接
 }
 
-// Log 实现 Context 接口
-func (ctx *ExecutionGRPCContext) Log(msg string) {
-	if ctx.Logger != nil {
-		ctx.Logger(msg)
+// memoizer 实现 memoSource 接口，命中的 blob 引用重新查找保存它的实例
+func (ctx *ExecutionGRPCContext) memoizer() *memoizer {
+	m := ctx.ExecutionContext.memoizer()
+	if m != nil {
+		m.locate = ctx.locateBlob
+	}
+	return m
+}
+
+// locateBlob 依次向服务的实例读取 blob 的第一块，返回保存着该 blob 的实例地址，没有时返回空
+func (ctx *ExecutionGRPCContext) locateBlob(runCtx context.Context, service, id string) string {
+	for _, inst := range global.LoadBalance.GetAllInstances(service) {
+		cli, err := ctx.client(inst.Addr)
+		if err != nil {
+			continue
+		}
+		readCtx, cancel := context.WithTimeout(runCtx, 5*time.Second)
+		stream, err := cli.ReadBlob(readCtx, &v1.ReadBlobRequest{Id: id})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		cancel()
+		// 空 blob 没有数据块，直接结束
+		if err == nil || err == io.EOF {
+			return inst.Addr
+		}
 	}
+	return ""
 }
 
-// ExecuteWorkflowWithGRPC 执行整个工作流
+// client 获取到指定实例的 BaseService 客户端，连接按地址复用
+func (ctx *ExecutionGRPCContext) client(addr string) (v1.BaseServiceClient, error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if ctx.conns == nil {
+		ctx.conns = make(map[string]*grpc.ClientConn)
+	}
+	conn, ok := ctx.conns[addr]
+	if !ok {
+		var err error
+		conn, err = grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		ctx.conns[addr] = conn
+	}
+	return v1.NewBaseServiceClient(conn), nil
+}
+
+// Close 关闭执行过程中建立的所有连接
+func (ctx *ExecutionGRPCContext) Close() {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	for addr, conn := range ctx.conns {
+		conn.Close()
+		delete(ctx.conns, addr)
+	}
+}
+
+// ExecuteWorkflowWithGRPC 执行整个工作流，每个节点都交给提供该节点类型的远程服务运行
 func (wf *Workflow) ExecuteWorkflowWithGRPC(ctx *ExecutionGRPCContext) error {
-	// 1. 获取拓扑排序
-	// order, err := wf.TopologicalSort()
-	// if err != nil {
-	// 	return fmt.Errorf("failed to sort workflow: %v", err)
-	// }
+	return wf.schedule(ctx, ctx.Limits, func(runCtx context.Context, nodeType NodeType, node *Node, inputs map[string][]byte) (map[string][]byte, error) {
+		return runNodeRemote(runCtx, ctx, nodeType, node, inputs)
+	})
+}
+
+// runNodeRemote 按节点类型所属服务选取实例并调用 RunNode
+func runNodeRemote(runCtx context.Context, ctx *ExecutionGRPCContext, nodeType NodeType, node *Node, inputs map[string][]byte) (map[string][]byte, error) {
+	// 1. 节点类型所属服务由 Resolve 填充
+	serviceName := nodeType.Service
+	if serviceName == "" {
+		return nil, fmt.Errorf("node type %s is not resolved to a service", node.TypeID)
+	}
+
+	// 2. 负载均衡选取实例
+	inst := global.LoadBalance.GetNextInstance(serviceName)
+	if inst == nil {
+		return nil, fmt.Errorf("no available instance for service %s", serviceName)
+	}
+
+	cli, err := ctx.client(inst.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s (%s) failed: %v", serviceName, inst.Addr, err)
+	}
+
+	// 3. 组装请求
+	runVars := varsOf(runCtx, ctx.Vars)
+	vars := make(map[string]string, len(runVars))
+	for k, v := range runVars {
+		vars[k] = string(formatValue(v))
+	}
 
+	req := &v1.RunNodeRequest{
+		NodeId: node.TypeID,
+		Inputs: make(map[string][]byte, len(inputs)),
+		Vars:   vars,
+	}
+	if err := splitBlobInputs(runCtx, cli, nodeType, inputs, req); err != nil {
+		return nil, fmt.Errorf("upload to %s (%s) failed: %w", serviceName, inst.Addr, err)
+	}
+
+	resp, err := callRunNode(runCtx, ctx, cli, req)
+	if err != nil {
+		return nil, fmt.Errorf("call %s (%s) failed: %w", serviceName, inst.Addr, err)
+	}
+	if resp.State != "success" {
+		return nil, &OperationError{Err: errors.New(resp.Error)}
+	}
+
+	// 4. 引用输出编码为端口数据，下游节点收到的仍是引用
+	outputs := resp.Outputs
+	if len(resp.BlobOutputs) > 0 {
+		outputs = make(map[string][]byte, len(resp.Outputs)+len(resp.BlobOutputs))
+		for port, data := range resp.Outputs {
+			outputs[port] = data
+		}
+		for port, pbRef := range resp.BlobOutputs {
+			ref := blob.FromProto(pbRef)
+			if ref.Addr == "" {
+				ref.Addr = inst.Addr
+			}
+			outputs[port] = ref.Encode()
+		}
+	}
+	return outputs, nil
+}
+
+// callRunNode 调用 RunNodeStream 执行节点，服务发来的日志与进度转发到所属节点，
+// 服务未实现流式接口时退回 RunNode
+func callRunNode(runCtx context.Context, ctx *ExecutionGRPCContext, cli v1.BaseServiceClient, req *v1.RunNodeRequest) (*v1.RunNodeResponse, error) {
+	stream, err := cli.RunNodeStream(runCtx, req)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		ev, err := stream.Recv()
+		if status.Code(err) == codes.Unimplemented {
+			return cli.RunNode(runCtx, req)
+		}
+		if err == io.EOF {
+			return nil, errors.New("run node stream closed without result")
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch ev.Type {
+		case "log":
+			nodeLog(ctx, runCtx, ev.Message)
+		case "progress":
+			nodeProgress(ctx, runCtx, ev.Percent, ev.Message)
+		case "result":
+			return ev.Result, nil
+		}
+	}
+}
+
+// splitBlobInputs 将节点输入拆分到请求中：blob 引用放入 BlobInputs，
+// file 端口上超过内联上限的数据先通过 WriteBlob 上传到目标实例再以引用传递
+func splitBlobInputs(ctx context.Context, cli v1.BaseServiceClient, nodeType NodeType, inputs map[string][]byte, req *v1.RunNodeRequest) error {
+	for port, data := range inputs {
+		if ref, ok := blob.Decode(data); ok {
+			if req.BlobInputs == nil {
+				req.BlobInputs = make(map[string]*v1.BlobRef)
+			}
+			req.BlobInputs[port] = ref.Proto()
+			continue
+		}
+
+		p, _ := findPort(nodeType.Properties["inputs"], port)
+		if len(data) <= blob.InlineLimit || !p.IsFile() {
+			req.Inputs[port] = data
+			continue
+		}
+		mime := p.MIME()
+		if strings.Contains(mime, "*") {
+			mime = ""
+		}
+		ref, err := blob.Upload(ctx, cli, bytes.NewReader(data), mime)
+		if err != nil {
+			return fmt.Errorf("input port %s: %w", port, err)
+		}
+		if req.BlobInputs == nil {
+			req.BlobInputs = make(map[string]*v1.BlobRef)
+		}
+		req.BlobInputs[port] = ref.Proto()
+	}
 	return nil
 }
@@ -0,0 +1,348 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"zflow/app/bff/secret"
+)
+
+// 表达式可以引用的运行元数据
+var runFields = map[string]*DataType{
+	"id":          {Kind: DataKindString},
+	"workflow_id": {Kind: DataKindString},
+	"version":     {Kind: DataKindInt},
+}
+
+// exprRef 表达式 ${scope.name} 或 ${nodes.节点ID.端口} 中的引用
+type exprRef struct {
+	text  string // 原始表达式，用于错误信息
+	scope string // vars / run / secrets / nodes
+	name  string // 变量名、元数据字段、密钥名或节点 ID
+	port  string // scope 为 nodes 时的输出端口
+}
+
+// exprPart 输入模板的一段，ref 为空时是字面文本
+type exprPart struct {
+	text string
+	ref  *exprRef
+}
+
+// exprEnv 表达式求值时可见的变量、运行元数据与密钥
+type exprEnv struct {
+	vars   map[string]interface{}
+	run    map[string]interface{}
+	secret func(name string) (string, error) // 为空时不能引用密钥
+}
+
+// exprSource 可选接口，Context 通过它向表达式提供变量与运行元数据
+type exprSource interface {
+	exprEnv() *exprEnv
+}
+
+// newExprEnv 创建求值环境，wf 为本次运行的顶层工作流；
+// 从 secrets 读取的密钥值记入 redactor，对外输出时隐藏
+func newExprEnv(wf *Workflow, runID string, vars map[string]interface{}, secrets secret.Provider, redactor *secret.Redactor) *exprEnv {
+	env := &exprEnv{vars: vars, run: map[string]interface{}{"id": runID}}
+	if wf != nil {
+		env.run["workflow_id"] = wf.ID
+		env.run["version"] = wf.Version
+	}
+	if secrets != nil {
+		env.secret = func(name string) (string, error) {
+			value, err := secrets.Get(name)
+			if err != nil {
+				return "", err
+			}
+			redactor.Add(value)
+			return value, nil
+		}
+	}
+	return env
+}
+
+// exprEnvOf 返回 Context 提供的求值环境，没有时变量与元数据都为空
+func exprEnvOf(ctx Context) *exprEnv {
+	if src, ok := ctx.(exprSource); ok {
+		return src.exprEnv()
+	}
+	return &exprEnv{}
+}
+
+// hasExpr 判断预设输入是否包含表达式
+func hasExpr(data []byte) bool {
+	return bytes.Contains(data, []byte("${"))
+}
+
+// referencesSecrets 判断预设输入中是否有 ${secrets.名称} 引用
+func referencesSecrets(inputs map[string][]byte) bool {
+	for _, data := range inputs {
+		if !hasExpr(data) {
+			continue
+		}
+		parts, err := parseTemplate(string(data))
+		if err != nil {
+			continue
+		}
+		for _, part := range parts {
+			if part.ref != nil && part.ref.scope == "secrets" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseTemplate 将预设输入解析为字面文本与 ${...} 引用，$${ 表示字面的 ${
+func parseTemplate(s string) ([]exprPart, error) {
+	var parts []exprPart
+	var text strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			text.WriteString(s)
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			text.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		text.WriteString(s[:i])
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated expression %q", s[i:])
+		}
+		ref, err := parseRef(strings.TrimSpace(s[i+2 : i+end]))
+		if err != nil {
+			return nil, err
+		}
+		if text.Len() > 0 {
+			parts = append(parts, exprPart{text: text.String()})
+			text.Reset()
+		}
+		parts = append(parts, exprPart{ref: ref})
+		s = s[i+end+1:]
+	}
+	if text.Len() > 0 {
+		parts = append(parts, exprPart{text: text.String()})
+	}
+	return parts, nil
+}
+
+// parseRef 解析单个引用：vars.名称、run.字段、secrets.名称 或 nodes.节点ID.端口
+func parseRef(expr string) (*exprRef, error) {
+	scope, rest, _ := strings.Cut(expr, ".")
+	ref := &exprRef{text: expr, scope: scope, name: rest}
+	switch scope {
+	case "vars", "run", "secrets":
+		if rest == "" || strings.Contains(rest, ".") {
+			return nil, fmt.Errorf("invalid expression ${%s}, want ${%s.name}", expr, scope)
+		}
+	case "nodes":
+		// 节点 ID 可能包含点，端口名取最后一段
+		i := strings.LastIndexByte(rest, '.')
+		if i <= 0 || i == len(rest)-1 {
+			return nil, fmt.Errorf("invalid expression ${%s}, want ${nodes.node_id.port}", expr)
+		}
+		ref.name, ref.port = rest[:i], rest[i+1:]
+	default:
+		return nil, fmt.Errorf("invalid expression ${%s}, want vars, run, secrets or nodes", expr)
+	}
+	return ref, nil
+}
+
+// presetInputs 复制节点的预设输入，不含父工作流传入子工作流入口端口的数据，调用方需持有 wf.mu
+func (wf *Workflow) presetInputs(node *Node) map[string][]byte {
+	presets := make(map[string][]byte, len(node.Inputs))
+	for k, v := range node.Inputs {
+		if !node.passed[k] {
+			presets[k] = v
+		}
+	}
+	return presets
+}
+
+// evalInputs 对节点预设输入中的表达式求值，并按端口类型校验结果，调用方需持有 wf.mu。
+// 整个输入只有一个引用时保留引用值的原始数据，否则按文本拼接。
+// 求值结果写回 inputs 用于派发；shown 为记入节点输入的值，其中的密钥引用保持原样。
+func (wf *Workflow) evalInputs(env *exprEnv, node *Node, inputs map[string][]byte) (shown map[string][]byte, err error) {
+	nodeType, known := wf.lookupNodeType(node)
+	shown = make(map[string][]byte)
+	for port, data := range inputs {
+		if !hasExpr(data) {
+			continue
+		}
+		parts, err := parseTemplate(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("input port %s: %v", port, err)
+		}
+		var value, display []byte
+		for _, part := range parts {
+			if part.ref == nil {
+				value = append(value, part.text...)
+				display = append(display, part.text...)
+				continue
+			}
+			v, err := wf.refValue(env, part.ref)
+			if err != nil {
+				return nil, fmt.Errorf("input port %s: %v", port, err)
+			}
+			value = append(value, v...)
+			if part.ref.scope == "secrets" {
+				display = append(display, "${"+part.ref.text+"}"...)
+			} else {
+				display = append(display, v...)
+			}
+		}
+		if known {
+			if p, found := findPort(nodeType.Properties["inputs"], port); found {
+				if err := portDataType(node, "inputs", p).Check(value); err != nil {
+					return nil, fmt.Errorf("input port %s: %v", port, err)
+				}
+			}
+		}
+		inputs[port] = value
+		shown[port] = display
+	}
+	return shown, nil
+}
+
+// refValue 返回引用的值：运行变量优先，其次工作流声明的默认值；节点输出要求节点已成功
+func (wf *Workflow) refValue(env *exprEnv, ref *exprRef) ([]byte, error) {
+	switch ref.scope {
+	case "vars":
+		if v, exists := env.vars[ref.name]; exists {
+			return formatValue(v), nil
+		}
+		if decl, declared := wf.Vars[ref.name]; declared && decl.Default != nil {
+			return formatValue(decl.Default), nil
+		}
+		return nil, fmt.Errorf("${%s}: variable %s is not set", ref.text, ref.name)
+	case "run":
+		v, exists := env.run[ref.name]
+		if !exists {
+			return nil, fmt.Errorf("${%s}: unknown run field %s", ref.text, ref.name)
+		}
+		return formatValue(v), nil
+	case "secrets":
+		if env.secret == nil {
+			return nil, fmt.Errorf("${%s}: no secret provider is configured", ref.text)
+		}
+		value, err := env.secret(ref.name)
+		if err != nil {
+			return nil, fmt.Errorf("${%s}: %v", ref.text, err)
+		}
+		return []byte(value), nil
+	default:
+		source := wf.Dag.Nodes[ref.name]
+		if source == nil || source.State != "success" {
+			return nil, fmt.Errorf("${%s}: node %s has not succeeded", ref.text, ref.name)
+		}
+		output, exists := source.Outputs[ref.port]
+		if !exists {
+			return nil, fmt.Errorf("${%s}: node %s did not output port %s", ref.text, ref.name, ref.port)
+		}
+		return output, nil
+	}
+}
+
+// diagnoseExpr 静态检查预设输入中的表达式：引用的变量已声明、元数据字段存在、
+// 节点是上游节点且端口存在；整个输入只有一个引用时还检查类型兼容
+func (wf *Workflow) diagnoseExpr(nodeID string, port Port, data []byte, upstream map[string]bool) []Diagnostic {
+	var diags []Diagnostic
+	fail := func(code, msg string) {
+		diags = append(diags, Diagnostic{Code: code, Severity: SeverityError, NodeID: nodeID, Port: port.Name,
+			Message: fmt.Sprintf("节点 %s 的输入端口 %s: %s", nodeID, port.Name, msg)})
+	}
+
+	parts, err := parseTemplate(string(data))
+	if err != nil {
+		fail(DiagInvalidExpression, err.Error())
+		return diags
+	}
+	targetType := portDataType(wf.Dag.Nodes[nodeID], "inputs", port)
+	if len(parts) <= 1 && (len(parts) == 0 || parts[0].ref == nil) {
+		// 只有转义的 $${，按字面值校验
+		var literal []byte
+		if len(parts) == 1 {
+			literal = []byte(parts[0].text)
+		}
+		if err := targetType.Check(literal); err != nil {
+			fail(DiagInvalidInputValue, err.Error())
+		}
+		return diags
+	}
+	for _, part := range parts {
+		if part.ref == nil {
+			continue
+		}
+		ref := part.ref
+		var sourceType *DataType
+		switch ref.scope {
+		case "vars":
+			decl, declared := wf.Vars[ref.name]
+			if !declared {
+				fail(DiagUnknownVariable, fmt.Sprintf("${%s} 引用了未声明的变量 %s", ref.text, ref.name))
+				continue
+			}
+			sourceType = decl.DataType
+		case "run":
+			t, exists := runFields[ref.name]
+			if !exists {
+				fail(DiagInvalidExpression, fmt.Sprintf("${%s} 引用了未知的运行字段 %s", ref.text, ref.name))
+				continue
+			}
+			sourceType = t
+		case "secrets":
+			// 密钥在派发时才读取，这里只检查名称
+			if !secret.ValidName(ref.name) {
+				fail(DiagInvalidExpression, fmt.Sprintf("${%s} 的密钥名不合法", ref.text))
+				continue
+			}
+			sourceType = &DataType{Kind: DataKindString}
+		default:
+			source, exists := wf.Dag.Nodes[ref.name]
+			if !exists {
+				fail(DiagUnknownNode, fmt.Sprintf("${%s} 引用了不存在的节点 %s", ref.text, ref.name))
+				continue
+			}
+			if !upstream[ref.name] {
+				fail(DiagInvalidExpression, fmt.Sprintf("${%s} 引用的节点 %s 不是上游节点", ref.text, ref.name))
+				continue
+			}
+			sourceNodeType, known := wf.lookupNodeType(source)
+			if !known {
+				continue
+			}
+			p, found := findPort(sourceNodeType.Properties["outputs"], ref.port)
+			if !found {
+				fail(DiagUnknownPort, fmt.Sprintf("${%s} 引用了节点 %s 不存在的输出端口 %s", ref.text, ref.name, ref.port))
+				continue
+			}
+			sourceType = portDataType(source, "outputs", p)
+		}
+		if len(parts) == 1 && !sourceType.AssignableTo(targetType) {
+			fail(DiagIncompatibleTypes, fmt.Sprintf("${%s} 的类型 %s 与端口类型 %s 不兼容", ref.text, sourceType, targetType))
+		}
+	}
+	return diags
+}
+
+// upstreamOf 返回节点的全部上游节点，包括经由控制流连线的上游
+func (wf *Workflow) upstreamOf(nodeID string) map[string]bool {
+	upstream := make(map[string]bool)
+	queue := []string{nodeID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, conn := range wf.Dag.Connections {
+			if conn.To.NodeID == current && !upstream[conn.From.NodeID] {
+				upstream[conn.From.NodeID] = true
+				queue = append(queue, conn.From.NodeID)
+			}
+		}
+	}
+	return upstream
+}
@@ -0,0 +1,217 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"zflow/app/bff/secret"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []exprPart
+		wantErr string
+	}{
+		{name: "纯文本", in: "hello", want: []exprPart{{text: "hello"}}},
+		{name: "单个引用", in: "${vars.x}", want: []exprPart{{ref: &exprRef{text: "vars.x", scope: "vars", name: "x"}}}},
+		{name: "文本与引用拼接", in: "Bearer ${ secrets.token }!", want: []exprPart{
+			{text: "Bearer "}, {ref: &exprRef{text: "secrets.token", scope: "secrets", name: "token"}}, {text: "!"},
+		}},
+		{name: "转义", in: "$${vars.x}-${run.id}", want: []exprPart{
+			{text: "${vars.x}-"}, {ref: &exprRef{text: "run.id", scope: "run", name: "id"}},
+		}},
+		{name: "节点 ID 含点", in: "${nodes.step.1.out}", want: []exprPart{
+			{ref: &exprRef{text: "nodes.step.1.out", scope: "nodes", name: "step.1", port: "out"}},
+		}},
+		{name: "未闭合", in: "a ${vars.x", wantErr: "unterminated expression"},
+		{name: "未知作用域", in: "${env.HOME}", wantErr: "want vars, run, secrets or nodes"},
+		{name: "变量名含点", in: "${vars.a.b}", wantErr: "want ${vars.name}"},
+		{name: "缺少端口", in: "${nodes.a}", wantErr: "want ${nodes.node_id.port}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTemplate(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseTemplate(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseTemplate(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferencesSecrets(t *testing.T) {
+	tests := []struct {
+		inputs map[string][]byte
+		want   bool
+	}{
+		{map[string][]byte{"a": []byte("plain"), "b": []byte("${vars.x}")}, false},
+		{map[string][]byte{"a": []byte("Bearer ${secrets.token}")}, true},
+		{map[string][]byte{"a": []byte("$${secrets.token}")}, false},
+		{map[string][]byte{"a": []byte("${secrets.token")}, false},
+	}
+	for _, tt := range tests {
+		if got := referencesSecrets(tt.inputs); got != tt.want {
+			t.Errorf("referencesSecrets(%s) = %v, want %v", tt.inputs, got, tt.want)
+		}
+	}
+}
+
+// exprWorkflow a 为已成功的上游节点，b 的文本输入引用变量、运行元数据、密钥与 a 的输出
+func exprWorkflow(t *testing.T, textInputs map[string]string) *Workflow {
+	t.Helper()
+	inputs, err := json.Marshal(textInputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wf := newTestWorkflow(t, `{"vars": {"x": {"default": 7}}, "nodes": [{"id": "a", "node_type": "t"}, {"id": "b", "node_type": "t", "text_inputs": `+string(inputs)+`}],
+		"connections": [{"connection_id": "1", "from": {"node_id": "a", "port_name": "out"}, "to": {"node_id": "b", "port_name": "in"}}]}`,
+		map[string]NodeType{"t": {Properties: map[string][]Port{
+			"inputs":  {{Name: "in", PortType: "connection"}, {Name: "n", PortType: "connection", DataType: &DataType{Kind: DataKindInt}}, {Name: "s", PortType: "connection"}},
+			"outputs": ports("out"),
+		}}})
+	a := wf.Dag.Nodes["a"]
+	a.State = "success"
+	a.Outputs = map[string][]byte{"out": []byte(`{"k":1}`)}
+	return wf
+}
+
+func TestEvalInputs(t *testing.T) {
+	wf := exprWorkflow(t, map[string]string{
+		"n": "${vars.x}",
+		"s": "${run.id}:${nodes.a.out}:Bearer ${secrets.token}:$${vars.x}",
+	})
+	r := secret.NewRedactor()
+	env := newExprEnv(wf, "run-1", map[string]interface{}{}, secretMap{"token": "s3cr3t"}, r)
+	node := wf.Dag.Nodes["b"]
+	inputs := map[string][]byte{"n": node.Inputs["n"], "s": node.Inputs["s"], "plain": []byte("p")}
+
+	shown, err := wf.evalInputs(env, node, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(inputs["n"]); got != "7" {
+		t.Errorf("n = %q, want default 7", got)
+	}
+	if got, want := string(inputs["s"]), `run-1:{"k":1}:Bearer s3cr3t:${vars.x}`; got != want {
+		t.Errorf("s = %q, want %q", got, want)
+	}
+	// 记入节点输入的值保留密钥引用
+	if got, want := string(shown["s"]), `run-1:{"k":1}:Bearer ${secrets.token}:${vars.x}`; got != want {
+		t.Errorf("shown s = %q, want %q", got, want)
+	}
+	if _, ok := shown["plain"]; ok || string(inputs["plain"]) != "p" {
+		t.Errorf("plain input changed: %q, shown %v", inputs["plain"], shown)
+	}
+	if !r.Contains([]byte("s3cr3t")) {
+		t.Error("secret value not added to redactor")
+	}
+}
+
+func TestEvalInputsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   map[string]string
+		vars map[string]interface{}
+		want string
+	}{
+		{"类型不符", map[string]string{"n": "${vars.x}"}, map[string]interface{}{"x": "seven"}, "input port n"},
+		{"变量未设置", map[string]string{"s": "${vars.y}"}, nil, "variable y is not set"},
+		{"节点未成功", map[string]string{"s": "${nodes.b.out}"}, nil, "node b has not succeeded"},
+		{"没有密钥来源", map[string]string{"s": "${secrets.token}"}, nil, "no secret provider"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := exprWorkflow(t, tt.in)
+			node := wf.Dag.Nodes["b"]
+			inputs := make(map[string][]byte)
+			for k, v := range node.Inputs {
+				inputs[k] = v
+			}
+			_, err := wf.evalInputs(newExprEnv(wf, "run-1", tt.vars, nil, nil), node, inputs)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("evalInputs() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextInputsConflict(t *testing.T) {
+	var raw RawWorkflow
+	def := `{"nodes": [{"id": "a", "node_type": "t", "inputs": {"in": "eA=="}, "text_inputs": {"in": "x"}}]}`
+	if err := json.Unmarshal([]byte(def), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWorkflow("test", raw); err == nil || !strings.Contains(err.Error(), "both inputs and text_inputs") {
+		t.Fatalf("NewWorkflow() error = %v", err)
+	}
+}
+
+func TestConnectionDataIsNotEvaluated(t *testing.T) {
+	const data = "price is ${vars.nope} and $${vars.x}"
+	var got []byte
+	wf := newTestWorkflow(t, `{"vars": {"x": {"default": 1}}, "nodes": [{"id": "a", "node_type": "src"}, {"id": "b", "node_type": "sink"}],
+		"connections": [{"connection_id": "1", "from": {"node_id": "a", "port_name": "out"}, "to": {"node_id": "b", "port_name": "in"}}]}`,
+		map[string]NodeType{
+			"src": {Operation: opFunc(func(Context, map[string][]byte) (map[string][]byte, error) {
+				return map[string][]byte{"out": []byte(data)}, nil
+			}), Properties: map[string][]Port{"outputs": ports("out")}},
+			"sink": {Operation: opFunc(func(_ Context, in map[string][]byte) (map[string][]byte, error) {
+				got = in["in"]
+				return map[string][]byte{}, nil
+			}), Properties: map[string][]Port{"inputs": ports("in")}},
+		})
+	if err := execute(wf, context.Background(), Limits{}); err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if string(got) != data {
+		t.Fatalf("downstream input = %q, want %q unchanged", got, data)
+	}
+	if in := string(wf.Dag.Nodes["b"].Inputs["in"]); in != data {
+		t.Fatalf("recorded input = %q, want %q", in, data)
+	}
+}
+
+func TestSubWorkflowEntryDataIsNotEvaluated(t *testing.T) {
+	// 父工作流的预设输入 $${vars.greeting} 求值为字面的 ${vars.greeting}，传入子工作流后不再求值
+	var raw RawWorkflow
+	def := `{"nodes": [{"id": "sub", "node_type": "builtin.subworkflow", "text_inputs": {"x": "$${vars.greeting}"},
+		"subworkflow": {"workflow_id": "greet", "inputs": {"x": {"node_id": "say", "port_name": "in"}},
+		"outputs": {"out": {"node_id": "say", "port_name": "out"}}, "vars": {"greeting": "hi"}}}]}`
+	if err := json.Unmarshal([]byte(def), &raw); err != nil {
+		t.Fatal(err)
+	}
+	wf, err := NewWorkflow("parent", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := `{"vars": {"greeting": {}}, "nodes": [{"id": "say", "node_type": "echo"}]}`
+	if err := wf.Resolve(NewResolver(echoCatalog(), defMap{"greet": child})); err != nil {
+		t.Fatal(err)
+	}
+	template := wf.Dag.Nodes["sub"].template
+	echo := template.NodeTypes["echo"]
+	echo.Operation = opFunc(func(_ Context, in map[string][]byte) (map[string][]byte, error) {
+		return map[string][]byte{"out": in["in"]}, nil
+	})
+	template.NodeTypes["echo"] = echo
+
+	if err := execute(wf, context.Background(), Limits{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(wf.Dag.Nodes["sub"].Outputs["out"]), "${vars.greeting}"; got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}
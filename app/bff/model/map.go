@@ -0,0 +1,201 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MapConfig 将节点展开为对列表逐元素执行：
+// 指定端口的输入必须是 JSON 数组，节点类型对每个元素执行一次，
+// 每个输出端口收集为与输入顺序一致的 JSON 数组。
+type MapConfig struct {
+	Port          string `json:"port"`                     // 输入为 JSON 数组的端口
+	Concurrency   int    `json:"concurrency,omitempty"`    // 同时执行的元素数，<=0 时为 1
+	AllowFailures bool   `json:"allow_failures,omitempty"` // 为 true 时失败元素输出 null，节点仍成功
+}
+
+// ElementResult map 节点中单个元素的执行结果
+type ElementResult struct {
+	Index    int       `json:"index"`
+	State    string    `json:"state"`
+	Error    string    `json:"error,omitempty"`
+	Attempts []Attempt `json:"attempts,omitempty"`
+}
+
+// decodeElement 将数组元素转换为端口数据：json 端口保留元素的 JSON 文本，
+// 其它端口的字符串元素取原文，其它值保留 JSON 文本
+func decodeElement(raw json.RawMessage, dt *DataType) []byte {
+	if dt != nil && dt.Kind == DataKindJSON {
+		return []byte(raw)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []byte(s)
+	}
+	return []byte(raw)
+}
+
+// encodeElement 按端口的数据类型将端口数据转换为数组元素：
+// json / int / float / bool 端口的数据是 JSON 文本，原样嵌入；其它端口（含未声明类型的端口）的数据作为字符串，
+// 不会因为文本恰好是合法 JSON 而改变类型。声明为 JSON 类型但数据不合法时同样作为字符串
+func encodeElement(data []byte, dt *DataType) json.RawMessage {
+	if dt != nil {
+		switch dt.Kind {
+		case DataKindJSON, DataKindInt, DataKindFloat, DataKindBool:
+			if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && json.Valid(trimmed) {
+				return json.RawMessage(trimmed)
+			}
+		}
+	}
+	encoded, _ := json.Marshal(string(data))
+	return encoded
+}
+
+// runMap 按 MapConfig 对列表逐元素执行节点，元素级失败分别记录在 node.Elements 中。
+// 每个元素与普通节点一样占用服务与全局并发槽位，子工作流的元素由其内部节点占用
+func (wf *Workflow) runMap(ctx Context, wfCtx context.Context, lim *limiter, node *Node, inputs map[string][]byte, run runFunc) (map[string][]byte, error) {
+	cfg := node.Map
+	var items []json.RawMessage
+	if err := json.Unmarshal(inputs[cfg.Port], &items); err != nil {
+		return nil, &OperationError{Err: fmt.Errorf("map port %s is not a JSON array: %v", cfg.Port, err)}
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	wf.mu.Lock()
+	node.Elements = make([]ElementResult, len(items))
+	for i := range node.Elements {
+		node.Elements[i] = ElementResult{Index: i, State: "queued"}
+	}
+	wf.mu.Unlock()
+
+	ctx.Log(fmt.Sprintf("节点 %s 展开为 %d 个元素，并发 %d", node.ID, len(items), concurrency))
+
+	// 元素的数据类型为端口声明的类型，portDataType 中 map 端口的类型是整个数组
+	elemType := wf.elementType(node)
+	serviceSem, globalSem := lim.service(wf.nodeTypeOf(node).Service), lim.global
+	if node.SubWorkflow != nil {
+		serviceSem, globalSem = nil, nil
+	}
+
+	outputs := make([]map[string][]byte, len(items))
+	sem := newSemaphore(concurrency)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		if err := sem.acquire(wfCtx); err != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, item json.RawMessage) {
+			defer wg.Done()
+			defer sem.release()
+
+			// 先占服务槽位再占全局槽位，与调度器一致
+			if err := serviceSem.acquire(wfCtx); err != nil {
+				wf.failElement(wfCtx, node, i, err)
+				return
+			}
+			defer serviceSem.release()
+			if err := globalSem.acquire(wfCtx); err != nil {
+				wf.failElement(wfCtx, node, i, err)
+				return
+			}
+			defer globalSem.release()
+
+			elemInputs := make(map[string][]byte, len(inputs))
+			for k, v := range inputs {
+				elemInputs[k] = v
+			}
+			elemInputs[cfg.Port] = decodeElement(item, elemType)
+
+			wf.mu.Lock()
+			node.Elements[i].State = "running"
+			wf.mu.Unlock()
+
+			out, err := wf.runWithRetry(ctx, wfCtx, node, elemInputs, run, func(a Attempt) {
+				node.Elements[i].Attempts = append(node.Elements[i].Attempts, a)
+			})
+
+			if err != nil {
+				wf.failElement(wfCtx, node, i, err)
+				return
+			}
+			wf.mu.Lock()
+			defer wf.mu.Unlock()
+			node.Elements[i].State = "success"
+			outputs[i] = out
+		}(i, item)
+	}
+	wg.Wait()
+
+	// 汇总元素级结果
+	wf.mu.RLock()
+	var failed []int
+	for i := range node.Elements {
+		if node.Elements[i].State != "success" {
+			failed = append(failed, i)
+		}
+	}
+	wf.mu.RUnlock()
+
+	if err := wfCtx.Err(); err != nil {
+		return nil, err
+	}
+	if len(failed) > 0 && !cfg.AllowFailures {
+		return nil, &OperationError{Err: fmt.Errorf("%d of %d elements failed: %v", len(failed), len(items), failed)}
+	}
+
+	// 按输出端口收集为有序数组，失败元素为 null
+	result := make(map[string][]byte)
+	for _, port := range wf.nodeTypeOf(node).Properties["outputs"] {
+		list := make([]json.RawMessage, len(items))
+		for i, out := range outputs {
+			if data, ok := out[port.Name]; ok && data != nil {
+				list[i] = encodeElement(data, port.DataType)
+			} else {
+				list[i] = json.RawMessage("null")
+			}
+		}
+		encoded, err := json.Marshal(list)
+		if err != nil {
+			return nil, err
+		}
+		result[port.Name] = encoded
+	}
+	return result, nil
+}
+
+// elementType 返回 map 端口声明的数据类型，即数组元素的类型
+func (wf *Workflow) elementType(node *Node) *DataType {
+	port, _ := findPort(wf.nodeTypeOf(node).Properties["inputs"], node.Map.Port)
+	return port.DataType
+}
+
+// partialMap 判断 map 节点是否有失败的元素（允许失败时节点仍然成功）
+func (wf *Workflow) partialMap(node *Node) bool {
+	if node.Map == nil {
+		return false
+	}
+	wf.mu.RLock()
+	defer wf.mu.RUnlock()
+	for _, e := range node.Elements {
+		if e.State != "success" {
+			return true
+		}
+	}
+	return false
+}
+
+// failElement 记录元素失败
+func (wf *Workflow) failElement(wfCtx context.Context, node *Node, i int, err error) {
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	node.Elements[i].State = FailureState(wfCtx, err)
+	node.Elements[i].Error = err.Error()
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEncodeElement(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		dt   *DataType
+		want string
+	}{
+		{"json 端口原样嵌入", ` {"k": 1} `, &DataType{Kind: DataKindJSON}, `{"k": 1}`},
+		{"int 端口原样嵌入", "42", &DataType{Kind: DataKindInt}, `42`},
+		{"bool 端口原样嵌入", "true", &DataType{Kind: DataKindBool}, `true`},
+		{"string 端口的数字文本", "42", &DataType{Kind: DataKindString}, `"42"`},
+		{"string 端口的 JSON 文本", `{"k":1}`, &DataType{Kind: DataKindString}, `"{\"k\":1}"`},
+		{"未声明类型的端口作为字符串", "null", nil, `"null"`},
+		{"json 端口数据不合法", "not json", &DataType{Kind: DataKindJSON}, `"not json"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(encodeElement([]byte(tt.data), tt.dt)); got != tt.want {
+				t.Fatalf("encodeElement(%q) = %s, want %s", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeElement(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		dt   *DataType
+		want string
+	}{
+		{"字符串取原文", `"a b"`, nil, "a b"},
+		{"数字保留 JSON 文本", `1.5`, &DataType{Kind: DataKindFloat}, "1.5"},
+		{"json 端口的字符串保留引号", `"a b"`, &DataType{Kind: DataKindJSON}, `"a b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(decodeElement(json.RawMessage(tt.raw), tt.dt)); got != tt.want {
+				t.Fatalf("decodeElement(%s) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"time"
+
+	"zflow/app/bff/memo"
+	"zflow/app/bff/secret"
+	"zflow/utils/blob"
+)
+
+// memoKeyFormat 缓存键与缓存项的格式版本，格式变化时修改以使旧缓存失效
+const memoKeyFormat = "2"
+
+// CachePolicy 节点类型的缓存策略：节点类型版本、输入与相关变量都相同的执行结果可以复用
+type CachePolicy struct {
+	Version string   `json:"version,omitempty"` // 节点类型版本，实现变化时修改以使旧缓存失效
+	Vars    []string `json:"vars,omitempty"`    // 参与缓存键的变量名
+	TTLMs   int64    `json:"ttl_ms,omitempty"`  // 缓存有效期（毫秒），0 表示不过期
+}
+
+// Validate 校验策略参数
+func (p *CachePolicy) Validate() error {
+	if p.TTLMs < 0 {
+		return fmt.Errorf("ttl_ms must not be negative")
+	}
+	return nil
+}
+
+// memoSource 可选接口，Context 配置了缓存时调度器通过它复用可缓存节点的输出
+type memoSource interface {
+	memoizer() *memoizer
+}
+
+// memoizer 按缓存键读写节点输出，为空时不缓存
+type memoizer struct {
+	store    memo.Store
+	vars     map[string]interface{}
+	redactor *secret.Redactor // 本次运行读取过的密钥值，含有密钥值的输入输出不缓存
+	// locate 返回服务中保存着指定 blob 的实例地址，没有时返回空；为空时缓存的 blob 引用原样使用
+	locate func(ctx context.Context, service, id string) string
+}
+
+// memoOf 返回 Context 配置的缓存，没有配置时为空
+func memoOf(ctx Context) *memoizer {
+	if src, ok := ctx.(memoSource); ok {
+		return src.memoizer()
+	}
+	return nil
+}
+
+// withVars 返回按 vars 计算缓存键的副本，子工作流的节点使用子工作流的变量
+func (m *memoizer) withVars(vars map[string]interface{}) *memoizer {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	c.vars = vars
+	return &c
+}
+
+// key 计算节点本次执行的缓存键，节点类型不可缓存或没有配置缓存时返回空。
+// 缓存键由格式版本、节点类型 UID、版本、map 端口、按端口名排序的输入以及策略中列出的变量组成，
+// blob 引用的输入只取内容摘要，与保存它的实例无关。
+func (m *memoizer) key(nodeType NodeType, node *Node, inputs map[string][]byte) string {
+	if m == nil || nodeType.Cache == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	writeField(h, []byte(memoKeyFormat))
+	writeField(h, []byte(nodeType.UID))
+	writeField(h, []byte(nodeType.Cache.Version))
+	if node.Map != nil {
+		writeField(h, []byte("map:"+node.Map.Port))
+	} else {
+		writeField(h, nil)
+	}
+
+	ports := sortedKeys(inputs)
+	binary.Write(h, binary.BigEndian, uint64(len(ports)))
+	for _, port := range ports {
+		writeField(h, []byte(port))
+		if ref, ok := blob.Decode(inputs[port]); ok {
+			writeField(h, []byte("blob:"+ref.ID))
+		} else {
+			writeField(h, inputs[port])
+		}
+	}
+
+	vars := append([]string(nil), nodeType.Cache.Vars...)
+	sort.Strings(vars)
+	binary.Write(h, binary.BigEndian, uint64(len(vars)))
+	for _, name := range vars {
+		writeField(h, []byte(name))
+		value, exists := m.vars[name]
+		if !exists {
+			writeField(h, nil)
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		writeField(h, append([]byte{'='}, data...))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get 读取缓存的输出，未命中或读取失败时返回 false。
+// 缓存中的 blob 引用不带地址，命中时重新查找保存它的实例，找不到时视为未命中
+func (m *memoizer) get(ctx Context, runCtx context.Context, key string, nodeType NodeType) (map[string][]byte, bool) {
+	if m == nil || key == "" {
+		return nil, false
+	}
+	e, err := m.store.Get(key)
+	if err != nil {
+		if !errors.Is(err, memo.ErrNotFound) {
+			ctx.Log(fmt.Sprintf("读取节点缓存失败: %v", err))
+		}
+		return nil, false
+	}
+	if m.locate == nil {
+		return e.Outputs, true
+	}
+	outputs := make(map[string][]byte, len(e.Outputs))
+	for port, data := range e.Outputs {
+		ref, ok := blob.Decode(data)
+		if !ok || ref.Addr != "" {
+			outputs[port] = data
+			continue
+		}
+		if ref.Addr = m.locate(runCtx, nodeType.Service, ref.ID); ref.Addr == "" {
+			ctx.Log(fmt.Sprintf("节点缓存引用的 blob %s 已不在任何实例上，重新执行", ref.ID))
+			return nil, false
+		}
+		outputs[port] = ref.Encode()
+	}
+	return outputs, true
+}
+
+// put 保存节点的输出，失败只记录日志。
+// 输入或输出中含有密钥值时不缓存，避免密钥明文写入缓存；blob 引用去掉实例地址，只按内容摘要保存
+func (m *memoizer) put(ctx Context, key string, nodeType NodeType, inputs, outputs map[string][]byte) {
+	if m == nil || key == "" {
+		return
+	}
+	for _, data := range [2]map[string][]byte{inputs, outputs} {
+		for _, v := range data {
+			if m.redactor.Contains(v) {
+				return
+			}
+		}
+	}
+	stored := make(map[string][]byte, len(outputs))
+	for port, data := range outputs {
+		if ref, ok := blob.Decode(data); ok {
+			ref.Addr = ""
+			data = ref.Encode()
+		}
+		stored[port] = data
+	}
+	e := memo.Entry{
+		Key:       key,
+		NodeType:  nodeType.UID,
+		Outputs:   stored,
+		CreatedAt: time.Now(),
+	}
+	if nodeType.Cache.TTLMs > 0 {
+		e.ExpiresAt = e.CreatedAt.Add(time.Duration(nodeType.Cache.TTLMs) * time.Millisecond)
+	}
+	if err := m.store.Put(e); err != nil {
+		ctx.Log(fmt.Sprintf("保存节点缓存失败: %v", err))
+	}
+}
+
+// writeField 写入带长度前缀的字段，避免相邻字段拼接产生歧义
+func writeField(h hash.Hash, data []byte) {
+	binary.Write(h, binary.BigEndian, uint64(len(data)))
+	h.Write(data)
+}
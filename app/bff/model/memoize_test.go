@@ -0,0 +1,164 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"zflow/app/bff/memo"
+	"zflow/app/bff/secret"
+	"zflow/utils/blob"
+)
+
+// secretMap 以 map 实现 secret.Provider
+type secretMap map[string]string
+
+func (m secretMap) Get(name string) (string, error) {
+	if v, ok := m[name]; ok {
+		return v, nil
+	}
+	return "", secret.ErrNotFound
+}
+
+func executeCached(t *testing.T, wf *Workflow, store memo.Store) error {
+	t.Helper()
+	return wf.ExecuteWorkflow(&ExecutionContext{
+		Workflow: wf,
+		Ctx:      context.Background(),
+		Memo:     store,
+		Secrets:  secretMap{"token": "s3cr3t"},
+		Redactor: secret.NewRedactor(),
+	})
+}
+
+func echoType(calls *atomic.Int32) NodeType {
+	return NodeType{
+		Operation: opFunc(func(_ Context, in map[string][]byte) (map[string][]byte, error) {
+			calls.Add(1)
+			if string(in["in"]) == "bad" {
+				return nil, errors.New("bad element")
+			}
+			return map[string][]byte{"out": in["in"]}, nil
+		}),
+		Cache:      &CachePolicy{Version: "1"},
+		Properties: map[string][]Port{"inputs": ports("in"), "outputs": ports("out")},
+	}
+}
+
+func TestMemoizeReusesOutputs(t *testing.T) {
+	var calls atomic.Int32
+	store := memo.NewMemoryStore(0)
+	for i := 0; i < 2; i++ {
+		wf := newTestWorkflow(t, `{"nodes": [{"id": "a", "node_type": "echo", "inputs": {"in": "aGk="}}]}`,
+			map[string]NodeType{"echo": echoType(&calls)})
+		if err := executeCached(t, wf, store); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(wf.Dag.Nodes["a"].Outputs["out"]); got != "hi" {
+			t.Fatalf("output = %q", got)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("operation called %d times, want 1", calls.Load())
+	}
+}
+
+func TestMemoizeSkipsPartialMap(t *testing.T) {
+	var calls atomic.Int32
+	store := memo.NewMemoryStore(0)
+	// ["ok","bad"]，bad 元素失败，节点允许失败
+	wf := newTestWorkflow(t, `{"nodes": [{"id": "m", "node_type": "echo", "inputs": {"in": "WyJvayIsImJhZCJd"},
+		"map": {"port": "in", "allow_failures": true}}]}`, map[string]NodeType{"echo": echoType(&calls)})
+	if err := executeCached(t, wf, store); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(wf.Dag.Nodes["m"].Outputs["out"]); got != `["ok",null]` {
+		t.Fatalf("output = %s", got)
+	}
+	if n := store.Stats().Entries; n != 0 {
+		t.Fatalf("cache entries = %d, want 0 for partial map output", n)
+	}
+}
+
+func TestMemoizeSkipsSecretInputs(t *testing.T) {
+	var calls atomic.Int32
+	store := memo.NewMemoryStore(0)
+	// 预设输入 "Bearer ${secrets.token}"
+	wf := newTestWorkflow(t, `{"nodes": [{"id": "a", "node_type": "echo", "inputs": {"in": "QmVhcmVyICR7c2VjcmV0cy50b2tlbn0="}},
+		{"id": "b", "node_type": "echo"}],
+		"connections": [{"connection_id": "1", "from": {"node_id": "a", "port_name": "out"}, "to": {"node_id": "b", "port_name": "in"}}]}`,
+		map[string]NodeType{"echo": echoType(&calls)})
+	if err := executeCached(t, wf, store); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(wf.Dag.Nodes["b"].Outputs["out"]); got != "Bearer s3cr3t" {
+		t.Fatalf("output = %q", got)
+	}
+	// a 引用了密钥，b 的输入含有密钥值，都不缓存
+	if n := store.Stats().Entries; n != 0 {
+		t.Fatalf("cache entries = %d, want 0 for secret-derived data", n)
+	}
+}
+
+func TestUpstreamSecretRefIsNotResolved(t *testing.T) {
+	var calls atomic.Int32
+	store := memo.NewMemoryStore(0)
+	// a 的预设输入 $${secrets.token} 求值为字面的 ${secrets.token}，作为 a 的输出传给 b
+	wf := newTestWorkflow(t, `{"nodes": [{"id": "a", "node_type": "echo", "text_inputs": {"in": "$${secrets.token}"}},
+		{"id": "b", "node_type": "echo"}],
+		"connections": [{"connection_id": "1", "from": {"node_id": "a", "port_name": "out"}, "to": {"node_id": "b", "port_name": "in"}}]}`,
+		map[string]NodeType{"echo": echoType(&calls)})
+	redactor := secret.NewRedactor()
+	err := wf.ExecuteWorkflow(&ExecutionContext{Workflow: wf, Ctx: context.Background(), Memo: store,
+		Secrets: secretMap{"token": "s3cr3t"}, Redactor: redactor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(wf.Dag.Nodes["b"].Outputs["out"]); got != "${secrets.token}" {
+		t.Fatalf("b output = %q, want the literal reference", got)
+	}
+	if redactor.Contains([]byte("s3cr3t")) {
+		t.Fatal("secret was read for connection data")
+	}
+	// 上游数据中的引用不影响缓存：b 与 a 的输入相同，命中 a 写入的缓存
+	if !wf.Dag.Nodes["b"].Cached || calls.Load() != 1 {
+		t.Fatalf("b cached = %v, operation calls = %d, want cache hit", wf.Dag.Nodes["b"].Cached, calls.Load())
+	}
+}
+
+func TestMemoizeBlobRefsByContent(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	store := memo.NewMemoryStore(0)
+	m := &memoizer{store: store}
+	nodeType := NodeType{UID: "t", Cache: &CachePolicy{}}
+	node := &Node{ID: "a"}
+
+	k1 := m.key(nodeType, node, map[string][]byte{"in": blob.Ref{ID: id, Size: 3, Addr: "10.0.0.1:9090"}.Encode()})
+	k2 := m.key(nodeType, node, map[string][]byte{"in": blob.Ref{ID: id, Size: 3, Addr: "10.0.0.2:9090"}.Encode()})
+	if k1 != k2 {
+		t.Fatal("cache key depends on blob address")
+	}
+
+	ctx := &ExecutionContext{Ctx: context.Background()}
+	m.put(ctx, k1, nodeType, nil, map[string][]byte{"out": blob.Ref{ID: id, Size: 3, Addr: "10.0.0.1:9090"}.Encode()})
+	e, err := store.Get(k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref, _ := blob.Decode(e.Outputs["out"]); ref.ID != id || ref.Addr != "" {
+		t.Fatalf("stored ref = %+v, want content hash without addr", ref)
+	}
+
+	// 命中时重新查找实例，找不到时视为未命中
+	m.locate = func(context.Context, string, string) string { return "" }
+	if _, hit := m.get(ctx, context.Background(), k1, nodeType); hit {
+		t.Fatal("hit with unavailable blob")
+	}
+	m.locate = func(context.Context, string, string) string { return "10.0.0.3:9090" }
+	outputs, hit := m.get(ctx, context.Background(), k1, nodeType)
+	if ref, _ := blob.Decode(outputs["out"]); !hit || ref.Addr != "10.0.0.3:9090" {
+		t.Fatalf("get() = %+v, %v", ref, hit)
+	}
+}
@@ -2,9 +2,26 @@ package model
 
 // Port 描述"某种节点类型"暴露出的端口
 type Port struct {
-	Name     string `json:"name"`      // in / out1 / pdf_out ...
-	Label    string `json:"label"`     // 可选，人类可读
-	PortType string `json:"port_type"` // connection / file
+	Name     string    `json:"name"`                // in / out1 / pdf_out ...
+	Label    string    `json:"label"`               // 可选，人类可读
+	PortType string    `json:"port_type"`           // connection / file
+	DataType *DataType `json:"data_type,omitempty"` // 可选，端口传递的数据类型
+}
+
+// PortTypeFile file 端口的端口类型，数据超过内联上限时以 blob 引用传递
+const PortTypeFile = "file"
+
+// IsFile 判断是否为 file 端口：端口类型为 file，或数据类型为 file
+func (p Port) IsFile() bool {
+	return p.PortType == PortTypeFile || (p.DataType != nil && p.DataType.Kind == DataKindFile)
+}
+
+// MIME 返回端口数据类型声明的 MIME，没有声明时为空
+func (p Port) MIME() string {
+	if p.DataType == nil {
+		return ""
+	}
+	return p.DataType.MIME
 }
 
 // Endpoint 表示一条连接线上的"端点"
@@ -20,8 +37,15 @@ type NodeType struct {
 	Note       string            `json:"note"`
 	Operation  Operation         `json:"operation"`
 	Properties map[string][]Port `json:"properties"`
+	Retry      *RetryPolicy      `json:"retry,omitempty"`   // 默认重试策略，可被工作流节点覆盖
+	Cache      *CachePolicy      `json:"cache,omitempty"`   // 缓存策略，为空表示节点输出不可缓存
+	Service    string            `json:"service,omitempty"` // 提供该节点类型的服务，由 bff 解析时填充
 }
 
+// ControlConnectionName 控制流连接类型的名称。
+// 控制流连线不传递数据，只决定目标节点是否执行：源节点输出了该端口时连线激活。
+const ControlConnectionName = "control"
+
 // ConnectionType 决定连线的语义与可连接端口类型
 type ConnectionType struct {
 	UID              string   `json:"connection_type"`
@@ -29,4 +53,18 @@ type ConnectionType struct {
 	Description      string   `json:"description"`
 	Color            string   `json:"color"`
 	AllowedPortTypes []string `json:"allowed_port_types"`
+	Service          string   `json:"service,omitempty"` // 提供该连接类型的服务，由 bff 解析时填充
+}
+
+// allows 判断连接类型是否允许连接该端口，AllowedPortTypes 为空时不限制
+func (ct ConnectionType) allows(port Port) bool {
+	if len(ct.AllowedPortTypes) == 0 {
+		return true
+	}
+	for _, t := range ct.AllowedPortTypes {
+		if t == port.PortType {
+			return true
+		}
+	}
+	return false
 }
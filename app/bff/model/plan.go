@@ -0,0 +1,140 @@
+package model
+
+import (
+	"slices"
+	"sort"
+
+	"zflow/app/bff/global"
+)
+
+// Plan 工作流的执行计划：按层级分组的节点、关键路径以及每个节点将由哪个服务执行。
+// 计划只依据当前的服务目录与负载均衡器生成，不执行任何节点，相同的工作流总是得到相同的计划。
+type Plan struct {
+	WorkflowID string `json:"workflow_id"`
+	Version    int    `json:"version,omitempty"`
+	// Levels 每一层的节点可以并行执行，下一层依赖上一层
+	Levels [][]string `json:"levels"`
+	// CriticalPath 最长的依赖链，决定了工作流至少需要执行的步数
+	CriticalPath []string   `json:"critical_path"`
+	Nodes        []PlanNode `json:"nodes"`
+}
+
+// PlanNode 执行计划中的一个节点
+type PlanNode struct {
+	ID       string   `json:"id"`
+	NodeType string   `json:"node_type"`
+	Label    string   `json:"label,omitempty"`
+	Level    int      `json:"level"`
+	Upstream []string `json:"upstream,omitempty"`
+	// Service 提供节点类型的服务，子工作流节点为空
+	Service   string         `json:"service,omitempty"`
+	Instances []PlanInstance `json:"instances,omitempty"`
+	// Available 服务当前有可用实例；子工作流节点要求其中所有节点都可用
+	Available bool `json:"available"`
+	// SubWorkflow 子工作流节点引用的工作流的执行计划
+	SubWorkflow *Plan `json:"subworkflow,omitempty"`
+}
+
+// PlanInstance 负载均衡器中可以执行节点的服务实例
+type PlanInstance struct {
+	ID   string `json:"id"`
+	Addr string `json:"addr"`
+}
+
+// Plan 生成执行计划，工作流需已通过 Resolve 与 Validate
+func (wf *Workflow) Plan() (*Plan, error) {
+	levels, err := wf.Levels()
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. 节点所在层级与上游节点
+	levelOf := make(map[string]int, len(wf.Dag.Nodes))
+	for i, level := range levels {
+		for _, nodeID := range level {
+			levelOf[nodeID] = i
+		}
+	}
+	upstream := make(map[string][]string)
+	for _, conn := range wf.Dag.Connections {
+		if !slices.Contains(upstream[conn.To.NodeID], conn.From.NodeID) {
+			upstream[conn.To.NodeID] = append(upstream[conn.To.NodeID], conn.From.NodeID)
+		}
+	}
+
+	plan := &Plan{
+		WorkflowID:   wf.ID,
+		Version:      wf.Version,
+		Levels:       levels,
+		CriticalPath: criticalPath(levels, levelOf, upstream),
+	}
+
+	// 2. 按层级顺序为每个节点确定执行的服务
+	for _, level := range levels {
+		for _, nodeID := range level {
+			node := wf.Dag.Nodes[nodeID]
+			sort.Strings(upstream[nodeID])
+			pn := PlanNode{
+				ID:       nodeID,
+				NodeType: node.TypeID,
+				Label:    node.Label,
+				Level:    levelOf[nodeID],
+				Upstream: upstream[nodeID],
+			}
+
+			if node.template != nil {
+				sub, err := node.template.Plan()
+				if err != nil {
+					return nil, err
+				}
+				pn.SubWorkflow = sub
+				pn.Available = sub.available()
+			} else {
+				pn.Service = wf.nodeTypeOf(node).Service
+				for _, inst := range global.LoadBalance.GetAllInstances(pn.Service) {
+					pn.Instances = append(pn.Instances, PlanInstance{ID: inst.ID, Addr: inst.Addr})
+				}
+				pn.Available = len(pn.Instances) > 0
+			}
+			plan.Nodes = append(plan.Nodes, pn)
+		}
+	}
+
+	return plan, nil
+}
+
+// available 计划中所有节点都有可用实例
+func (p *Plan) available() bool {
+	for _, node := range p.Nodes {
+		if !node.Available {
+			return false
+		}
+	}
+	return true
+}
+
+// criticalPath 从最深层中 ID 最小的节点出发，每次回溯到上一层中 ID 最小的上游节点。
+// 节点的层级就是以它结尾的最长依赖链的长度，所以得到的是一条最长依赖链。
+func criticalPath(levels [][]string, levelOf map[string]int, upstream map[string][]string) []string {
+	if len(levels) == 0 {
+		return []string{}
+	}
+
+	last := levels[len(levels)-1]
+	path := make([]string, len(levels))
+	nodeID := last[0]
+	for i := len(levels) - 1; i >= 0; i-- {
+		path[i] = nodeID
+		if i == 0 {
+			break
+		}
+		prev := ""
+		for _, up := range upstream[nodeID] {
+			if levelOf[up] == i-1 && (prev == "" || up < prev) {
+				prev = up
+			}
+		}
+		nodeID = prev
+	}
+	return path
+}
@@ -0,0 +1,193 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "zflow/api/base"
+	"zflow/utils/cache"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrTypeNotFound 服务目录中没有任何服务提供该类型
+	ErrTypeNotFound = errors.New("type not found in catalog")
+	// ErrTypeAmbiguous 多个服务提供了同一 UID 的类型，无法确定归属
+	ErrTypeAmbiguous = errors.New("type is ambiguous across services")
+)
+
+// Resolver 从 bff 缓存的服务目录中解析节点类型与连接类型，从定义源加载子工作流
+type Resolver struct {
+	cache *cache.Cache
+	defs  DefinitionSource
+}
+
+// NewResolver 创建解析器，defs 为空时不支持子工作流节点
+func NewResolver(c *cache.Cache, defs DefinitionSource) *Resolver {
+	return &Resolver{cache: c, defs: defs}
+}
+
+// NodeType 按 UID 解析节点类型，并附上所属服务
+func (r *Resolver) NodeType(uid string) (NodeType, error) {
+	found := r.cache.FindNodeType(uid)
+	if len(found) == 0 {
+		return NodeType{}, fmt.Errorf("node type %s: %w", uid, ErrTypeNotFound)
+	}
+	if len(found) > 1 {
+		return NodeType{}, fmt.Errorf("node type %s provided by %v: %w", uid, sortedKeys(found), ErrTypeAmbiguous)
+	}
+
+	for service, nt := range found {
+		nodeType, err := parseNodeType(nt)
+		if err != nil {
+			return NodeType{}, fmt.Errorf("node type %s provided by %s: %v", uid, service, err)
+		}
+		nodeType.Service = service
+		return nodeType, nil
+	}
+	return NodeType{}, nil
+}
+
+// ConnectionType 按 UID 解析连接类型，并附上所属服务。
+// 连接类型常被多个服务重复声明，定义完全一致时不视为歧义。
+func (r *Resolver) ConnectionType(uid string) (ConnectionType, error) {
+	found := r.cache.FindConnType(uid)
+	if len(found) == 0 {
+		return ConnectionType{}, fmt.Errorf("connection type %s: %w", uid, ErrTypeNotFound)
+	}
+
+	services := sortedKeys(found)
+	first := found[services[0]]
+	for _, service := range services[1:] {
+		if !proto.Equal(first, found[service]) {
+			return ConnectionType{}, fmt.Errorf("connection type %s provided by %v: %w", uid, services, ErrTypeAmbiguous)
+		}
+	}
+
+	connType := parseConnType(first)
+	connType.Service = services[0]
+	return connType, nil
+}
+
+// Resolve 为工作流中引用到的每个节点类型和连接类型填充元数据，
+// 并递归加载子工作流节点引用的工作流定义。
+// 解析失败时继续解析其余类型，返回按节点 ID 排序后的第一个错误，全部原因记录在诊断中。
+func (wf *Workflow) Resolve(r *Resolver) error {
+	return wf.resolve(r, []string{wf.ID})
+}
+
+// resolve stack 为从根工作流到当前工作流的定义 ID 路径
+func (wf *Workflow) resolve(r *Resolver, stack []string) error {
+	wf.nodeErrs = make(map[string]error)
+	wf.connErrs = make(map[string]error)
+	var firstErr error
+
+	for _, nodeID := range sortedKeys(wf.Dag.Nodes) {
+		node := wf.Dag.Nodes[nodeID]
+		if node.SubWorkflow != nil {
+			if err := wf.resolveSubWorkflow(r, node, stack); err != nil {
+				wf.nodeErrs[nodeID] = err
+				if firstErr == nil {
+					firstErr = fmt.Errorf("node %s: %w", nodeID, err)
+				}
+			}
+			continue
+		}
+		if _, ok := wf.NodeTypes[node.TypeID]; ok {
+			continue
+		}
+		nodeType, err := r.NodeType(node.TypeID)
+		if err != nil {
+			wf.nodeErrs[nodeID] = err
+			if firstErr == nil {
+				firstErr = fmt.Errorf("node %s: %w", nodeID, err)
+			}
+			continue
+		}
+		wf.NodeTypes[node.TypeID] = nodeType
+	}
+
+	for _, conn := range wf.Dag.Connections {
+		if _, ok := wf.ConnectionTypes[conn.TypeID]; ok {
+			continue
+		}
+		if _, failed := wf.connErrs[conn.TypeID]; failed {
+			continue
+		}
+		connType, err := r.ConnectionType(conn.TypeID)
+		if err != nil {
+			wf.connErrs[conn.TypeID] = err
+			if firstErr == nil {
+				firstErr = fmt.Errorf("connection %s: %w", conn.ID, err)
+			}
+			continue
+		}
+		wf.ConnectionTypes[conn.TypeID] = connType
+	}
+
+	return firstErr
+}
+
+// parseNodeType 将 v1.NodeType 转换为 NodeType，与 tool.ConvertNodeType 互逆
+func parseNodeType(nt *v1.NodeType) (NodeType, error) {
+	properties := make(map[string][]Port)
+	for k, portList := range nt.Properties {
+		ports := make([]Port, len(portList.GetPorts()))
+		for i, port := range portList.GetPorts() {
+			ports[i] = Port{
+				Name:     port.Name,
+				Label:    port.Label,
+				PortType: port.PortType,
+			}
+			if dt := port.GetDataType(); dt != nil {
+				ports[i].DataType = &DataType{Kind: dt.Kind, Schema: dt.Schema, MIME: dt.Mime}
+				if err := ports[i].DataType.Validate(); err != nil {
+					return NodeType{}, fmt.Errorf("port %s: %v", port.Name, err)
+				}
+			}
+		}
+		properties[k] = ports
+	}
+
+	nodeType := NodeType{
+		UID:        nt.Uid,
+		Category:   nt.Category,
+		Note:       nt.Note,
+		Properties: properties,
+	}
+	if r := nt.GetRetry(); r != nil {
+		nodeType.Retry = &RetryPolicy{
+			MaxAttempts:   int(r.MaxAttempts),
+			BackoffBaseMs: r.BackoffBaseMs,
+			BackoffMaxMs:  r.BackoffMaxMs,
+			Jitter:        r.Jitter,
+			RetryOn:       append([]string(nil), r.RetryOn...),
+		}
+		if err := nodeType.Retry.Validate(); err != nil {
+			return NodeType{}, fmt.Errorf("retry: %v", err)
+		}
+	}
+	if c := nt.GetCache(); c != nil {
+		nodeType.Cache = &CachePolicy{
+			Version: c.Version,
+			Vars:    append([]string(nil), c.Vars...),
+			TTLMs:   c.TtlMs,
+		}
+		if err := nodeType.Cache.Validate(); err != nil {
+			return NodeType{}, fmt.Errorf("cache: %v", err)
+		}
+	}
+	return nodeType, nil
+}
+
+// parseConnType 将 v1.ConnectionType 转换为 ConnectionType，与 tool.ConvertConnType 互逆
+func parseConnType(ct *v1.ConnectionType) ConnectionType {
+	return ConnectionType{
+		UID:              ct.Uid,
+		Name:             ct.Name,
+		Description:      ct.Description,
+		Color:            ct.Color,
+		AllowedPortTypes: append([]string(nil), ct.AllowedPortTypes...),
+	}
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PriorResult 上一次运行中节点的结果，续跑时用于复用输出
+type PriorResult struct {
+	State   string
+	Outputs map[string][]byte
+}
+
+// ResumeFrom 根据上一次运行各节点的结果准备续跑，工作流需已通过 Resolve 与 Validate：
+// 未成功的节点、被 overrides 覆盖输入的节点以及它们的所有下游重新执行，
+// 其余成功的节点复用上一次的输出，不再执行。返回复用输出的节点 ID，按 ID 排序。
+func (wf *Workflow) ResumeFrom(prior map[string]PriorResult, overrides map[string]map[string][]byte) ([]string, error) {
+	// 1. 校验覆盖的输入
+	for nodeID, inputs := range overrides {
+		node, ok := wf.Dag.Nodes[nodeID]
+		if !ok {
+			return nil, fmt.Errorf("node %s not found", nodeID)
+		}
+		inputPorts := wf.nodeTypeOf(node).Properties["inputs"]
+		for portName, data := range inputs {
+			port, found := findPort(inputPorts, portName)
+			if !found {
+				return nil, fmt.Errorf("节点 %s 的输入端口 %s 不存在", nodeID, portName)
+			}
+			if err := portDataType(node, "inputs", port).Check(data); err != nil {
+				return nil, fmt.Errorf("节点 %s 的输入端口 %s: %v", nodeID, portName, err)
+			}
+		}
+	}
+
+	// 2. 需要重新执行的节点：跳过的节点交给调度器按分支重新判断，不作为起点
+	rerun := make(map[string]bool)
+	var queue []string
+	for nodeID := range wf.Dag.Nodes {
+		state := prior[nodeID].State
+		_, overridden := overrides[nodeID]
+		if overridden || (state != "success" && state != "skipped") {
+			rerun[nodeID] = true
+			queue = append(queue, nodeID)
+		}
+	}
+	downstream := make(map[string][]string)
+	for _, conn := range wf.Dag.Connections {
+		downstream[conn.From.NodeID] = append(downstream[conn.From.NodeID], conn.To.NodeID)
+	}
+	for len(queue) > 0 {
+		nodeID := queue[0]
+		queue = queue[1:]
+		for _, next := range downstream[nodeID] {
+			if !rerun[next] {
+				rerun[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// 3. 复用其余成功节点的输出，写入覆盖的输入
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+
+	var reused []string
+	for nodeID, node := range wf.Dag.Nodes {
+		if rerun[nodeID] || prior[nodeID].State != "success" {
+			continue
+		}
+		node.State = "success"
+		node.Outputs = prior[nodeID].Outputs
+		node.Reused = true
+		reused = append(reused, nodeID)
+	}
+	for nodeID, inputs := range overrides {
+		for portName, data := range inputs {
+			wf.Dag.Nodes[nodeID].Inputs[portName] = data
+		}
+	}
+
+	sort.Strings(reused)
+	return reused, nil
+}
@@ -0,0 +1,199 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// 可重试的错误类别
+const (
+	ErrorClassTransport = "transport" // 调用链路错误：找不到实例、连接失败、gRPC 传输错误
+	ErrorClassTimeout   = "timeout"   // 单次尝试超时
+	ErrorClassOperation = "operation" // Operation 执行返回错误
+)
+
+const (
+	// maxRetryAttempts 重试策略允许的最大尝试次数
+	maxRetryAttempts = 100
+	// maxBackoff 退避时间的上限，未设置 backoff_max_ms 时同样生效
+	maxBackoff = time.Hour
+)
+
+// RetryPolicy 节点重试策略
+type RetryPolicy struct {
+	MaxAttempts   int      `json:"max_attempts"`       // 最大尝试次数（含首次），<=1 表示不重试
+	BackoffBaseMs int64    `json:"backoff_base_ms"`    // 退避基数（毫秒），按 2 的幂增长
+	BackoffMaxMs  int64    `json:"backoff_max_ms"`     // 退避上限（毫秒），0 表示使用默认上限 1 小时
+	Jitter        float64  `json:"jitter"`             // 抖动比例 0~1
+	RetryOn       []string `json:"retry_on,omitempty"` // 可重试的错误类别，为空时重试 transport 与 timeout
+}
+
+// Attempt 节点的一次执行尝试
+type Attempt struct {
+	Attempt    int       `json:"attempt"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	ErrorClass string    `json:"error_class,omitempty"`
+}
+
+// OperationError Operation 执行返回的错误，区别于调用链路上的错误
+type OperationError struct {
+	Err error
+}
+
+func (e *OperationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate 校验策略参数
+func (p *RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 || p.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("max_attempts must be within [0, %d]", maxRetryAttempts)
+	}
+	if p.BackoffBaseMs < 0 || p.BackoffMaxMs < 0 {
+		return fmt.Errorf("backoff must not be negative")
+	}
+	if p.BackoffBaseMs > maxBackoff.Milliseconds() || p.BackoffMaxMs > maxBackoff.Milliseconds() {
+		return fmt.Errorf("backoff must not exceed %s", maxBackoff)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be within [0, 1]")
+	}
+	for _, class := range p.RetryOn {
+		switch class {
+		case ErrorClassTransport, ErrorClassTimeout, ErrorClassOperation:
+		default:
+			return fmt.Errorf("unknown retry_on class %s", class)
+		}
+	}
+	return nil
+}
+
+// retryable 判断错误类别是否可重试
+func (p *RetryPolicy) retryable(class string) bool {
+	if len(p.RetryOn) == 0 {
+		return class == ErrorClassTransport || class == ErrorClassTimeout
+	}
+	for _, c := range p.RetryOn {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff 第 attempt 次失败后的等待时间，达到上限后不再翻倍，避免溢出
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	limit := maxBackoff
+	if p.BackoffMaxMs > 0 && p.BackoffMaxMs < limit.Milliseconds() {
+		limit = time.Duration(p.BackoffMaxMs) * time.Millisecond
+	}
+	delay := limit
+	if p.BackoffBaseMs < limit.Milliseconds() {
+		delay = time.Duration(p.BackoffBaseMs) * time.Millisecond
+	}
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay = min(delay*2, limit)
+	}
+	if p.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// errorClass 对节点执行错误分类，取消返回空字符串表示永不重试
+func errorClass(err error) string {
+	var opErr *OperationError
+	switch FailureState(context.Background(), err) {
+	case "timeout":
+		return ErrorClassTimeout
+	case "cancelled":
+		return ""
+	}
+	if errors.As(err, &opErr) {
+		return ErrorClassOperation
+	}
+	return ErrorClassTransport
+}
+
+// retryPolicy 节点生效的重试策略：节点配置优先，其次节点类型默认值
+func (wf *Workflow) retryPolicy(node *Node) *RetryPolicy {
+	if node.Retry != nil {
+		return node.Retry
+	}
+	return wf.nodeTypeOf(node).Retry
+}
+
+// runWithRetry 按重试策略执行节点，每次尝试都在持有 wf.mu 时交给 record 记录。
+// 节点超时作用于单次尝试，退避等待受工作流期限约束。
+func (wf *Workflow) runWithRetry(ctx Context, wfCtx context.Context, node *Node, inputs map[string][]byte, run runFunc, record func(Attempt)) (map[string][]byte, error) {
+	policy := wf.retryPolicy(node)
+	maxAttempts := 1
+	if policy != nil && policy.MaxAttempts > 1 {
+		maxAttempts = policy.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := wfCtx, context.CancelFunc(func() {})
+		if node.Timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(wfCtx, node.Timeout)
+		}
+
+		startedAt := time.Now()
+		outputs, err := run(attemptCtx, wf.nodeTypeOf(node), node, inputs)
+		// 单次尝试超时需在 cancel 前识别，避免被当作取消
+		if err != nil && attemptCtx.Err() == context.DeadlineExceeded && wfCtx.Err() == nil {
+			err = fmt.Errorf("attempt timed out after %s: %w", node.Timeout, context.DeadlineExceeded)
+		}
+		cancel()
+
+		a := Attempt{
+			Attempt:    attempt,
+			StartedAt:  startedAt,
+			DurationMs: time.Since(startedAt).Milliseconds(),
+		}
+		if err != nil {
+			a.Error = err.Error()
+			a.ErrorClass = errorClass(err)
+		}
+		wf.mu.Lock()
+		record(a)
+		wf.mu.Unlock()
+
+		if err == nil {
+			return outputs, nil
+		}
+		if attempt >= maxAttempts || wfCtx.Err() != nil || !policy.retryable(a.ErrorClass) {
+			return nil, err
+		}
+
+		// 退避后重试，map 节点的元素重试不改变节点状态
+		delay := policy.backoff(attempt)
+		if node.Map == nil {
+			wf.setNodeState(ctx, node.ID, "retrying")
+		}
+		ctx.Log(fmt.Sprintf("节点 %s 第 %d 次尝试失败（%s），%s 后重试: %v", node.ID, attempt, a.ErrorClass, delay, err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-wfCtx.Done():
+			timer.Stop()
+			return nil, err
+		}
+		if node.Map == nil {
+			wf.setNodeState(ctx, node.ID, "running")
+		}
+	}
+}
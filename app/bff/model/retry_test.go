@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"首次失败", RetryPolicy{BackoffBaseMs: 100}, 1, 100 * time.Millisecond},
+		{"按 2 的幂增长", RetryPolicy{BackoffBaseMs: 100}, 4, 800 * time.Millisecond},
+		{"受 backoff_max_ms 限制", RetryPolicy{BackoffBaseMs: 100, BackoffMaxMs: 500}, 10, 500 * time.Millisecond},
+		// 不设上限时翻倍 100 次会溢出为负数
+		{"未设上限时使用默认上限", RetryPolicy{BackoffBaseMs: 100}, 100, maxBackoff},
+		{"上限超过默认上限", RetryPolicy{BackoffBaseMs: 1, BackoffMaxMs: 1 << 62}, 100, maxBackoff},
+		{"基数超过默认上限", RetryPolicy{BackoffBaseMs: 1 << 62}, 1, maxBackoff},
+		{"基数为 0", RetryPolicy{}, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.backoff(tt.attempt); got != tt.want {
+				t.Fatalf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitterStaysPositive(t *testing.T) {
+	p := RetryPolicy{BackoffBaseMs: 1000, Jitter: 1}
+	for attempt := 1; attempt <= maxRetryAttempts; attempt++ {
+		if got := p.backoff(attempt); got < 0 || got > 2*maxBackoff {
+			t.Fatalf("backoff(%d) = %s", attempt, got)
+		}
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   string
+	}{
+		{"合法", RetryPolicy{MaxAttempts: 3, BackoffBaseMs: 100, Jitter: 0.2, RetryOn: []string{"operation"}}, ""},
+		{"尝试次数过多", RetryPolicy{MaxAttempts: maxRetryAttempts + 1}, "max_attempts"},
+		{"尝试次数为负", RetryPolicy{MaxAttempts: -1}, "max_attempts"},
+		{"退避过长", RetryPolicy{BackoffMaxMs: 2 * maxBackoff.Milliseconds()}, "backoff must not exceed"},
+		{"未知错误类别", RetryPolicy{RetryOn: []string{"panic"}}, "unknown retry_on class"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
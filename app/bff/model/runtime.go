@@ -2,6 +2,9 @@ package model
 
 import (
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 )
 
 // Node 是一个具体实例，引用某个 NodeType
@@ -9,11 +12,38 @@ type Node struct {
 	ID     string `json:"id"`
 	TypeID string `json:"node_type"` // 对应 NodeType.ID
 	Label  string `json:"label"`
+	// Timeout 节点单次尝试的执行超时，0 表示不限制
+	Timeout time.Duration `json:"-"`
+	// Retry 节点重试策略，为空时使用节点类型的默认策略
+	Retry *RetryPolicy `json:"-"`
+	// Map 非空时节点对列表逐元素执行
+	Map *MapConfig `json:"-"`
+	// SubWorkflow 非空时节点执行引用的工作流定义
+	SubWorkflow *SubWorkflowConfig `json:"-"`
+	// nodeType 节点专属的节点类型，子工作流节点按端口映射生成
+	nodeType *NodeType
+	// template 解析并校验过的子工作流，每次执行时复制
+	template *Workflow
 	// 运行期字段 ↓↓↓
-	State string `json:"-"` // running / success / failed ...
+	State string `json:"-"` // queued / running / success / failed / timeout / cancelled ...
+	Error string `json:"-"` // 节点失败的原因
+	// Reused 续跑时复用了上一次运行的输出，不再执行
+	Reused bool `json:"-"`
+	// Cached 输出来自节点缓存，没有调用 RunNode
+	Cached bool `json:"-"`
+	// Progress 节点执行过程中最近一次上报的进度
+	Progress *Progress `json:"-"`
 	// 存储每个端口的输入输出数据
 	Inputs  map[string][]byte `json:"-"` // 端口名 -> 输入数据
 	Outputs map[string][]byte `json:"-"` // 端口名 -> 输出数据
+	// passed 父工作流传入子工作流入口端口的数据，与连线数据一样原样传递，不作为预设输入求值
+	passed map[string]bool
+	// 每次执行尝试的记录
+	Attempts []Attempt `json:"-"`
+	// map 节点每个元素的执行结果
+	Elements []ElementResult `json:"-"`
+	// 子工作流节点最近一次执行的子工作流实例
+	Child *Workflow `json:"-"`
 }
 
 // Connection 表示有向边
@@ -32,8 +62,26 @@ type Dag struct {
 
 // Workflow 则在更高一层，打包元数据 + DAG + 运行时映射关系
 type Workflow struct {
-	ID  string
-	Dag *Dag
+	ID string
+	// Version 工作流定义的版本，直接提交的工作流为 0
+	Version int
+	Dag     *Dag
+	// Timeout 整个工作流的执行期限，0 表示不限制
+	Timeout time.Duration
+	// Vars 工作流声明的变量，预设输入中以 ${vars.名称} 引用
+	Vars map[string]VarDecl
+	// path 子工作流在父工作流中的节点路径前缀，用于事件中的节点 ID
+	path string
+	// entries 父工作流绑定的子工作流入口端口，数据由父节点提供，校验时不要求预设输入或连线
+	entries map[Endpoint]bool
+	// diagnostics 构建时发现的问题，如重复的节点 ID
+	diagnostics []Diagnostic
+	// nodeErrs / connErrs Resolve 失败的原因，分别以节点 ID 和连接类型 ID 为键
+	nodeErrs map[string]error
+	connErrs map[string]error
+
+	// mu 保护运行期的节点状态和输入输出，节点并行执行时使用
+	mu sync.RWMutex
 
 	// 元数据查字典
 	NodeTypes       map[string]NodeType
@@ -47,6 +95,17 @@ type RawWorkflow struct {
 		NodeType string            `json:"node_type"`
 		Label    string            `json:"label"`
 		Inputs   map[string][]byte `json:"inputs,omitempty"`
+		// TextInputs 以文本给出的预设输入，JSON 中不需要 base64 编码，适合书写 ${...} 表达式；
+		// 与 inputs 合并，同一端口不能同时出现在两者中
+		TextInputs map[string]string `json:"text_inputs,omitempty"`
+		// TimeoutMs 节点单次尝试的执行超时（毫秒），0 表示不限制
+		TimeoutMs int64 `json:"timeout_ms,omitempty"`
+		// Retry 节点重试策略，覆盖节点类型的默认策略
+		Retry *RetryPolicy `json:"retry,omitempty"`
+		// Map 将节点展开为对列表逐元素执行
+		Map *MapConfig `json:"map,omitempty"`
+		// SubWorkflow 子工作流节点引用的工作流定义及端口映射
+		SubWorkflow *SubWorkflowConfig `json:"subworkflow,omitempty"`
 	} `json:"nodes"`
 	Connections []struct {
 		ID             string   `json:"connection_id"`
@@ -54,12 +113,25 @@ type RawWorkflow struct {
 		From           Endpoint `json:"from"`
 		To             Endpoint `json:"to"`
 	} `json:"connections"`
+	// TimeoutMs 整个工作流的执行期限（毫秒），0 表示不限制
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+	// Vars 声明的变量及默认值，每次运行可以覆盖
+	Vars map[string]VarDecl `json:"vars,omitempty"`
 }
 
 // NewWorkflow 从 JSON 配置创建新的工作流实例
 func NewWorkflow(uid string, raw RawWorkflow) (*Workflow, error) {
+	if raw.TimeoutMs < 0 {
+		return nil, fmt.Errorf("workflow timeout_ms must not be negative")
+	}
+	if err := validateVars(raw.Vars); err != nil {
+		return nil, err
+	}
+
 	wf := &Workflow{
 		ID:              uid,
+		Timeout:         time.Duration(raw.TimeoutMs) * time.Millisecond,
+		Vars:            raw.Vars,
 		Dag:             &Dag{Nodes: make(map[string]*Node)},
 		NodeTypes:       make(map[string]NodeType),
 		ConnectionTypes: make(map[string]ConnectionType),
@@ -67,11 +139,51 @@ func NewWorkflow(uid string, raw RawWorkflow) (*Workflow, error) {
 
 	// 3. 节点
 	for _, n := range raw.Nodes {
+		if n.TimeoutMs < 0 {
+			return nil, fmt.Errorf("node %s timeout_ms must not be negative", n.ID)
+		}
+		if n.Retry != nil {
+			if err := n.Retry.Validate(); err != nil {
+				return nil, fmt.Errorf("node %s retry: %v", n.ID, err)
+			}
+		}
+		if n.Map != nil && n.Map.Port == "" {
+			return nil, fmt.Errorf("node %s map port is required", n.ID)
+		}
+		if (n.NodeType == SubWorkflowNodeType) != (n.SubWorkflow != nil) {
+			return nil, fmt.Errorf("node %s: subworkflow config is required exactly for node type %s", n.ID, SubWorkflowNodeType)
+		}
+		if n.SubWorkflow != nil {
+			if n.SubWorkflow.WorkflowID == "" {
+				return nil, fmt.Errorf("node %s subworkflow workflow_id is required", n.ID)
+			}
+			if n.SubWorkflow.Version < 0 {
+				return nil, fmt.Errorf("node %s subworkflow version must not be negative", n.ID)
+			}
+			if n.Map != nil {
+				return nil, fmt.Errorf("node %s: map is not supported on sub-workflow nodes", n.ID)
+			}
+		}
+		if n.ID == "" {
+			wf.diagnostics = append(wf.diagnostics, Diagnostic{Code: DiagInvalidNodeID, Severity: SeverityError,
+				Message: fmt.Sprintf("node of type %s has no id", n.NodeType)})
+			continue
+		}
+		if _, exists := wf.Dag.Nodes[n.ID]; exists {
+			wf.diagnostics = append(wf.diagnostics, Diagnostic{Code: DiagDuplicateNodeID, Severity: SeverityError, NodeID: n.ID,
+				Message: fmt.Sprintf("duplicate node id %s", n.ID)})
+			continue
+		}
 		node := &Node{
-			ID:     n.ID,
-			TypeID: n.NodeType,
-			Label:  n.Label,
-			Inputs: make(map[string][]byte), // 初始化 Inputs map
+			ID:      n.ID,
+			TypeID:  n.NodeType,
+			Label:   n.Label,
+			Timeout: time.Duration(n.TimeoutMs) * time.Millisecond,
+			Retry:   n.Retry,
+			Map:     n.Map,
+			Inputs:  make(map[string][]byte), // 初始化 Inputs map
+
+			SubWorkflow: n.SubWorkflow,
 		}
 
 		// 如果有输入数据，复制到节点的 Inputs
@@ -80,6 +192,12 @@ func NewWorkflow(uid string, raw RawWorkflow) (*Workflow, error) {
 				node.Inputs[k] = v
 			}
 		}
+		for k, v := range n.TextInputs {
+			if _, exists := node.Inputs[k]; exists {
+				return nil, fmt.Errorf("node %s input %s is set in both inputs and text_inputs", n.ID, k)
+			}
+			node.Inputs[k] = []byte(v)
+		}
 
 		wf.Dag.Nodes[n.ID] = node
 	}
@@ -98,93 +216,125 @@ func NewWorkflow(uid string, raw RawWorkflow) (*Workflow, error) {
 	return wf, nil
 }
 
-// Validate 验证工作流的节点和连接是否有效
+// Validate 验证工作流，返回第一个错误级别的问题，完整的诊断见 Diagnose
 func (wf *Workflow) Validate() error {
-	// 1. 验证基本结构
-	if len(wf.Dag.Nodes) == 0 || len(wf.Dag.Connections) == 0 {
-		return fmt.Errorf("workflow is required")
-	}
-
-	// 2. 验证节点
-	for nodeID, node := range wf.Dag.Nodes {
-		// 2.1 验证节点类型是否在工作流中定义
-		nodeType, exists := wf.NodeTypes[node.TypeID]
-		if !exists {
-			return fmt.Errorf("node %s references undefined node type %s", nodeID, node.TypeID)
-		}
-
-		// 2.2 验证输入端口
-		inputPorts := nodeType.Properties["inputs"]
-		for inputName := range node.Inputs {
-			found := false
-			for _, port := range inputPorts {
-				if port.Name == inputName {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return fmt.Errorf("节点 %s 的输入端口 %s 不存在", nodeID, inputName)
-			}
+	for _, d := range wf.Diagnose() {
+		if d.Severity == SeverityError {
+			return fmt.Errorf("%s", d.Message)
 		}
 	}
+	return nil
+}
 
-	// 3. 验证连接
-	for _, conn := range wf.Dag.Connections {
-		// 3.1 验证连接类型是否在工作流中定义
-		if _, exists := wf.ConnectionTypes[conn.TypeID]; !exists {
-			return fmt.Errorf("connection %s references undefined connection type %s", conn.ID, conn.TypeID)
-		}
+func (wf *Workflow) InjectOperations() {
+}
 
-		// 3.2 验证源节点和目标节点是否存在
-		if _, exists := wf.Dag.Nodes[conn.From.NodeID]; !exists {
-			return fmt.Errorf("connection %s references unknown source node %s", conn.ID, conn.From.NodeID)
-		}
-		if _, exists := wf.Dag.Nodes[conn.To.NodeID]; !exists {
-			return fmt.Errorf("connection %s references unknown target node %s", conn.ID, conn.To.NodeID)
-		}
+// lookupNodeType 查找节点生效的节点类型：节点专属类型优先，其次工作流的类型字典
+func (wf *Workflow) lookupNodeType(node *Node) (NodeType, bool) {
+	if node.nodeType != nil {
+		return *node.nodeType, true
+	}
+	nodeType, exists := wf.NodeTypes[node.TypeID]
+	return nodeType, exists
+}
 
-		// 3.3 验证端口是否存在
-		sourceNode := wf.Dag.Nodes[conn.From.NodeID]
-		targetNode := wf.Dag.Nodes[conn.To.NodeID]
-		sourceNodeType := wf.NodeTypes[sourceNode.TypeID]
-		targetNodeType := wf.NodeTypes[targetNode.TypeID]
+// nodeTypeOf 返回节点生效的节点类型，未解析时返回零值
+func (wf *Workflow) nodeTypeOf(node *Node) NodeType {
+	nodeType, _ := wf.lookupNodeType(node)
+	return nodeType
+}
 
-		// 验证源节点输出端口
-		found := false
-		for _, port := range sourceNodeType.Properties["outputs"] {
-			if port.Name == conn.From.PortName {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return fmt.Errorf("connection %s references unknown output port %s in node %s",
-				conn.ID, conn.From.PortName, conn.From.NodeID)
+// findPort 在端口列表中查找指定端口
+func findPort(ports []Port, name string) (Port, bool) {
+	for _, port := range ports {
+		if port.Name == name {
+			return port, true
 		}
+	}
+	return Port{}, false
+}
 
-		// 验证目标节点输入端口
-		found = false
-		for _, port := range targetNodeType.Properties["inputs"] {
-			if port.Name == conn.To.PortName {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return fmt.Errorf("connection %s references unknown input port %s in node %s",
-				conn.ID, conn.To.PortName, conn.To.NodeID)
-		}
+// hasPort 判断端口列表中是否存在指定端口
+func hasPort(ports []Port, name string) bool {
+	_, ok := findPort(ports, name)
+	return ok
+}
+
+// sortedKeys 返回 map 排序后的键，用于按确定的顺序遍历
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	return nil
+// portDataType 端口在连线上实际传递的数据类型：
+// map 节点的 map 端口接收 JSON 数组，输出端口汇总为 JSON 数组
+func portDataType(node *Node, direction string, port Port) *DataType {
+	if node.Map != nil && (direction == "outputs" || port.Name == node.Map.Port) {
+		return &DataType{Kind: DataKindJSON}
+	}
+	return port.DataType
 }
 
-func (wf *Workflow) InjectOperations() {
+// isControl 判断连接是否为控制流连线
+func (wf *Workflow) isControl(conn Connection) bool {
+	return wf.ConnectionTypes[conn.TypeID].Name == ControlConnectionName
 }
 
-// TopologicalSort 对 DAG 进行拓扑排序
+// edgeActive 判断连线是否激活：源节点执行成功且输出了连线的源端口。
+// 分支节点通过只输出部分端口来选择激活哪些下游分支。调用方需持有 wf.mu。
+func (wf *Workflow) edgeActive(conn Connection) bool {
+	source := wf.Dag.Nodes[conn.From.NodeID]
+	if source == nil || source.State != "success" {
+		return false
+	}
+	output, exists := source.Outputs[conn.From.PortName]
+	return exists && output != nil
+}
+
+// branchInactive 判断节点是否位于未激活的分支上：
+// 有控制流连线时，所有控制流连线都未激活；否则所有数据连线都未激活。
+// 没有任何入边的节点总是执行。调用方需持有 wf.mu。
+func (wf *Workflow) branchInactive(nodeID string) bool {
+	var hasControl, controlActive, hasData, dataActive bool
+	for _, conn := range wf.Dag.Connections {
+		if conn.To.NodeID != nodeID {
+			continue
+		}
+		active := wf.edgeActive(conn)
+		if wf.isControl(conn) {
+			hasControl = true
+			controlActive = controlActive || active
+		} else {
+			hasData = true
+			dataActive = dataActive || active
+		}
+	}
+	if hasControl {
+		return !controlActive
+	}
+	return hasData && !dataActive
+}
+
+// TopologicalSort 对 DAG 进行拓扑排序，按层级依次输出，同一层级内按节点 ID 排序，结果是确定的
 func (wf *Workflow) TopologicalSort() ([]string, error) {
+	levels, err := wf.Levels()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(wf.Dag.Nodes))
+	for _, level := range levels {
+		result = append(result, level...)
+	}
+	return result, nil
+}
+
+// Levels 将节点按层级分组：没有上游的节点位于第 0 层，其余节点位于所有上游节点的下一层。
+// 同一层级的节点互不依赖，可以并行执行；层级内按节点 ID 排序。
+func (wf *Workflow) Levels() ([][]string, error) {
 	// 构建邻接表
 	graph := make(map[string][]string)
 	inDegree := make(map[string]int)
@@ -200,36 +350,38 @@ func (wf *Workflow) TopologicalSort() ([]string, error) {
 		inDegree[conn.To.NodeID]++
 	}
 
-	// 拓扑排序
-	var result []string
-	var queue []string
-
-	// 将入度为 0 的节点加入队列
+	// 将入度为 0 的节点作为第 0 层
+	var current []string
 	for nodeID, degree := range inDegree {
 		if degree == 0 {
-			queue = append(queue, nodeID)
+			current = append(current, nodeID)
 		}
 	}
 
-	// 处理队列
-	for len(queue) > 0 {
-		nodeID := queue[0]
-		queue = queue[1:]
-		result = append(result, nodeID)
-
-		// 更新相邻节点的入度
-		for _, neighbor := range graph[nodeID] {
-			inDegree[neighbor]--
-			if inDegree[neighbor] == 0 {
-				queue = append(queue, neighbor)
+	// 逐层处理，下游节点的入度减为 0 时进入下一层
+	var levels [][]string
+	visited := 0
+	for len(current) > 0 {
+		sort.Strings(current)
+		levels = append(levels, current)
+		visited += len(current)
+
+		var next []string
+		for _, nodeID := range current {
+			for _, neighbor := range graph[nodeID] {
+				inDegree[neighbor]--
+				if inDegree[neighbor] == 0 {
+					next = append(next, neighbor)
+				}
 			}
 		}
+		current = next
 	}
 
 	// 检查是否有环
-	if len(result) != len(wf.Dag.Nodes) {
+	if visited != len(wf.Dag.Nodes) {
 		return nil, fmt.Errorf("workflow contains cycles")
 	}
 
-	return result, nil
+	return levels, nil
 }
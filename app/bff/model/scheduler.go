@@ -0,0 +1,360 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limits 节点并发限制，0 表示不限制
+type Limits struct {
+	MaxConcurrency int `json:"max_concurrency"` // 全局同时运行的节点数上限
+	MaxPerService  int `json:"max_per_service"` // 单个服务同时运行的节点数上限
+}
+
+// runFunc 运行单个节点，ctx 已带上节点与工作流的期限，inputs 为收集好的输入快照
+type runFunc func(ctx context.Context, nodeType NodeType, node *Node, inputs map[string][]byte) (map[string][]byte, error)
+
+// semaphore 计数信号量，nil 表示不限制
+type semaphore chan struct{}
+
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// limiter 全局与按服务的并发槽位，嵌套的子工作流共享同一个 limiter
+type limiter struct {
+	limits   Limits
+	global   semaphore
+	mu       sync.Mutex
+	services map[string]semaphore
+}
+
+func newLimiter(limits Limits) *limiter {
+	return &limiter{
+		limits:   limits,
+		global:   newSemaphore(limits.MaxConcurrency),
+		services: make(map[string]semaphore),
+	}
+}
+
+// service 获取服务的并发槽位，服务名为空时不限制
+func (l *limiter) service(name string) semaphore {
+	if name == "" {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.services[name]; !ok {
+		l.services[name] = newSemaphore(l.limits.MaxPerService)
+	}
+	return l.services[name]
+}
+
+// nodeResult 工作协程回传给调度循环的结果
+type nodeResult struct {
+	nodeID  string
+	outputs map[string][]byte
+	state   string
+	cached  bool // 输出来自节点缓存
+	err     error
+}
+
+// FailureState 根据错误判断节点或运行的失败状态：timeout / cancelled / failed
+func FailureState(ctx context.Context, err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), status.Code(err) == codes.DeadlineExceeded:
+		return "timeout"
+	case errors.Is(err, context.Canceled), status.Code(err) == codes.Canceled:
+		return "cancelled"
+	}
+	// Operation 可能把取消包装成普通错误返回
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return "timeout"
+	case context.Canceled:
+		return "cancelled"
+	}
+	return "failed"
+}
+
+// schedule 按依赖关系并行调度节点：上游节点全部结束后立即启动该节点，
+// 位于未激活分支上的节点标记为 skipped。
+// 节点状态只在调度循环和持有 wf.mu 时修改；任一节点失败后不再启动新节点，
+// 等待已启动的节点结束后返回第一个错误。工作流期限到达或被取消时，
+// 尚未完成的节点标记为 timeout / cancelled。
+func (wf *Workflow) schedule(ctx Context, limits Limits, run runFunc) error {
+	return wf.scheduleWith(ctx, ctx.Context(), newLimiter(limits), exprEnvOf(ctx), run)
+}
+
+// scheduleWith 在给定的 context、并发槽位与求值环境下调度工作流，
+// 子工作流复用父工作流的 limiter，使用自己的求值环境
+func (wf *Workflow) scheduleWith(ctx Context, parent context.Context, lim *limiter, env *exprEnv, run runFunc) error {
+	levels, err := wf.Levels()
+	if err != nil {
+		return fmt.Errorf("failed to sort workflow: %v", err)
+	}
+	ctx.Log(fmt.Sprintf("工作流 %s 执行层级: %v", wf.ID, levels))
+
+	wfCtx := parent
+	if wf.Timeout > 0 {
+		var cancel context.CancelFunc
+		wfCtx, cancel = context.WithTimeout(wfCtx, wf.Timeout)
+		defer cancel()
+	}
+
+	// 1. 构建下游表和待完成的上游连接数
+	downstream := make(map[string][]string)
+	pending := make(map[string]int)
+	for _, conn := range wf.Dag.Connections {
+		downstream[conn.From.NodeID] = append(downstream[conn.From.NodeID], conn.To.NodeID)
+		pending[conn.To.NodeID]++
+	}
+
+	results := make(chan nodeResult)
+	cache := memoOf(ctx).withVars(env.vars)
+	running := 0
+
+	var start func(nodeID string)
+
+	// advance 节点结束（成功或跳过）后释放下游节点
+	advance := func(nodeID string) {
+		for _, next := range downstream[nodeID] {
+			pending[next]--
+			if pending[next] == 0 {
+				start(next)
+			}
+		}
+	}
+
+	start = func(nodeID string) {
+		node := wf.Dag.Nodes[nodeID]
+
+		// 续跑时复用上一次输出的节点直接视为成功
+		if node.Reused {
+			wf.emitNodeEvent(ctx, nodeID, "success", "")
+			ctx.Log(fmt.Sprintf("节点 %s 复用上一次运行的输出", nodeID))
+			advance(nodeID)
+			return
+		}
+
+		// 位于未激活分支上的节点直接跳过，并继续向下游传播
+		wf.mu.Lock()
+		inactive := wf.branchInactive(nodeID)
+		if inactive {
+			node.State = "skipped"
+		}
+		wf.mu.Unlock()
+		if inactive {
+			wf.emitNodeEvent(ctx, nodeID, "skipped", "")
+			ctx.Log(fmt.Sprintf("节点 %s 位于未激活的分支上，跳过", nodeID))
+			advance(nodeID)
+			return
+		}
+
+		// 子工作流节点与 map 节点只负责编排，不占用并发槽位，由其内部节点或每个元素各自占用，避免互相等待
+		sem, globalSem := lim.service(wf.nodeTypeOf(node).Service), lim.global
+		if node.SubWorkflow != nil || node.Map != nil {
+			sem, globalSem = nil, nil
+		}
+		wf.setNodeState(ctx, nodeID, "queued")
+		running++
+
+		go func() {
+			// 先占服务槽位再占全局槽位，等待服务槽位时不占用全局并发
+			if err := sem.acquire(wfCtx); err != nil {
+				results <- nodeResult{nodeID: nodeID, state: FailureState(wfCtx, err), err: fmt.Errorf("node %s not started: %w", nodeID, err)}
+				return
+			}
+			defer sem.release()
+			if err := globalSem.acquire(wfCtx); err != nil {
+				results <- nodeResult{nodeID: nodeID, state: FailureState(wfCtx, err), err: fmt.Errorf("node %s not started: %w", nodeID, err)}
+				return
+			}
+			defer globalSem.release()
+
+			ctx.Log(fmt.Sprintf("开始执行节点 %s (%s)", nodeID, node.Label))
+
+			wf.mu.Lock()
+			// 只对工作流作者写下的预设输入求值，连线传入的数据原样传递，其中的 ${ 不是表达式。
+			// 引用了密钥的节点不缓存，密钥值不能进入缓存键与缓存内容
+			presets := wf.presetInputs(node)
+			secretRef := referencesSecrets(presets)
+			// 表达式在派发前求值，结果记入节点输入，密钥只出现在派发的输入中
+			shown, err := wf.evalInputs(env, node, presets)
+			if err == nil {
+				for k, v := range shown {
+					node.Inputs[k] = v
+				}
+				err = wf.collectNodeInputs(nodeID)
+			}
+			inputs := make(map[string][]byte, len(node.Inputs))
+			for k, v := range node.Inputs {
+				inputs[k] = v
+			}
+			for k, v := range presets {
+				inputs[k] = v
+			}
+			node.State = "running"
+			wf.mu.Unlock()
+			wf.emitNodeEvent(ctx, nodeID, "running", "")
+
+			if err != nil {
+				results <- nodeResult{nodeID: nodeID, state: "failed", err: fmt.Errorf("failed to prepare inputs for node %s: %v", nodeID, err)}
+				return
+			}
+
+			// 可缓存的节点命中缓存时不再执行
+			nodeType := wf.nodeTypeOf(node)
+			key := ""
+			if !secretRef {
+				key = cache.key(nodeType, node, inputs)
+			}
+			if outputs, hit := cache.get(ctx, wfCtx, key, nodeType); hit {
+				results <- nodeResult{nodeID: nodeID, outputs: outputs, state: "success", cached: true}
+				return
+			}
+
+			outputs, err := wf.execNode(ctx, wfCtx, lim, env, node, inputs, run)
+			if err != nil {
+				results <- nodeResult{nodeID: nodeID, state: FailureState(wfCtx, err), err: fmt.Errorf("node %s execution failed: %w", nodeID, err)}
+				return
+			}
+			// 部分元素失败的 map 输出中有 null，失败可能是暂时的，不缓存
+			if !wf.partialMap(node) {
+				cache.put(ctx, key, nodeType, inputs, outputs)
+			}
+			results <- nodeResult{nodeID: nodeID, outputs: outputs, state: "success"}
+		}()
+	}
+
+	// 3. 启动所有没有上游的节点，按 ID 排序保证启动顺序稳定
+	var roots []string
+	for nodeID := range wf.Dag.Nodes {
+		if pending[nodeID] == 0 {
+			roots = append(roots, nodeID)
+		}
+	}
+	sort.Strings(roots)
+	for _, nodeID := range roots {
+		start(nodeID)
+	}
+
+	// 4. 调度循环
+	var firstErr error
+	for running > 0 {
+		res := <-results
+		running--
+
+		if res.err != nil {
+			wf.mu.Lock()
+			node := wf.Dag.Nodes[res.nodeID]
+			node.State = res.state
+			node.Error = res.err.Error()
+			wf.mu.Unlock()
+			wf.emitNodeEvent(ctx, res.nodeID, res.state, res.err.Error())
+			ctx.Log(res.err.Error())
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+
+		wf.mu.Lock()
+		node := wf.Dag.Nodes[res.nodeID]
+		node.Outputs = res.outputs
+		node.State = "success"
+		node.Cached = res.cached
+		wf.mu.Unlock()
+		wf.emitNodeEvent(ctx, res.nodeID, "success", "")
+		if res.cached {
+			ctx.Log(fmt.Sprintf("节点 %s 命中缓存，跳过执行", res.nodeID))
+		} else {
+			ctx.Log(fmt.Sprintf("节点 %s 执行完成，状态: %s", res.nodeID, "success"))
+		}
+
+		if firstErr != nil {
+			continue
+		}
+		if err := wfCtx.Err(); err != nil {
+			firstErr = fmt.Errorf("workflow %s stopped: %w", wf.ID, err)
+			continue
+		}
+		advance(res.nodeID)
+	}
+
+	// 5. 因期限或取消而没有机会运行的节点
+	if err := wfCtx.Err(); err != nil {
+		state := FailureState(wfCtx, err)
+		var stopped []string
+		wf.mu.Lock()
+		for nodeID, node := range wf.Dag.Nodes {
+			if node.State == "" {
+				node.State = state
+				stopped = append(stopped, nodeID)
+			}
+		}
+		wf.mu.Unlock()
+		sort.Strings(stopped)
+		for _, nodeID := range stopped {
+			wf.emitNodeEvent(ctx, nodeID, state, "")
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("workflow %s stopped: %w", wf.ID, err)
+		}
+	}
+
+	return firstErr
+}
+
+// execNode 执行节点：子工作流节点在 bff 内展开执行，map 节点按元素展开，
+// 其它节点直接按重试策略执行
+func (wf *Workflow) execNode(ctx Context, wfCtx context.Context, lim *limiter, env *exprEnv, node *Node, inputs map[string][]byte, run runFunc) (map[string][]byte, error) {
+	wfCtx = withNodeScope(wfCtx, wf, node)
+	if node.SubWorkflow != nil {
+		childRun := run
+		run = func(runCtx context.Context, _ NodeType, node *Node, inputs map[string][]byte) (map[string][]byte, error) {
+			return wf.runSubWorkflow(ctx, runCtx, lim, env, node, inputs, childRun)
+		}
+	}
+	if node.Map != nil {
+		return wf.runMap(ctx, wfCtx, lim, node, inputs, run)
+	}
+	return wf.runWithRetry(ctx, wfCtx, node, inputs, run, func(a Attempt) {
+		node.Attempts = append(node.Attempts, a)
+	})
+}
+
+// setNodeState 并发安全地设置节点状态并发出事件
+func (wf *Workflow) setNodeState(ctx Context, nodeID, state string) {
+	wf.mu.Lock()
+	wf.Dag.Nodes[nodeID].State = state
+	wf.mu.Unlock()
+	wf.emitNodeEvent(ctx, nodeID, state, "")
+}
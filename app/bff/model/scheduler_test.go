@@ -0,0 +1,263 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// opFunc 以函数实现 Operation
+type opFunc func(ctx Context, inputs map[string][]byte) (map[string][]byte, error)
+
+func (f opFunc) Execute(ctx Context, inputs map[string][]byte, _ map[string]interface{}) (map[string][]byte, error) {
+	return f(ctx, inputs)
+}
+
+// gauge 记录同时执行的数量与峰值
+type gauge struct {
+	current, peak atomic.Int32
+}
+
+func (g *gauge) enter() {
+	n := g.current.Add(1)
+	for {
+		p := g.peak.Load()
+		if n <= p || g.peak.CompareAndSwap(p, n) {
+			return
+		}
+	}
+}
+
+func (g *gauge) leave() { g.current.Add(-1) }
+
+// newTestWorkflow 按 JSON 定义创建工作流，节点类型直接给出，不经过服务目录解析
+func newTestWorkflow(t *testing.T, definition string, types map[string]NodeType) *Workflow {
+	t.Helper()
+	var raw RawWorkflow
+	if err := json.Unmarshal([]byte(definition), &raw); err != nil {
+		t.Fatal(err)
+	}
+	wf, err := NewWorkflow("test", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, nt := range types {
+		nt.UID = id
+		wf.NodeTypes[id] = nt
+	}
+	return wf
+}
+
+func ports(names ...string) []Port {
+	var out []Port
+	for _, name := range names {
+		out = append(out, Port{Name: name, PortType: "connection"})
+	}
+	return out
+}
+
+func execute(wf *Workflow, ctx context.Context, limits Limits) error {
+	return wf.ExecuteWorkflow(&ExecutionContext{Workflow: wf, Ctx: ctx, Limits: limits})
+}
+
+func nodeState(wf *Workflow, id string) string {
+	wf.mu.RLock()
+	defer wf.mu.RUnlock()
+	return wf.Dag.Nodes[id].State
+}
+
+const diamond = `{
+	"nodes": [
+		{"id": "a", "node_type": "src"},
+		{"id": "b", "node_type": "step"},
+		{"id": "c", "node_type": "step"},
+		{"id": "d", "node_type": "join"}
+	],
+	"connections": [
+		{"connection_id": "1", "from": {"node_id": "a", "port_name": "out"}, "to": {"node_id": "b", "port_name": "in"}},
+		{"connection_id": "2", "from": {"node_id": "a", "port_name": "out"}, "to": {"node_id": "c", "port_name": "in"}},
+		{"connection_id": "3", "from": {"node_id": "b", "port_name": "out"}, "to": {"node_id": "d", "port_name": "left"}},
+		{"connection_id": "4", "from": {"node_id": "c", "port_name": "out"}, "to": {"node_id": "d", "port_name": "right"}}
+	]
+}`
+
+func TestScheduleRunsIndependentNodesInParallel(t *testing.T) {
+	// b 与 c 互相等待对方开始，串行执行时会超时
+	started := make(chan struct{}, 2)
+	step := opFunc(func(ctx Context, in map[string][]byte) (map[string][]byte, error) {
+		started <- struct{}{}
+		deadline := time.After(2 * time.Second)
+		for len(started) < 2 {
+			select {
+			case <-deadline:
+				return nil, errors.New("sibling did not start")
+			case <-time.After(time.Millisecond):
+			}
+		}
+		return map[string][]byte{"out": append([]byte("step:"), in["in"]...)}, nil
+	})
+	wf := newTestWorkflow(t, diamond, map[string]NodeType{
+		"src": {Operation: opFunc(func(Context, map[string][]byte) (map[string][]byte, error) {
+			return map[string][]byte{"out": []byte("x")}, nil
+		}), Properties: map[string][]Port{"outputs": ports("out")}},
+		"step": {Operation: step, Properties: map[string][]Port{"inputs": ports("in"), "outputs": ports("out")}},
+		"join": {Operation: opFunc(func(_ Context, in map[string][]byte) (map[string][]byte, error) {
+			return map[string][]byte{"out": []byte(string(in["left"]) + "+" + string(in["right"]))}, nil
+		}), Properties: map[string][]Port{"inputs": ports("left", "right"), "outputs": ports("out")}},
+	})
+
+	if err := execute(wf, context.Background(), Limits{}); err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if got := string(wf.Dag.Nodes["d"].Outputs["out"]); got != "step:x+step:x" {
+		t.Fatalf("d output = %q", got)
+	}
+}
+
+func TestScheduleRespectsServiceLimit(t *testing.T) {
+	var g gauge
+	slow := opFunc(func(Context, map[string][]byte) (map[string][]byte, error) {
+		g.enter()
+		defer g.leave()
+		time.Sleep(20 * time.Millisecond)
+		return map[string][]byte{}, nil
+	})
+	wf := newTestWorkflow(t, `{"nodes": [
+		{"id": "a", "node_type": "slow"}, {"id": "b", "node_type": "slow"},
+		{"id": "c", "node_type": "slow"}, {"id": "d", "node_type": "slow"}
+	]}`, map[string]NodeType{"slow": {Operation: slow, Service: "svc"}})
+
+	if err := execute(wf, context.Background(), Limits{MaxPerService: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if peak := g.peak.Load(); peak != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak)
+	}
+}
+
+func TestMapElementsShareConcurrencyLimits(t *testing.T) {
+	var g gauge
+	upper := opFunc(func(_ Context, in map[string][]byte) (map[string][]byte, error) {
+		g.enter()
+		defer g.leave()
+		time.Sleep(20 * time.Millisecond)
+		return map[string][]byte{"out": []byte(strings.ToUpper(string(in["in"])))}, nil
+	})
+	wf := newTestWorkflow(t, `{"nodes": [
+		{"id": "m", "node_type": "upper", "inputs": {"in": "WyJhIiwiYiIsImMiLCJkIiwiZSIsImYiXQ=="},
+		 "map": {"port": "in", "concurrency": 6}},
+		{"id": "n", "node_type": "upper", "inputs": {"in": "eA=="}}
+	]}`, map[string]NodeType{"upper": {Operation: upper, Service: "svc",
+		Properties: map[string][]Port{"inputs": ports("in"), "outputs": ports("out")}}})
+
+	if err := execute(wf, context.Background(), Limits{MaxPerService: 2, MaxConcurrency: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if peak := g.peak.Load(); peak > 2 {
+		t.Fatalf("peak concurrency = %d, want <= 2", peak)
+	}
+	if got := string(wf.Dag.Nodes["m"].Outputs["out"]); got != `["A","B","C","D","E","F"]` {
+		t.Fatalf("map output = %s", got)
+	}
+}
+
+func TestScheduleStopsAfterFailure(t *testing.T) {
+	var ran sync.Map
+	wf := newTestWorkflow(t, diamond, map[string]NodeType{
+		"src": {Operation: opFunc(func(Context, map[string][]byte) (map[string][]byte, error) {
+			return nil, errors.New("boom")
+		})},
+		"step": {Operation: opFunc(func(ctx Context, _ map[string][]byte) (map[string][]byte, error) {
+			ran.Store("step", true)
+			return nil, nil
+		})},
+		"join": {Operation: opFunc(func(Context, map[string][]byte) (map[string][]byte, error) {
+			ran.Store("join", true)
+			return nil, nil
+		})},
+	})
+
+	err := execute(wf, context.Background(), Limits{})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("execute() error = %v, want boom", err)
+	}
+	if nodeState(wf, "a") != "failed" {
+		t.Errorf("a state = %s, want failed", nodeState(wf, "a"))
+	}
+	if _, ok := ran.Load("step"); ok {
+		t.Error("downstream node ran after upstream failure")
+	}
+}
+
+func TestScheduleCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	blocked := make(chan struct{})
+	wf := newTestWorkflow(t, diamond, map[string]NodeType{
+		"src": {Operation: opFunc(func(c Context, _ map[string][]byte) (map[string][]byte, error) {
+			close(blocked)
+			<-c.Context().Done()
+			return nil, c.Context().Err()
+		})},
+		"step": {Operation: opFunc(func(Context, map[string][]byte) (map[string][]byte, error) { return nil, nil })},
+		"join": {Operation: opFunc(func(Context, map[string][]byte) (map[string][]byte, error) { return nil, nil })},
+	})
+
+	go func() {
+		<-blocked
+		cancel()
+	}()
+	err := execute(wf, ctx, Limits{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("execute() error = %v, want context.Canceled", err)
+	}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if state := nodeState(wf, id); state != "cancelled" {
+			t.Errorf("%s state = %s, want cancelled", id, state)
+		}
+	}
+}
+
+func TestScheduleWorkflowTimeout(t *testing.T) {
+	wf := newTestWorkflow(t, `{"timeout_ms": 30, "nodes": [{"id": "a", "node_type": "wait"}, {"id": "b", "node_type": "wait"}],
+		"connections": [{"connection_id": "1", "from": {"node_id": "a", "port_name": "out"}, "to": {"node_id": "b", "port_name": "in"}}]}`,
+		map[string]NodeType{"wait": {Operation: opFunc(func(c Context, _ map[string][]byte) (map[string][]byte, error) {
+			<-c.Context().Done()
+			return nil, c.Context().Err()
+		})}})
+
+	err := execute(wf, context.Background(), Limits{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("execute() error = %v, want deadline exceeded", err)
+	}
+	if nodeState(wf, "a") != "timeout" || nodeState(wf, "b") != "timeout" {
+		t.Fatalf("states = %s, %s, want timeout", nodeState(wf, "a"), nodeState(wf, "b"))
+	}
+}
+
+func TestScheduleSkipsInactiveBranch(t *testing.T) {
+	wf := newTestWorkflow(t, `{"nodes": [
+		{"id": "if", "node_type": "branch"}, {"id": "yes", "node_type": "leaf"}, {"id": "no", "node_type": "leaf"}
+	], "connections": [
+		{"connection_id": "1", "from": {"node_id": "if", "port_name": "true"}, "to": {"node_id": "yes", "port_name": "in"}},
+		{"connection_id": "2", "from": {"node_id": "if", "port_name": "false"}, "to": {"node_id": "no", "port_name": "in"}}
+	]}`, map[string]NodeType{
+		"branch": {Operation: opFunc(func(Context, map[string][]byte) (map[string][]byte, error) {
+			return map[string][]byte{"true": []byte("1")}, nil
+		}), Properties: map[string][]Port{"outputs": ports("true", "false")}},
+		"leaf": {Operation: opFunc(func(Context, map[string][]byte) (map[string][]byte, error) {
+			return map[string][]byte{}, nil
+		}), Properties: map[string][]Port{"inputs": ports("in")}},
+	})
+
+	if err := execute(wf, context.Background(), Limits{}); err != nil {
+		t.Fatal(err)
+	}
+	if nodeState(wf, "yes") != "success" || nodeState(wf, "no") != "skipped" {
+		t.Fatalf("states = %s, %s, want success, skipped", nodeState(wf, "yes"), nodeState(wf, "no"))
+	}
+}
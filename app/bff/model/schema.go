@@ -0,0 +1,224 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+)
+
+// 端口 Schema 支持 JSON Schema 的常用关键字：
+// type、enum、const、properties、required、additionalProperties、items、
+// minItems / maxItems、minLength / maxLength、pattern、minimum / maximum，其它关键字忽略
+
+// parseSchema 解析并检查 Schema 本身：必须是对象或布尔值，支持的关键字取值要合法
+func parseSchema(text string) (interface{}, error) {
+	var schema interface{}
+	if err := json.Unmarshal([]byte(text), &schema); err != nil {
+		return nil, fmt.Errorf("json schema is not valid JSON")
+	}
+	if err := checkSchemaDef(schema, "$"); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// checkSchemaDef 递归检查 Schema 定义，path 为关键字所在位置
+func checkSchemaDef(schema interface{}, path string) error {
+	if _, ok := schema.(bool); ok {
+		return nil
+	}
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("json schema %s must be an object or boolean", path)
+	}
+	if t, ok := s["type"]; ok {
+		names, ok := t.([]interface{})
+		if !ok {
+			names = []interface{}{t}
+		}
+		for _, name := range names {
+			switch name {
+			case "object", "array", "string", "number", "integer", "boolean", "null":
+			default:
+				return fmt.Errorf("json schema %s.type: unknown type %v", path, name)
+			}
+		}
+	}
+	if e, ok := s["enum"]; ok {
+		if _, ok := e.([]interface{}); !ok {
+			return fmt.Errorf("json schema %s.enum must be an array", path)
+		}
+	}
+	if r, ok := s["required"]; ok {
+		names, ok := r.([]interface{})
+		if !ok {
+			return fmt.Errorf("json schema %s.required must be an array of strings", path)
+		}
+		for _, name := range names {
+			if _, ok := name.(string); !ok {
+				return fmt.Errorf("json schema %s.required must be an array of strings", path)
+			}
+		}
+	}
+	if p, ok := s["properties"]; ok {
+		props, ok := p.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("json schema %s.properties must be an object", path)
+		}
+		for _, name := range sortedKeys(props) {
+			if err := checkSchemaDef(props[name], path+".properties."+name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range []string{"additionalProperties", "items"} {
+		if sub, ok := s[key]; ok {
+			if err := checkSchemaDef(sub, path+"."+key); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range []string{"minItems", "maxItems", "minLength", "maxLength", "minimum", "maximum"} {
+		if v, ok := s[key]; ok {
+			if _, ok := v.(float64); !ok {
+				return fmt.Errorf("json schema %s.%s must be a number", path, key)
+			}
+		}
+	}
+	if p, ok := s["pattern"]; ok {
+		pattern, ok := p.(string)
+		if !ok {
+			return fmt.Errorf("json schema %s.pattern must be a string", path)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("json schema %s.pattern: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// matchSchema 校验 JSON 值是否符合已检查过的 Schema，path 为值所在位置
+func matchSchema(schema, value interface{}, path string) error {
+	if b, ok := schema.(bool); ok {
+		if !b {
+			return fmt.Errorf("%s is not allowed", path)
+		}
+		return nil
+	}
+	s := schema.(map[string]interface{})
+
+	// 1. 类型与取值范围
+	if t, ok := s["type"]; ok {
+		names, ok := t.([]interface{})
+		if !ok {
+			names = []interface{}{t}
+		}
+		matched := false
+		for _, name := range names {
+			if jsonTypeIs(value, name.(string)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s should be %v", path, t)
+		}
+	}
+	if e, ok := s["enum"]; ok {
+		matched := false
+		for _, want := range e.([]interface{}) {
+			if reflect.DeepEqual(value, want) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s is not one of the enum values", path)
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(value, c) {
+		return fmt.Errorf("%s should be %v", path, c)
+	}
+
+	// 2. 按值的类型检查其余关键字
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if r, ok := s["required"]; ok {
+			for _, name := range r.([]interface{}) {
+				if _, ok := v[name.(string)]; !ok {
+					return fmt.Errorf("%s is missing required property %s", path, name)
+				}
+			}
+		}
+		props, _ := s["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(v) {
+			sub, ok := props[name]
+			if !ok {
+				if sub, ok = s["additionalProperties"]; !ok {
+					continue
+				}
+			}
+			if err := matchSchema(sub, v[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if err := checkBounds(s, "minItems", "maxItems", float64(len(v)), path+" length"); err != nil {
+			return err
+		}
+		if items, ok := s["items"]; ok {
+			for i, item := range v {
+				if err := matchSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if err := checkBounds(s, "minLength", "maxLength", float64(utf8.RuneCountInString(v)), path+" length"); err != nil {
+			return err
+		}
+		if p, ok := s["pattern"]; ok {
+			if !regexp.MustCompile(p.(string)).MatchString(v) {
+				return fmt.Errorf("%s does not match pattern %s", path, p)
+			}
+		}
+	case float64:
+		if err := checkBounds(s, "minimum", "maximum", v, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonTypeIs 判断解码后的 JSON 值是否为 Schema 中的类型
+func jsonTypeIs(value interface{}, name string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return name == "object"
+	case []interface{}:
+		return name == "array"
+	case string:
+		return name == "string"
+	case bool:
+		return name == "boolean"
+	case float64:
+		return name == "number" || name == "integer" && v == math.Trunc(v)
+	case nil:
+		return name == "null"
+	}
+	return false
+}
+
+// checkBounds 检查 n 是否在 Schema 的 min / max 关键字范围内
+func checkBounds(s map[string]interface{}, minKey, maxKey string, n float64, what string) error {
+	if min, ok := s[minKey].(float64); ok && n < min {
+		return fmt.Errorf("%s should be at least %v", what, min)
+	}
+	if max, ok := s[maxKey].(float64); ok && n > max {
+		return fmt.Errorf("%s should be at most %v", what, max)
+	}
+	return nil
+}
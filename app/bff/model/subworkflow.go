@@ -0,0 +1,323 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SubWorkflowNodeType 内置的子工作流节点类型，由 bff 展开执行，不对应任何服务
+const SubWorkflowNodeType = "builtin.subworkflow"
+
+// ErrDefinitionNotFound 找不到引用的工作流定义
+var ErrDefinitionNotFound = errors.New("workflow definition not found")
+
+// SubWorkflowConfig 子工作流节点的配置：引用一个已保存的工作流定义，
+// 将本节点的输入端口映射到子工作流的入口端口，子工作流的出口端口作为本节点的输出。
+type SubWorkflowConfig struct {
+	WorkflowID string              `json:"workflow_id"`       // 工作流定义 ID
+	Version    int                 `json:"version,omitempty"` // 定义版本，0 表示最新版本
+	Inputs     map[string]Endpoint `json:"inputs"`            // 本节点输入端口 -> 子工作流节点的输入端口
+	Outputs    map[string]Endpoint `json:"outputs"`           // 本节点输出端口 -> 子工作流节点的输出端口
+	// Vars 子工作流变量的取值，字符串中可以用 ${vars.名称} 与 ${run.字段} 引用父工作流的变量与运行元数据。
+	// 子工作流只能看到自己声明的变量，未绑定的变量使用声明的默认值。
+	Vars map[string]interface{} `json:"vars,omitempty"`
+}
+
+// DefinitionSource 按 ID 与版本提供工作流定义，version 为 0 时返回最新版本
+type DefinitionSource interface {
+	GetDefinition(id string, version int) (RawWorkflow, error)
+}
+
+// resolveSubWorkflow 加载子工作流定义并递归解析、校验，生成该节点专属的节点类型。
+// stack 为当前解析路径上的工作流定义 ID，用于检测工作流之间的递归引用。
+func (wf *Workflow) resolveSubWorkflow(r *Resolver, node *Node, stack []string) error {
+	cfg := node.SubWorkflow
+
+	// 1. 检测递归引用
+	for _, id := range stack {
+		if id == cfg.WorkflowID {
+			return fmt.Errorf("recursive sub-workflow: %s -> %s", strings.Join(stack, " -> "), cfg.WorkflowID)
+		}
+	}
+
+	// 2. 加载定义并解析、校验子工作流
+	if r.defs == nil {
+		return fmt.Errorf("workflow %s: %w", cfg.WorkflowID, ErrDefinitionNotFound)
+	}
+	raw, err := r.defs.GetDefinition(cfg.WorkflowID, cfg.Version)
+	if err != nil {
+		return err
+	}
+	child, err := NewWorkflow(cfg.WorkflowID, raw)
+	if err != nil {
+		return fmt.Errorf("sub-workflow %s: %w", cfg.WorkflowID, err)
+	}
+	if err := child.resolve(r, append(stack, cfg.WorkflowID)); err != nil {
+		return fmt.Errorf("sub-workflow %s: %w", cfg.WorkflowID, err)
+	}
+	child.entries = make(map[Endpoint]bool, len(cfg.Inputs))
+	for _, ep := range cfg.Inputs {
+		child.entries[ep] = true
+	}
+	if err := child.Validate(); err != nil {
+		return fmt.Errorf("sub-workflow %s: %w", cfg.WorkflowID, err)
+	}
+
+	// 3. 校验入口与出口端口，生成节点类型，端口类型与数据类型沿用子工作流的端口
+	nodeType := NodeType{
+		UID:        SubWorkflowNodeType,
+		Category:   "builtin",
+		Note:       "sub-workflow " + cfg.WorkflowID,
+		Properties: make(map[string][]Port),
+	}
+	for _, name := range sortedKeys(cfg.Inputs) {
+		ep := cfg.Inputs[name]
+		target, ok := child.Dag.Nodes[ep.NodeID]
+		if !ok {
+			return fmt.Errorf("sub-workflow %s has no entry port %s.%s", cfg.WorkflowID, ep.NodeID, ep.PortName)
+		}
+		port, ok := findPort(child.nodeTypeOf(target).Properties["inputs"], ep.PortName)
+		if !ok {
+			return fmt.Errorf("sub-workflow %s has no entry port %s.%s", cfg.WorkflowID, ep.NodeID, ep.PortName)
+		}
+		nodeType.Properties["inputs"] = append(nodeType.Properties["inputs"], Port{
+			Name:     name,
+			Label:    name,
+			PortType: port.PortType,
+			DataType: portDataType(target, "inputs", port),
+		})
+	}
+	for _, name := range sortedKeys(cfg.Outputs) {
+		ep := cfg.Outputs[name]
+		source, ok := child.Dag.Nodes[ep.NodeID]
+		if !ok {
+			return fmt.Errorf("sub-workflow %s has no exit port %s.%s", cfg.WorkflowID, ep.NodeID, ep.PortName)
+		}
+		port, ok := findPort(child.nodeTypeOf(source).Properties["outputs"], ep.PortName)
+		if !ok {
+			return fmt.Errorf("sub-workflow %s has no exit port %s.%s", cfg.WorkflowID, ep.NodeID, ep.PortName)
+		}
+		nodeType.Properties["outputs"] = append(nodeType.Properties["outputs"], Port{
+			Name:     name,
+			Label:    name,
+			PortType: port.PortType,
+			DataType: portDataType(source, "outputs", port),
+		})
+	}
+
+	// 4. 校验变量绑定
+	if err := wf.checkVarBindings(child, cfg); err != nil {
+		return fmt.Errorf("sub-workflow %s: %w", cfg.WorkflowID, err)
+	}
+
+	node.nodeType = &nodeType
+	node.template = child
+	return nil
+}
+
+// checkVarBindings 校验子工作流的变量绑定：只能绑定子工作流声明的变量，没有默认值的变量必须绑定，
+// 表达式只能引用父工作流声明的变量与运行元数据，不含表达式的值按声明的类型校验
+func (wf *Workflow) checkVarBindings(child *Workflow, cfg *SubWorkflowConfig) error {
+	for _, name := range sortedKeys(cfg.Vars) {
+		decl, declared := child.Vars[name]
+		if !declared {
+			return fmt.Errorf("variable %s is not declared", name)
+		}
+		value := cfg.Vars[name]
+		text, isString := value.(string)
+		if !isString || !hasExpr([]byte(text)) {
+			if err := decl.DataType.Check(formatValue(value)); err != nil {
+				return fmt.Errorf("variable %s: %v", name, err)
+			}
+			continue
+		}
+		parts, err := parseTemplate(text)
+		if err != nil {
+			return fmt.Errorf("variable %s: %v", name, err)
+		}
+		for _, part := range parts {
+			if part.ref == nil {
+				continue
+			}
+			switch part.ref.scope {
+			case "vars":
+				if _, declared := wf.Vars[part.ref.name]; !declared {
+					return fmt.Errorf("variable %s: ${%s} references undeclared variable %s", name, part.ref.text, part.ref.name)
+				}
+			case "run":
+				if _, exists := runFields[part.ref.name]; !exists {
+					return fmt.Errorf("variable %s: ${%s} references unknown run field %s", name, part.ref.text, part.ref.name)
+				}
+			default:
+				return fmt.Errorf("variable %s: ${%s} is not allowed, only vars and run can be referenced", name, part.ref.text)
+			}
+		}
+	}
+	for _, name := range sortedKeys(child.Vars) {
+		if _, bound := cfg.Vars[name]; !bound && child.Vars[name].Default == nil {
+			return fmt.Errorf("variable %s is required", name)
+		}
+	}
+	return nil
+}
+
+// bindVars 在父工作流的求值环境中计算子工作流的变量，表达式按文本拼接
+func (wf *Workflow) bindVars(env *exprEnv, bindings map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{}, len(bindings))
+	for name, value := range bindings {
+		text, isString := value.(string)
+		if !isString || !hasExpr([]byte(text)) {
+			vars[name] = value
+			continue
+		}
+		parts, err := parseTemplate(text)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %v", name, err)
+		}
+		var b strings.Builder
+		for _, part := range parts {
+			if part.ref == nil {
+				b.WriteString(part.text)
+				continue
+			}
+			data, err := wf.refValue(env, part.ref)
+			if err != nil {
+				return nil, fmt.Errorf("variable %s: %v", name, err)
+			}
+			b.Write(data)
+		}
+		vars[name] = b.String()
+	}
+	return vars, nil
+}
+
+type varsKey struct{}
+
+// withVars 标记 ctx 中执行的节点使用子工作流的变量 vars
+func withVars(ctx context.Context, vars map[string]interface{}) context.Context {
+	return context.WithValue(ctx, varsKey{}, vars)
+}
+
+// varsOf 返回 runCtx 所属工作流的变量，顶层工作流的节点返回 vars
+func varsOf(runCtx context.Context, vars map[string]interface{}) map[string]interface{} {
+	if v, ok := runCtx.Value(varsKey{}).(map[string]interface{}); ok {
+		return v
+	}
+	return vars
+}
+
+// CheckSubWorkflowCycles 检查定义 id 保存为 raw 后子工作流之间是否存在递归引用，
+// 引用的定义从 defs 加载，尚不存在的定义跳过，运行时解析会报错
+func CheckSubWorkflowCycles(id string, raw RawWorkflow, defs DefinitionSource) error {
+	return checkCycles(raw, []string{id}, defs)
+}
+
+func checkCycles(raw RawWorkflow, stack []string, defs DefinitionSource) error {
+	for _, n := range raw.Nodes {
+		if n.SubWorkflow == nil {
+			continue
+		}
+		cfg := n.SubWorkflow
+		for _, id := range stack {
+			if id == cfg.WorkflowID {
+				return fmt.Errorf("recursive sub-workflow: %s -> %s", strings.Join(stack, " -> "), cfg.WorkflowID)
+			}
+		}
+		child, err := defs.GetDefinition(cfg.WorkflowID, cfg.Version)
+		if errors.Is(err, ErrDefinitionNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkCycles(child, append(stack[:len(stack):len(stack)], cfg.WorkflowID), defs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clone 复制工作流的配置，运行期字段重新初始化，元数据与子工作流模板共享
+func (wf *Workflow) clone() *Workflow {
+	c := &Workflow{
+		ID:              wf.ID,
+		Timeout:         wf.Timeout,
+		Vars:            wf.Vars,
+		entries:         wf.entries,
+		Dag:             &Dag{Nodes: make(map[string]*Node, len(wf.Dag.Nodes)), Connections: wf.Dag.Connections},
+		NodeTypes:       wf.NodeTypes,
+		ConnectionTypes: wf.ConnectionTypes,
+	}
+	for id, n := range wf.Dag.Nodes {
+		node := &Node{
+			ID:          n.ID,
+			TypeID:      n.TypeID,
+			Label:       n.Label,
+			Timeout:     n.Timeout,
+			Retry:       n.Retry,
+			Map:         n.Map,
+			SubWorkflow: n.SubWorkflow,
+			nodeType:    n.nodeType,
+			template:    n.template,
+			Inputs:      make(map[string][]byte, len(n.Inputs)),
+		}
+		for k, v := range n.Inputs {
+			node.Inputs[k] = v
+		}
+		c.Dag.Nodes[id] = node
+	}
+	return c
+}
+
+// runSubWorkflow 以子工作流模板创建新实例并在本次尝试的期限内执行，
+// 出口端口的输出映射为本节点的输出，子工作流未产生的端口不输出。
+// 子工作流在自己的求值环境中执行：变量由绑定与声明的默认值组成，运行元数据与密钥沿用父工作流。
+func (wf *Workflow) runSubWorkflow(ctx Context, runCtx context.Context, lim *limiter, env *exprEnv, node *Node, inputs map[string][]byte, run runFunc) (map[string][]byte, error) {
+	cfg := node.SubWorkflow
+	child := node.template.clone()
+	bound, err := wf.bindVars(env, cfg.Vars)
+	if err != nil {
+		return nil, &OperationError{Err: fmt.Errorf("sub-workflow %s: %w", cfg.WorkflowID, err)}
+	}
+	vars, err := child.ResolveVars(bound)
+	if err != nil {
+		return nil, &OperationError{Err: fmt.Errorf("sub-workflow %s: %w", cfg.WorkflowID, err)}
+	}
+	childEnv := &exprEnv{vars: vars, run: env.run, secret: env.secret}
+	child.path = wf.path + node.ID + "/"
+	for name, ep := range cfg.Inputs {
+		if data, ok := inputs[name]; ok {
+			entry := child.Dag.Nodes[ep.NodeID]
+			entry.Inputs[ep.PortName] = data
+			if entry.passed == nil {
+				entry.passed = make(map[string]bool)
+			}
+			entry.passed[ep.PortName] = true
+		}
+	}
+
+	wf.mu.Lock()
+	node.Child = child
+	wf.mu.Unlock()
+
+	ctx.Log(fmt.Sprintf("节点 %s 开始执行子工作流 %s", node.ID, cfg.WorkflowID))
+	if err := child.scheduleWith(ctx, withVars(runCtx, vars), lim, childEnv, run); err != nil {
+		if runCtx.Err() != nil {
+			return nil, err
+		}
+		return nil, &OperationError{Err: fmt.Errorf("sub-workflow %s failed: %w", cfg.WorkflowID, err)}
+	}
+
+	child.mu.RLock()
+	defer child.mu.RUnlock()
+	outputs := make(map[string][]byte)
+	for name, ep := range cfg.Outputs {
+		source := child.Dag.Nodes[ep.NodeID]
+		if data, ok := source.Outputs[ep.PortName]; ok && data != nil {
+			outputs[name] = data
+		}
+	}
+	return outputs, nil
+}
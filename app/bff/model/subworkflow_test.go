@@ -0,0 +1,152 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	v1 "zflow/api/base"
+	"zflow/utils/cache"
+)
+
+// defMap 以 map 实现 DefinitionSource，值为定义的 JSON
+type defMap map[string]string
+
+func (m defMap) GetDefinition(id string, _ int) (RawWorkflow, error) {
+	data, ok := m[id]
+	if !ok {
+		return RawWorkflow{}, ErrDefinitionNotFound
+	}
+	var raw RawWorkflow
+	err := json.Unmarshal([]byte(data), &raw)
+	return raw, err
+}
+
+// echoCatalog 服务目录中只有 echo 节点类型：输入端口 in，输出端口 out
+func echoCatalog() *cache.Cache {
+	c := cache.NewCache()
+	c.AddNodeType("svc", &v1.NodeType{
+		Uid: "echo",
+		Properties: map[string]*v1.PortList{
+			"inputs":  {Ports: []*v1.Port{{Name: "in", PortType: "connection"}}},
+			"outputs": {Ports: []*v1.Port{{Name: "out", PortType: "connection"}}},
+		},
+	})
+	return c
+}
+
+// 子工作流声明 greeting（必填）与 level（默认 1），节点输入为 "${vars.greeting}/${vars.level}"
+const greetDef = `{
+	"vars": {"greeting": {"data_type": {"kind": "string"}}, "level": {"data_type": {"kind": "int"}, "default": 1}},
+	"nodes": [{"id": "say", "node_type": "echo", "inputs": {"in": "JHt2YXJzLmdyZWV0aW5nfS8ke3ZhcnMubGV2ZWx9"}}]
+}`
+
+func newParent(t *testing.T, vars string) *Workflow {
+	t.Helper()
+	var raw RawWorkflow
+	def := `{"vars": {"name": {}, "token": {"default": "parent-only"}}, "nodes": [{"id": "sub", "node_type": "builtin.subworkflow",
+		"subworkflow": {"workflow_id": "greet", "outputs": {"out": {"node_id": "say", "port_name": "out"}}, "vars": ` + vars + `}}]}`
+	if err := json.Unmarshal([]byte(def), &raw); err != nil {
+		t.Fatal(err)
+	}
+	wf, err := NewWorkflow("parent", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wf
+}
+
+func TestSubWorkflowUsesOwnVars(t *testing.T) {
+	wf := newParent(t, `{"greeting": "hi ${vars.name}"}`)
+	if err := wf.Resolve(NewResolver(echoCatalog(), defMap{"greet": greetDef})); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var seen map[string]interface{}
+	child := wf.Dag.Nodes["sub"].template
+	echo := child.NodeTypes["echo"]
+	echo.Operation = operationFunc(func(_ Context, in map[string][]byte, vars map[string]interface{}) (map[string][]byte, error) {
+		mu.Lock()
+		seen = vars
+		mu.Unlock()
+		return map[string][]byte{"out": in["in"]}, nil
+	})
+	child.NodeTypes["echo"] = echo
+
+	err := wf.ExecuteWorkflow(&ExecutionContext{Workflow: wf, Ctx: context.Background(),
+		Vars: map[string]interface{}{"name": "bob", "token": "parent-only"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(wf.Dag.Nodes["sub"].Outputs["out"]); got != "hi bob/1" {
+		t.Fatalf("output = %q, want %q", got, "hi bob/1")
+	}
+	// 子工作流的节点只看到子工作流的变量
+	if want := map[string]interface{}{"greeting": "hi bob", "level": 1.0}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("operation vars = %v, want %v", seen, want)
+	}
+}
+
+// operationFunc 以函数实现 Operation，同时接收变量
+type operationFunc func(ctx Context, inputs map[string][]byte, vars map[string]interface{}) (map[string][]byte, error)
+
+func (f operationFunc) Execute(ctx Context, inputs map[string][]byte, vars map[string]interface{}) (map[string][]byte, error) {
+	return f(ctx, inputs, vars)
+}
+
+func TestSubWorkflowVarBindingsChecked(t *testing.T) {
+	tests := []struct {
+		name string
+		vars string
+		want string
+	}{
+		{"未声明的变量", `{"greeting": "hi", "other": 1}`, "variable other is not declared"},
+		{"必填变量未绑定", `{}`, "variable greeting is required"},
+		{"类型不符", `{"greeting": "hi", "level": "high"}`, "variable level"},
+		{"引用密钥", `{"greeting": "${secrets.token}"}`, "only vars and run can be referenced"},
+		{"引用父工作流未声明的变量", `{"greeting": "${vars.missing}"}`, "undeclared variable missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := newParent(t, tt.vars)
+			err := wf.Resolve(NewResolver(echoCatalog(), defMap{"greet": greetDef}))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Resolve() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSubWorkflowCycles(t *testing.T) {
+	ref := func(id string) string {
+		return `{"nodes": [{"id": "n", "node_type": "builtin.subworkflow", "subworkflow": {"workflow_id": "` + id + `"}}]}`
+	}
+	defs := defMap{"a": ref("b"), "c": greetDef}
+	tests := []struct {
+		name string
+		id   string
+		def  string
+		want string
+	}{
+		{"间接递归", "b", ref("a"), "recursive sub-workflow: b -> a -> b"},
+		{"引用自身", "a", ref("a"), "recursive sub-workflow: a -> a"},
+		{"无递归", "b", ref("c"), ""},
+		{"引用的定义尚不存在", "b", ref("missing"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw RawWorkflow
+			if err := json.Unmarshal([]byte(tt.def), &raw); err != nil {
+				t.Fatal(err)
+			}
+			err := CheckSubWorkflowCycles(tt.id, raw, defs)
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Fatalf("CheckSubWorkflowCycles() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
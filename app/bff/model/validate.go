@@ -0,0 +1,305 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 诊断的严重程度
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// 诊断代码
+const (
+	DiagEmptyWorkflow         = "empty_workflow"
+	DiagInvalidNodeID         = "invalid_node_id"
+	DiagDuplicateNodeID       = "duplicate_node_id"
+	DiagDuplicateConnectionID = "duplicate_connection_id"
+	DiagUnknownNodeType       = "unknown_node_type"
+	DiagUnknownConnectionType = "unknown_connection_type"
+	DiagUnknownNode           = "unknown_node"
+	DiagUnknownPort           = "unknown_port"
+	DiagInvalidInputValue     = "invalid_input_value"
+	DiagPortTypeNotAllowed    = "port_type_not_allowed"
+	DiagIncompatibleTypes     = "incompatible_types"
+	DiagMultipleIncoming      = "multiple_incoming"
+	DiagMissingInput          = "missing_input"
+	DiagShadowedInput         = "shadowed_input"
+	DiagCycle                 = "cycle"
+	DiagIsolatedNode          = "isolated_node"
+	DiagInvalidExpression     = "invalid_expression"
+	DiagUnknownVariable       = "unknown_variable"
+)
+
+// Diagnostic 工作流校验发现的一个问题
+type Diagnostic struct {
+	Code         string `json:"code"`
+	Severity     string `json:"severity"`
+	NodeID       string `json:"node_id,omitempty"`
+	ConnectionID string `json:"connection_id,omitempty"`
+	Port         string `json:"port,omitempty"`
+	Message      string `json:"message"`
+}
+
+// HasErrors 判断诊断中是否有错误级别的问题
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Diagnose 检查工作流的全部问题，按节点 ID 与连接顺序返回，结果是确定的。
+// 节点类型与连接类型未解析时报告 unknown_*，Resolve 失败的原因会写入诊断信息。
+func (wf *Workflow) Diagnose() []Diagnostic {
+	diags := append([]Diagnostic(nil), wf.diagnostics...)
+	add := func(d Diagnostic) {
+		diags = append(diags, d)
+	}
+
+	// 1. 基本结构
+	if len(wf.Dag.Nodes) == 0 {
+		add(Diagnostic{Code: DiagEmptyWorkflow, Severity: SeverityError, Message: "workflow has no nodes"})
+		return diags
+	}
+
+	nodeIDs := make([]string, 0, len(wf.Dag.Nodes))
+	for nodeID := range wf.Dag.Nodes {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+
+	// 2. 节点：类型与预设输入
+	for _, nodeID := range nodeIDs {
+		node := wf.Dag.Nodes[nodeID]
+		nodeType, exists := wf.lookupNodeType(node)
+		if !exists {
+			msg := fmt.Sprintf("node %s references undefined node type %s", nodeID, node.TypeID)
+			if err := wf.nodeErrs[nodeID]; err != nil {
+				msg = fmt.Sprintf("node %s: %v", nodeID, err)
+			}
+			add(Diagnostic{Code: DiagUnknownNodeType, Severity: SeverityError, NodeID: nodeID, Message: msg})
+			continue
+		}
+
+		inputPorts := nodeType.Properties["inputs"]
+		if node.Map != nil && !hasPort(inputPorts, node.Map.Port) {
+			add(Diagnostic{Code: DiagUnknownPort, Severity: SeverityError, NodeID: nodeID, Port: node.Map.Port,
+				Message: fmt.Sprintf("节点 %s 的 map 端口 %s 不存在", nodeID, node.Map.Port)})
+		}
+		for _, inputName := range sortedKeys(node.Inputs) {
+			port, found := findPort(inputPorts, inputName)
+			if !found {
+				add(Diagnostic{Code: DiagUnknownPort, Severity: SeverityError, NodeID: nodeID, Port: inputName,
+					Message: fmt.Sprintf("节点 %s 的输入端口 %s 不存在", nodeID, inputName)})
+				continue
+			}
+			if hasExpr(node.Inputs[inputName]) {
+				diags = append(diags, wf.diagnoseExpr(nodeID, port, node.Inputs[inputName], wf.upstreamOf(nodeID))...)
+				continue
+			}
+			if err := portDataType(node, "inputs", port).Check(node.Inputs[inputName]); err != nil {
+				add(Diagnostic{Code: DiagInvalidInputValue, Severity: SeverityError, NodeID: nodeID, Port: inputName,
+					Message: fmt.Sprintf("节点 %s 的输入端口 %s: %v", nodeID, inputName, err)})
+			}
+		}
+	}
+
+	// 3. 连接
+	seenConns := make(map[string]bool)
+	incoming := make(map[Endpoint][]string) // 输入端口 -> 数据连线 ID
+	connected := make(map[string]bool)
+	for _, conn := range wf.Dag.Connections {
+		if seenConns[conn.ID] {
+			add(Diagnostic{Code: DiagDuplicateConnectionID, Severity: SeverityError, ConnectionID: conn.ID,
+				Message: fmt.Sprintf("duplicate connection id %s", conn.ID)})
+		}
+		seenConns[conn.ID] = true
+		diags = append(diags, wf.diagnoseConnection(conn)...)
+
+		connected[conn.From.NodeID] = true
+		connected[conn.To.NodeID] = true
+		if _, known := wf.ConnectionTypes[conn.TypeID]; !known || !wf.isControl(conn) {
+			incoming[conn.To] = append(incoming[conn.To], conn.ID)
+		}
+	}
+
+	// 4. 输入端口：多条入边、既未预设也未连接、预设覆盖连线
+	for _, nodeID := range nodeIDs {
+		node := wf.Dag.Nodes[nodeID]
+		nodeType, exists := wf.lookupNodeType(node)
+		if !exists {
+			continue
+		}
+		for _, port := range nodeType.Properties["inputs"] {
+			ep := Endpoint{NodeID: nodeID, PortName: port.Name}
+			_, preset := node.Inputs[port.Name]
+			conns := incoming[ep]
+			switch {
+			case len(conns) > 1:
+				add(Diagnostic{Code: DiagMultipleIncoming, Severity: SeverityError, NodeID: nodeID, Port: port.Name,
+					Message: fmt.Sprintf("节点 %s 的输入端口 %s 有多条连线: %s", nodeID, port.Name, strings.Join(conns, ", "))})
+			case len(conns) == 0 && !preset && !wf.entries[ep]:
+				// 执行时缺少输入的节点会失败
+				add(Diagnostic{Code: DiagMissingInput, Severity: SeverityError, NodeID: nodeID, Port: port.Name,
+					Message: fmt.Sprintf("节点 %s 的输入端口 %s 既没有预设输入也没有连线", nodeID, port.Name)})
+			case len(conns) > 0 && preset:
+				add(Diagnostic{Code: DiagShadowedInput, Severity: SeverityWarning, NodeID: nodeID, Port: port.Name,
+					Message: fmt.Sprintf("节点 %s 的输入端口 %s 有预设输入，连线 %s 的数据不会被使用", nodeID, port.Name, strings.Join(conns, ", "))})
+			}
+		}
+		if len(wf.Dag.Nodes) > 1 && !connected[nodeID] {
+			add(Diagnostic{Code: DiagIsolatedNode, Severity: SeverityWarning, NodeID: nodeID,
+				Message: fmt.Sprintf("节点 %s 没有任何连线", nodeID)})
+		}
+	}
+
+	// 5. 环
+	if cyclic := wf.cyclicNodes(); len(cyclic) > 0 {
+		for _, nodeID := range cyclic {
+			add(Diagnostic{Code: DiagCycle, Severity: SeverityError, NodeID: nodeID,
+				Message: fmt.Sprintf("节点 %s 位于环上，环上的节点: %s", nodeID, strings.Join(cyclic, ", "))})
+		}
+	}
+
+	return diags
+}
+
+// diagnoseConnection 检查单条连接的类型、端点、端口与数据类型
+func (wf *Workflow) diagnoseConnection(conn Connection) []Diagnostic {
+	var diags []Diagnostic
+	fail := func(code, nodeID, port, msg string) {
+		diags = append(diags, Diagnostic{Code: code, Severity: SeverityError, ConnectionID: conn.ID, NodeID: nodeID, Port: port, Message: msg})
+	}
+
+	connType, known := wf.ConnectionTypes[conn.TypeID]
+	if !known {
+		msg := fmt.Sprintf("connection %s references undefined connection type %s", conn.ID, conn.TypeID)
+		if err := wf.connErrs[conn.TypeID]; err != nil {
+			msg = fmt.Sprintf("connection %s: %v", conn.ID, err)
+		}
+		fail(DiagUnknownConnectionType, "", "", msg)
+	}
+
+	sourceNode, sourceOK := wf.Dag.Nodes[conn.From.NodeID]
+	if !sourceOK {
+		fail(DiagUnknownNode, conn.From.NodeID, "", fmt.Sprintf("connection %s references unknown source node %s", conn.ID, conn.From.NodeID))
+	}
+	targetNode, targetOK := wf.Dag.Nodes[conn.To.NodeID]
+	if !targetOK {
+		fail(DiagUnknownNode, conn.To.NodeID, "", fmt.Sprintf("connection %s references unknown target node %s", conn.ID, conn.To.NodeID))
+	}
+
+	// 源端口
+	var sourcePort Port
+	sourceChecked := false
+	if sourceOK {
+		if sourceNodeType, ok := wf.lookupNodeType(sourceNode); ok {
+			port, found := findPort(sourceNodeType.Properties["outputs"], conn.From.PortName)
+			if !found {
+				fail(DiagUnknownPort, conn.From.NodeID, conn.From.PortName, fmt.Sprintf("connection %s references unknown output port %s in node %s",
+					conn.ID, conn.From.PortName, conn.From.NodeID))
+			} else {
+				sourcePort, sourceChecked = port, true
+				if known && !connType.allows(port) {
+					fail(DiagPortTypeNotAllowed, conn.From.NodeID, conn.From.PortName, fmt.Sprintf("connection %s of type %s does not allow port type %s of %s.%s",
+						conn.ID, connType.Name, port.PortType, conn.From.NodeID, conn.From.PortName))
+				}
+			}
+		}
+	}
+
+	// 控制流连线不传递数据，目标端口不必是输入端口
+	if known && wf.isControl(conn) {
+		return diags
+	}
+
+	// 目标端口与数据类型
+	if targetOK {
+		if targetNodeType, ok := wf.lookupNodeType(targetNode); ok {
+			port, found := findPort(targetNodeType.Properties["inputs"], conn.To.PortName)
+			if !found {
+				fail(DiagUnknownPort, conn.To.NodeID, conn.To.PortName, fmt.Sprintf("connection %s references unknown input port %s in node %s",
+					conn.ID, conn.To.PortName, conn.To.NodeID))
+				return diags
+			}
+			if known && !connType.allows(port) {
+				fail(DiagPortTypeNotAllowed, conn.To.NodeID, conn.To.PortName, fmt.Sprintf("connection %s of type %s does not allow port type %s of %s.%s",
+					conn.ID, connType.Name, port.PortType, conn.To.NodeID, conn.To.PortName))
+			}
+			if sourceChecked {
+				sourceType := portDataType(sourceNode, "outputs", sourcePort)
+				targetType := portDataType(targetNode, "inputs", port)
+				if !sourceType.AssignableTo(targetType) {
+					fail(DiagIncompatibleTypes, conn.To.NodeID, conn.To.PortName, fmt.Sprintf("connection %s connects incompatible ports: %s.%s (%s) -> %s.%s (%s)",
+						conn.ID, conn.From.NodeID, conn.From.PortName, sourceType,
+						conn.To.NodeID, conn.To.PortName, targetType))
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// cyclicNodes 返回位于环上的节点，按 ID 排序：
+// 先从入度为 0 的节点正向剥离，再从出度为 0 的节点反向剥离，剩下的节点都在环上
+func (wf *Workflow) cyclicNodes() []string {
+	inDegree := make(map[string]int, len(wf.Dag.Nodes))
+	graph := make(map[string][]string)
+	for nodeID := range wf.Dag.Nodes {
+		inDegree[nodeID] = 0
+	}
+	for _, conn := range wf.Dag.Connections {
+		if _, ok := wf.Dag.Nodes[conn.From.NodeID]; !ok {
+			continue
+		}
+		if _, ok := wf.Dag.Nodes[conn.To.NodeID]; !ok {
+			continue
+		}
+		graph[conn.From.NodeID] = append(graph[conn.From.NodeID], conn.To.NodeID)
+		inDegree[conn.To.NodeID]++
+	}
+
+	var queue []string
+	for nodeID, degree := range inDegree {
+		if degree == 0 {
+			queue = append(queue, nodeID)
+		}
+	}
+	for len(queue) > 0 {
+		nodeID := queue[0]
+		queue = queue[1:]
+		delete(inDegree, nodeID)
+		for _, next := range graph[nodeID] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// 反向剥离只依赖环、自身不在环上的下游节点
+	for removed := true; removed; {
+		removed = false
+		for nodeID := range inDegree {
+			onPath := false
+			for _, next := range graph[nodeID] {
+				if _, ok := inDegree[next]; ok {
+					onPath = true
+					break
+				}
+			}
+			if !onPath {
+				delete(inDegree, nodeID)
+				removed = true
+			}
+		}
+	}
+
+	return sortedKeys(inDegree)
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseMissingInput(t *testing.T) {
+	wf := newTestWorkflow(t, `{"nodes": [{"id": "a", "node_type": "t"}]}`,
+		map[string]NodeType{"t": {Properties: map[string][]Port{"inputs": ports("in")}}})
+	var found bool
+	for _, d := range wf.Diagnose() {
+		if d.Code == DiagMissingInput && d.NodeID == "a" && d.Port == "in" {
+			found = true
+			if d.Severity != SeverityError {
+				t.Fatalf("missing_input severity = %s, want error", d.Severity)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("missing_input not reported")
+	}
+}
+
+func TestSubWorkflowEntryPortsMustBeBound(t *testing.T) {
+	const child = `{"nodes": [{"id": "say", "node_type": "echo"}]}`
+	tests := []struct {
+		name string
+		node string
+		want string
+	}{
+		{"父节点绑定入口端口", `"text_inputs": {"x": "hi"}, "subworkflow": {"workflow_id": "greet", "inputs": {"x": {"node_id": "say", "port_name": "in"}}}`, ""},
+		{"入口端口未绑定", `"subworkflow": {"workflow_id": "greet"}`, "节点 say 的输入端口 in 既没有预设输入也没有连线"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw RawWorkflow
+			def := `{"nodes": [{"id": "sub", "node_type": "builtin.subworkflow", ` + tt.node + `}]}`
+			if err := json.Unmarshal([]byte(def), &raw); err != nil {
+				t.Fatal(err)
+			}
+			wf, err := NewWorkflow("parent", raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = wf.Resolve(NewResolver(echoCatalog(), defMap{"greet": child}))
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Fatalf("Resolve() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
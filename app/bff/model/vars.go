@@ -0,0 +1,79 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// varNamePattern 变量名，表达式中以 ${vars.名称} 引用
+var varNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// VarDecl 工作流声明的变量，运行时可以覆盖默认值
+type VarDecl struct {
+	DataType    *DataType   `json:"data_type,omitempty"`   // 变量的数据类型，为空时不校验
+	Default     interface{} `json:"default,omitempty"`     // 默认值，为空表示每次运行都必须提供
+	Description string      `json:"description,omitempty"` // 变量说明
+}
+
+// validateVars 校验变量声明：变量名合法、类型定义合法、默认值符合类型
+func validateVars(vars map[string]VarDecl) error {
+	for _, name := range sortedKeys(vars) {
+		decl := vars[name]
+		if !varNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+		if decl.DataType != nil {
+			if err := decl.DataType.Validate(); err != nil {
+				return fmt.Errorf("variable %s: %v", name, err)
+			}
+		}
+		if decl.Default != nil {
+			if err := decl.DataType.Check(formatValue(decl.Default)); err != nil {
+				return fmt.Errorf("variable %s default: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveVars 返回本次运行的变量：声明的默认值被 overrides 覆盖，值按声明的类型校验。
+// 未声明的变量与没有默认值又未提供的变量都会报错。
+func (wf *Workflow) ResolveVars(overrides map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{}, len(wf.Vars))
+	for _, name := range sortedKeys(overrides) {
+		decl, declared := wf.Vars[name]
+		if !declared {
+			return nil, fmt.Errorf("variable %s is not declared by workflow %s", name, wf.ID)
+		}
+		if err := decl.DataType.Check(formatValue(overrides[name])); err != nil {
+			return nil, fmt.Errorf("variable %s: %v", name, err)
+		}
+		vars[name] = overrides[name]
+	}
+	for _, name := range sortedKeys(wf.Vars) {
+		if _, exists := vars[name]; exists {
+			continue
+		}
+		if wf.Vars[name].Default == nil {
+			return nil, fmt.Errorf("variable %s is required", name)
+		}
+		vars[name] = wf.Vars[name].Default
+	}
+	return vars, nil
+}
+
+// formatValue 将变量值编码为端口数据：字符串原样输出，其它值编码为 JSON
+func formatValue(v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return []byte(v)
+	case []byte:
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return []byte(fmt.Sprint(v))
+	}
+	return data
+}
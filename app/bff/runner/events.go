@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"sync"
+
+	"zflow/app/bff/model"
+)
+
+// maxEvents 每个运行在内存中保留的事件数，超过后丢弃最早的 maxEvents/10 条，完整的日志见运行记录
+const maxEvents = 10000
+
+// eventLog 一次运行的事件序列，订阅方可以从保留的任意序号开始重放
+type eventLog struct {
+	mu      sync.Mutex
+	events  []model.Event
+	dropped int64 // 已丢弃的最早事件数，events[0] 的序号为 dropped+1
+	closed  bool
+	notify  chan struct{} // 有新事件或关闭时关闭并替换
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{notify: make(chan struct{})}
+}
+
+// append 追加事件并分配序号，关闭后的事件被丢弃
+func (l *eventLog) append(e model.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	e.Seq = l.dropped + int64(len(l.events)) + 1
+	if len(l.events) >= maxEvents {
+		drop := maxEvents / 10
+		l.events = append([]model.Event(nil), l.events[drop:]...)
+		l.dropped += int64(drop)
+	}
+	l.events = append(l.events, e)
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// close 运行结束后不再有新事件
+func (l *eventLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		close(l.notify)
+	}
+}
+
+// Events 返回序号大于 after 的事件，after 之后的事件已被丢弃时从保留的最早事件开始；
+// closed 为 true 时不会再有新事件，否则可以等待 wait 关闭后再次调用获取新事件
+func (r *Run) Events(after int64) (events []model.Event, wait <-chan struct{}, closed bool) {
+	l := r.events
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if after < l.dropped {
+		after = l.dropped
+	}
+	if i := after - l.dropped; i < int64(len(l.events)) {
+		events = append(events, l.events[i:]...)
+	}
+	return events, l.notify, l.closed
+}
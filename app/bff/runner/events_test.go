@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"testing"
+
+	"zflow/app/bff/model"
+)
+
+func TestEventLogDropsOldestEvents(t *testing.T) {
+	r := &Run{events: newEventLog()}
+	total := maxEvents + 5
+	for i := 0; i < total; i++ {
+		r.events.append(model.Event{Type: model.EventLog})
+	}
+	if n := len(r.events.events); n > maxEvents {
+		t.Fatalf("retained %d events, want at most %d", n, maxEvents)
+	}
+
+	// 从已丢弃的序号重放时从保留的最早事件开始，序号保持连续
+	events, _, _ := r.Events(0)
+	first := int64(total - len(events) + 1)
+	for i, e := range events {
+		if e.Seq != first+int64(i) {
+			t.Fatalf("events[%d].Seq = %d, want %d", i, e.Seq, first+int64(i))
+		}
+	}
+	if last := events[len(events)-1].Seq; last != int64(total) {
+		t.Fatalf("last seq = %d, want %d", last, total)
+	}
+	if events, _, _ := r.Events(int64(total - 2)); len(events) != 2 {
+		t.Fatalf("Events(%d) returned %d events, want 2", total-2, len(events))
+	}
+}
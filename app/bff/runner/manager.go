@@ -0,0 +1,412 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"zflow/app/bff/history"
+	"zflow/app/bff/memo"
+	"zflow/app/bff/model"
+	"zflow/app/bff/secret"
+
+	"github.com/google/uuid"
+)
+
+// 运行状态
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSuccess   = "success"
+	StatusFailed    = "failed"
+	StatusTimeout   = "timeout"
+	StatusCancelled = "cancelled"
+	// StatusInterrupted bff 重启时运行尚未结束，可以续跑
+	StatusInterrupted = "interrupted"
+)
+
+// saveDelay 运行中节点状态与日志变化后延迟保存运行记录，期间的多次变化合并为一次保存
+const saveDelay = time.Second
+
+// Run 一次工作流运行
+type Run struct {
+	ID         string
+	WorkflowID string
+	Version    int
+	// ResumedFrom 续跑的原运行 ID，普通运行为空
+	ResumedFrom string
+
+	mu         sync.RWMutex
+	status     string
+	err        string
+	createdAt  time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+
+	wf         *model.Workflow
+	definition model.RawWorkflow
+	vars       map[string]interface{}
+	logs       []history.LogEntry
+	events     *eventLog
+	redactor   *secret.Redactor // 本次运行解析过的密钥值，对外输出前隐藏
+	saveTimer  *time.Timer      // 等待中的延迟保存，为空时没有
+	saveMu     sync.Mutex       // 串行化保存，后生成的记录后写入
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// Snapshot 运行状态快照
+type Snapshot struct {
+	ID          string             `json:"run_id"`
+	WorkflowID  string             `json:"workflow_id"`
+	Version     int                `json:"version,omitempty"`
+	ResumedFrom string             `json:"resumed_from,omitempty"`
+	Status      string             `json:"status"`
+	Error       string             `json:"error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	FinishedAt  *time.Time         `json:"finished_at,omitempty"`
+	Nodes       []model.NodeStatus `json:"nodes"`
+}
+
+// Snapshot 返回运行的整体状态和每个节点的状态，其中的密钥值已隐藏
+func (r *Run) Snapshot() Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snap := Snapshot{
+		ID:          r.ID,
+		WorkflowID:  r.WorkflowID,
+		Version:     r.Version,
+		ResumedFrom: r.ResumedFrom,
+		Status:      r.status,
+		Error:       r.err,
+		CreatedAt:   r.createdAt,
+		Nodes:       r.wf.NodeStatuses(),
+	}
+	if !r.startedAt.IsZero() {
+		t := r.startedAt
+		snap.StartedAt = &t
+	}
+	if !r.finishedAt.IsZero() {
+		t := r.finishedAt
+		snap.FinishedAt = &t
+	}
+	return secret.Redact(r.redactor, snap)
+}
+
+// Finished 运行是否已结束
+func (r *Run) Finished() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Done 运行结束时关闭
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// Result 返回 CollectWorkflowResults 的结果，status 为运行的最终状态，其中的密钥值已隐藏
+func (r *Run) Result() map[string]interface{} {
+	result := r.wf.CollectWorkflowResults()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result["run_id"] = r.ID
+	result["status"] = r.status
+	if r.err != "" {
+		result["error"] = r.err
+	}
+	return secret.Redact(r.redactor, result)
+}
+
+// Record 返回运行的完整记录，运行中也可调用，其中的密钥值已隐藏
+func (r *Run) Record() history.Record {
+	nodes := r.wf.CollectWorkflowResults()["nodes"].(map[string]map[string]interface{})
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec := history.Record{
+		RunID:       r.ID,
+		WorkflowID:  r.WorkflowID,
+		Version:     r.Version,
+		ResumedFrom: r.ResumedFrom,
+		Definition:  r.definition,
+		Vars:        r.vars,
+		Status:      r.status,
+		Error:       r.err,
+		CreatedAt:   r.createdAt,
+		Nodes:       nodes,
+		Logs:        append([]history.LogEntry(nil), r.logs...),
+	}
+	if !r.startedAt.IsZero() {
+		t := r.startedAt
+		rec.StartedAt = &t
+	}
+	if !r.finishedAt.IsZero() {
+		t := r.finishedAt
+		rec.FinishedAt = &t
+	}
+	rec = secret.Redact(r.redactor, rec)
+
+	// 原始输出不经过隐藏，含有密钥值的节点不保存输出
+	rec.Outputs = make(map[string]map[string][]byte)
+	for nodeID, outputs := range r.wf.NodeOutputs() {
+		redacted := false
+		for _, data := range outputs {
+			if r.redactor.Contains(data) {
+				redacted = true
+				break
+			}
+		}
+		if redacted {
+			rec.Redacted = append(rec.Redacted, nodeID)
+			continue
+		}
+		rec.Outputs[nodeID] = outputs
+	}
+	sort.Strings(rec.Redacted)
+	return rec
+}
+
+// emit 发布运行事件，log 事件同时记入运行日志
+func (r *Run) emit(e model.Event) {
+	e.RunID = r.ID
+	e.Message = r.redactor.String(e.Message)
+	e.Error = r.redactor.String(e.Error)
+	if e.Type == model.EventLog {
+		r.mu.Lock()
+		r.logs = append(r.logs, history.LogEntry{Time: e.Time, NodeID: e.NodeID, Message: e.Message})
+		r.mu.Unlock()
+	}
+	r.events.append(e)
+}
+
+// log 输出运行日志到 bff 的本地日志，记入运行记录由对应的 log 事件完成
+func (r *Run) log(msg string) {
+	log.Printf("[run %s] %s", r.ID, r.redactor.String(msg))
+}
+
+// note 在执行上下文之外记录一条运行日志并发布对应的 log 事件
+func (r *Run) note(msg string) {
+	r.log(msg)
+	r.emit(model.Event{Type: model.EventLog, Time: time.Now(), Message: msg})
+}
+
+// Cancel 取消运行，已结束的运行不受影响
+func (r *Run) Cancel() {
+	r.cancel()
+}
+
+// Manager 管理 bff 内所有工作流运行
+type Manager struct {
+	mu        sync.RWMutex
+	runs      map[string]*Run
+	limits    model.Limits
+	retention time.Duration
+	history   history.Store
+	memo      memo.Store
+	secrets   secret.Provider
+}
+
+// NewManager 创建运行管理器，已结束的运行在内存中保留 retention 后清理，
+// 运行记录持久化到 store，store 为空时不持久化，上次 bff 退出时未结束的运行标记为 interrupted；可缓存节点的输出保存在 cache，cache 为空时不缓存；
+// 工作流引用的密钥从 secrets 读取，secrets 为空时不能引用密钥
+func NewManager(limits model.Limits, retention time.Duration, store history.Store, cache memo.Store, secrets secret.Provider) *Manager {
+	m := &Manager{
+		runs:      make(map[string]*Run),
+		limits:    limits,
+		retention: retention,
+		history:   store,
+		memo:      cache,
+		secrets:   secrets,
+	}
+	m.markInterrupted()
+	return m
+}
+
+// markInterrupted 将记录中仍为 pending / running 的运行标记为 interrupted，这些运行随上次 bff 退出而中断
+func (m *Manager) markInterrupted() {
+	if m.history == nil {
+		return
+	}
+	for _, st := range []string{StatusPending, StatusRunning} {
+		summaries, err := m.history.List(history.Filter{Status: st})
+		if err != nil {
+			log.Printf("查询未结束的运行记录失败: %v", err)
+			return
+		}
+		for _, summary := range summaries {
+			rec, err := m.history.Get(summary.RunID)
+			if err != nil {
+				log.Printf("[run %s] 读取运行记录失败: %v", summary.RunID, err)
+				continue
+			}
+			rec.Status = StatusInterrupted
+			rec.Error = "run interrupted by bff restart"
+			if err := m.history.Save(rec); err != nil {
+				log.Printf("[run %s] 保存运行记录失败: %v", rec.RunID, err)
+				continue
+			}
+			log.Printf("[run %s] bff 重启前运行未结束，标记为 %s", rec.RunID, StatusInterrupted)
+		}
+	}
+}
+
+// Submit 提交工作流并在后台执行，立即返回运行。definition 为工作流的原始定义，
+// vars 为 wf.ResolveVars 返回的运行变量，二者随运行记录保存
+func (m *Manager) Submit(wf *model.Workflow, definition model.RawWorkflow, vars map[string]interface{}) *Run {
+	return m.submit(wf, definition, "", vars)
+}
+
+// submit 创建运行并在后台执行，resumedFrom 为续跑的原运行 ID
+func (m *Manager) submit(wf *model.Workflow, definition model.RawWorkflow, resumedFrom string, vars map[string]interface{}) *Run {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &Run{
+		ID:          uuid.New().String(),
+		WorkflowID:  wf.ID,
+		Version:     wf.Version,
+		ResumedFrom: resumedFrom,
+		status:      StatusPending,
+		createdAt:   time.Now(),
+		wf:          wf,
+		definition:  definition,
+		vars:        vars,
+		events:      newEventLog(),
+		redactor:    secret.NewRedactor(),
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.evictLocked()
+	m.runs[run.ID] = run
+	m.mu.Unlock()
+
+	m.save(run)
+	go m.execute(ctx, run)
+	return run
+}
+
+// save 持久化运行记录，失败只记录日志，不影响运行
+func (m *Manager) save(run *Run) {
+	if m.history == nil {
+		return
+	}
+	// 在锁内生成记录，并发保存时较新的记录不会被较旧的覆盖
+	run.saveMu.Lock()
+	defer run.saveMu.Unlock()
+	if err := m.history.Save(run.Record()); err != nil {
+		log.Printf("[run %s] 保存运行记录失败: %v", run.ID, err)
+	}
+}
+
+// saveSoon 在 saveDelay 后保存运行记录，已有等待中的保存时不重复安排
+func (m *Manager) saveSoon(run *Run) {
+	if m.history == nil {
+		return
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.saveTimer != nil {
+		return
+	}
+	run.saveTimer = time.AfterFunc(saveDelay, func() {
+		run.mu.Lock()
+		run.saveTimer = nil
+		run.mu.Unlock()
+		m.save(run)
+	})
+}
+
+// Get 获取运行
+func (m *Manager) Get(id string) (*Run, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	run, ok := m.runs[id]
+	return run, ok
+}
+
+// execute 执行运行并记录最终状态
+func (m *Manager) execute(ctx context.Context, run *Run) {
+	defer close(run.done)
+	defer run.cancel()
+
+	defer run.events.close()
+
+	run.mu.Lock()
+	run.status = StatusRunning
+	run.startedAt = time.Now()
+	run.mu.Unlock()
+	m.save(run)
+	run.emit(model.Event{Type: model.EventRunStarted, Time: run.startedAt, State: StatusRunning})
+
+	execCtx := &model.ExecutionGRPCContext{ExecutionContext: model.ExecutionContext{
+		Workflow: run.wf,
+		RunID:    run.ID,
+		Ctx:      ctx,
+		Logger:   run.log,
+		// 节点状态与日志变化后延迟保存，bff 异常退出时运行记录不会停留在开始时
+		OnEvent: func(e model.Event) {
+			run.emit(e)
+			m.saveSoon(run)
+		},
+		Vars:     run.vars,
+		Limits:   m.limits,
+		Memo:     m.memo,
+		Secrets:  m.secrets,
+		Redactor: run.redactor,
+	}}
+	defer execCtx.Close()
+
+	err := run.wf.ExecuteWorkflowWithGRPC(execCtx)
+
+	run.mu.Lock()
+	run.finishedAt = time.Now()
+	if err != nil {
+		run.status = model.FailureState(ctx, err)
+		run.err = err.Error()
+	} else {
+		run.status = StatusSuccess
+	}
+	run.mu.Unlock()
+
+	if err != nil {
+		execCtx.Log(fmt.Sprintf("工作流 %s 执行结束: %s", run.WorkflowID, err))
+	} else {
+		execCtx.Log(fmt.Sprintf("工作流 %s 执行成功，耗时 %s", run.WorkflowID, run.finishedAt.Sub(run.startedAt)))
+	}
+	m.save(run)
+
+	finished := model.Event{Type: model.EventRunFinished, Time: run.finishedAt, State: run.status}
+	if err != nil {
+		finished.Error = err.Error()
+	}
+	run.emit(finished)
+}
+
+// evictLocked 清理超过保留期的已结束运行，调用方需持有 m.mu
+func (m *Manager) evictLocked() {
+	if m.retention <= 0 {
+		return
+	}
+	deadline := time.Now().Add(-m.retention)
+	for id, run := range m.runs {
+		if !run.Finished() {
+			continue
+		}
+		run.mu.RLock()
+		expired := run.finishedAt.Before(deadline)
+		run.mu.RUnlock()
+		if expired {
+			delete(m.runs, id)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+
+	"zflow/app/bff/history"
+	"zflow/app/bff/model"
+)
+
+// ErrNotResumable 运行尚未结束或已经成功，不能续跑
+var ErrNotResumable = errors.New("run is not resumable")
+
+// Resume 从运行记录续跑：复用成功节点的输出，重新执行失败的节点及其下游。
+// wf 由记录中的定义快照创建，需已通过 Resolve 与 Validate；
+// inputs 覆盖节点的预设输入，vars 覆盖原运行的变量，新运行在记录中通过 resumed_from 关联原运行。
+func (m *Manager) Resume(rec history.Record, wf *model.Workflow, inputs map[string]map[string][]byte, vars map[string]interface{}) (*Run, error) {
+	// 1、只有已结束且未成功的运行可以续跑
+	switch rec.Status {
+	case StatusFailed, StatusTimeout, StatusCancelled, StatusInterrupted:
+	default:
+		return nil, fmt.Errorf("run %s is %s: %w", rec.RunID, rec.Status, ErrNotResumable)
+	}
+
+	// 2、复用上一次运行中成功节点的输出
+	reused, err := wf.ResumeFrom(priorResults(rec), inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3、合并变量并按声明校验后提交
+	merged := make(map[string]interface{}, len(rec.Vars)+len(vars))
+	for k, v := range rec.Vars {
+		merged[k] = v
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	merged, err = wf.ResolveVars(merged)
+	if err != nil {
+		return nil, err
+	}
+	run := m.submit(wf, rec.Definition, rec.RunID, merged)
+	run.note(fmt.Sprintf("续跑运行 %s，复用节点 %v 的输出", rec.RunID, reused))
+	if len(rec.Redacted) > 0 {
+		run.note(fmt.Sprintf("节点 %v 的输出含有密钥值，重新执行", rec.Redacted))
+	}
+	return run, nil
+}
+
+// priorResults 从运行记录中取出每个节点的状态和原始输出。
+// 输出含有密钥值或没有保存原始输出的成功节点不复用，续跑时重新执行
+func priorResults(rec history.Record) map[string]model.PriorResult {
+	redacted := make(map[string]bool, len(rec.Redacted))
+	for _, nodeID := range rec.Redacted {
+		redacted[nodeID] = true
+	}
+	prior := make(map[string]model.PriorResult, len(rec.Nodes))
+	for nodeID, result := range rec.Nodes {
+		state, _ := result["state"].(string)
+		outputs, saved := rec.Outputs[nodeID]
+		if state == "success" && (redacted[nodeID] || !saved) {
+			continue
+		}
+		prior[nodeID] = model.PriorResult{State: state, Outputs: outputs}
+	}
+	return prior
+}
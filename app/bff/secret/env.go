@@ -0,0 +1,24 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvProvider 从环境变量读取密钥，密钥 api_token 对应环境变量 <Prefix>API_TOKEN
+type EnvProvider struct {
+	Prefix string
+}
+
+// Get 实现 Provider 接口
+func (p EnvProvider) Get(name string) (string, error) {
+	if !ValidName(name) {
+		return "", fmt.Errorf("secret %s: %w", name, ErrNotFound)
+	}
+	value, ok := os.LookupEnv(p.Prefix + strings.ToUpper(name))
+	if !ok {
+		return "", fmt.Errorf("secret %s: %w", name, ErrNotFound)
+	}
+	return value, nil
+}
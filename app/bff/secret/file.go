@@ -0,0 +1,192 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// KeySize 文件存储使用 AES-256-GCM，密钥为 32 字节
+const KeySize = 32
+
+// FileStore 加密的本地文件密钥存储，所有密钥保存在一个 JSON 文件中，
+// 每个值以 AES-GCM 单独加密，密钥名作为附加数据，值不能被挪用到其它名称下
+type FileStore struct {
+	path string
+	aead cipher.AEAD
+
+	mu      sync.Mutex
+	secrets map[string]string // 名称 -> base64(nonce + 密文)
+}
+
+// LoadKey 读取密钥文件，文件不存在时生成随机密钥并以 0600 权限保存
+func LoadKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil || len(key) != KeySize {
+			return nil, fmt.Errorf("invalid secret key file %s", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read secret key file: %v", err)
+	}
+
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create secret key dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write secret key file: %v", err)
+	}
+	return key, nil
+}
+
+// NewFileStore 打开加密文件存储，文件不存在时为空存储，第一次写入时创建
+func NewFileStore(path string, key []byte) (*FileStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %v", err)
+	}
+	s := &FileStore{path: path, aead: aead, secrets: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret file: %v", err)
+	}
+	if err := json.Unmarshal(data, &s.secrets); err != nil {
+		return nil, fmt.Errorf("failed to decode secret file: %v", err)
+	}
+	// 打开时解密一次，密钥文件不匹配时尽早报错
+	for name := range s.secrets {
+		if _, err := s.decrypt(name); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Get 实现 Provider 接口
+func (s *FileStore) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.secrets[name]; !ok {
+		return "", fmt.Errorf("secret %s: %w", name, ErrNotFound)
+	}
+	return s.decrypt(name)
+}
+
+// Put 实现 Store 接口
+func (s *FileStore) Put(name, value string) error {
+	if !ValidName(name) {
+		return fmt.Errorf("invalid secret name %q", name)
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to encrypt secret %s: %v", name, err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(value), []byte(name))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, existed := s.secrets[name]
+	s.secrets[name] = base64.StdEncoding.EncodeToString(sealed)
+	if err := s.save(); err != nil {
+		if existed {
+			s.secrets[name] = old
+		} else {
+			delete(s.secrets, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete 实现 Store 接口
+func (s *FileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.secrets[name]
+	if !ok {
+		return fmt.Errorf("secret %s: %w", name, ErrNotFound)
+	}
+	delete(s.secrets, name)
+	if err := s.save(); err != nil {
+		s.secrets[name] = old
+		return err
+	}
+	return nil
+}
+
+// List 实现 Store 接口
+func (s *FileStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// decrypt 解密密钥值，调用方需持有 s.mu 或在初始化时调用
+func (s *FileStore) decrypt(name string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(s.secrets[name])
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", fmt.Errorf("secret %s is corrupted", name)
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	value, err := s.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s: wrong key or corrupted file", name)
+	}
+	return string(value), nil
+}
+
+// save 先写临时文件再改名，调用方需持有 s.mu
+func (s *FileStore) save() error {
+	data, err := json.MarshalIndent(s.secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode secret file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create secret dir: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".secrets-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write secret file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write secret file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write secret file: %v", err)
+	}
+	return nil
+}
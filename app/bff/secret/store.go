@@ -0,0 +1,230 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound 密钥不存在
+var ErrNotFound = errors.New("secret not found")
+
+// Mask 替换密钥值的占位文本
+const Mask = "***"
+
+// namePattern 密钥名，工作流中以 ${secrets.名称} 引用
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidName 判断是否为合法的密钥名
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Provider 按名称读取密钥
+type Provider interface {
+	// Get 返回密钥值，不存在时返回 ErrNotFound
+	Get(name string) (string, error)
+}
+
+// Store 可以写入的密钥存储
+type Store interface {
+	Provider
+	// Put 保存密钥，已存在时覆盖
+	Put(name, value string) error
+	// Delete 删除密钥，不存在时返回 ErrNotFound
+	Delete(name string) error
+	// List 返回全部密钥名，按名称排序
+	List() ([]string, error)
+}
+
+// Chain 按顺序在多个 Provider 中查找密钥，返回第一个找到的值
+type Chain []Provider
+
+// Get 实现 Provider 接口
+func (c Chain) Get(name string) (string, error) {
+	for _, p := range c {
+		value, err := p.Get(name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return value, err
+	}
+	return "", fmt.Errorf("secret %s: %w", name, ErrNotFound)
+}
+
+// Redactor 记录一次运行中解析过的密钥值，对外输出前将其替换为 Mask，为空时不做替换
+type Redactor struct {
+	mu     sync.RWMutex
+	values []string // 按长度降序，较长的值先替换
+}
+
+// NewRedactor 创建 Redactor
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// Add 记录需要隐藏的密钥值
+func (r *Redactor) Add(value string) {
+	if r == nil || value == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.values {
+		if v == value {
+			return
+		}
+	}
+	r.values = append(r.values, value)
+	sort.Slice(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+}
+
+// String 隐藏文本中的密钥值
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	return s
+}
+
+// Bytes 隐藏端口数据中的密钥值
+func (r *Redactor) Bytes(b []byte) []byte {
+	if r == nil {
+		return b
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		b = bytes.ReplaceAll(b, []byte(v), []byte(Mask))
+	}
+	return b
+}
+
+// Contains 判断数据中是否含有密钥值
+func (r *Redactor) Contains(b []byte) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		if bytes.Contains(b, []byte(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// empty 是否还没有记录任何密钥值
+func (r *Redactor) empty() bool {
+	if r == nil {
+		return true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.values) == 0
+}
+
+// rawMessageType json.RawMessage 是 JSON 文本而不是端口数据，解码后再隐藏
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// Redact 返回 v 的副本，其中所有字符串与 []byte 端口数据里的密钥值已隐藏，
+// 用于运行结果与运行记录。只替换字符串与数据本身，map 的键、数字、布尔值等保持不变
+func Redact[T any](r *Redactor, v T) T {
+	if r.empty() {
+		return v
+	}
+	out := r.redact(reflect.ValueOf(&v).Elem())
+	return out.Interface().(T)
+}
+
+// redact 递归复制 v 并隐藏其中的字符串与 []byte，未导出的结构体字段原样复制
+func (r *Redactor) redact(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(r.String(v.String())).Convert(v.Type())
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		if v.Type() == rawMessageType {
+			return reflect.ValueOf(r.rawMessage(v.Bytes()))
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return reflect.ValueOf(r.Bytes(append([]byte(nil), v.Bytes()...))).Convert(v.Type())
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(r.redact(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(r.redact(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), r.redact(iter.Value()))
+		}
+		return out
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(r.redact(v.Elem()))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(r.redact(v.Elem()))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				out.Field(i).Set(r.redact(v.Field(i)))
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// rawMessage 解码 JSON 文本后隐藏其中的字符串再编码；无法解码时整体替换为 Mask，不会泄露密钥
+func (r *Redactor) rawMessage(data []byte) json.RawMessage {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		log.Printf("隐藏密钥时解码 JSON 失败，整体隐藏: %v", err)
+		masked, _ := json.Marshal(Mask)
+		return masked
+	}
+	masked, err := json.Marshal(r.redact(reflect.ValueOf(&v).Elem()).Interface())
+	if err != nil {
+		log.Printf("隐藏密钥时编码 JSON 失败，整体隐藏: %v", err)
+		masked, _ = json.Marshal(Mask)
+	}
+	return masked
+}
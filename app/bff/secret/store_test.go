@@ -0,0 +1,102 @@
+package secret
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type record struct {
+	Name    string                 `json:"name"`
+	Count   int                    `json:"count"`
+	OK      bool                   `json:"ok"`
+	At      time.Time              `json:"at"`
+	Inputs  map[string][]byte      `json:"inputs"`
+	Vars    map[string]interface{} `json:"vars"`
+	Outputs map[string]string      `json:"outputs"`
+	Raw     json.RawMessage        `json:"raw"`
+	Next    *record                `json:"next,omitempty"`
+}
+
+func TestRedact(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		secrets []string
+		in      record
+		want    record
+	}{
+		{
+			name:    "字符串与端口数据",
+			secrets: []string{"s3cr3t"},
+			in: record{
+				Name:    "token s3cr3t",
+				Inputs:  map[string][]byte{"auth": []byte("Bearer s3cr3t")},
+				Outputs: map[string]string{"out": "s3cr3t"},
+			},
+			want: record{
+				Name:    "token ***",
+				Inputs:  map[string][]byte{"auth": []byte("Bearer ***")},
+				Outputs: map[string]string{"out": "***"},
+			},
+		},
+		{
+			name:    "与数字和时间冲突",
+			secrets: []string{"1", "2026"},
+			in:      record{Name: "v1", Count: 12026, At: at, Vars: map[string]interface{}{"n": 1.0, "s": "1"}},
+			want:    record{Name: "v***", Count: 12026, At: at, Vars: map[string]interface{}{"n": 1.0, "s": "***"}},
+		},
+		{
+			name:    "与键名和布尔值冲突",
+			secrets: []string{"name", "true", "outputs"},
+			in:      record{Name: "name", OK: true, Outputs: map[string]string{"name": "true"}},
+			want:    record{Name: "***", OK: true, Outputs: map[string]string{"name": "***"}},
+		},
+		{
+			name:    "与 JSON 转义字符冲突",
+			secrets: []string{`"`, `\`, "\n"},
+			in:      record{Name: `a"b\c` + "\n", Outputs: map[string]string{`k"`: `"v"`}},
+			want:    record{Name: "a***b***c***", Outputs: map[string]string{`k"`: "***v***"}},
+		},
+		{
+			name:    "JSON 文本与嵌套指针",
+			secrets: []string{"pw", "5"},
+			in:      record{Raw: json.RawMessage(`{"pw":"pw","n":5}`), Next: &record{Name: "pw"}},
+			want:    record{Raw: json.RawMessage(`{"n":5,"pw":"***"}`), Next: &record{Name: "***"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRedactor()
+			for _, s := range tt.secrets {
+				r.Add(s)
+			}
+			got := Redact(r, tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Redact() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactDoesNotModifyInput(t *testing.T) {
+	r := NewRedactor()
+	r.Add("s3cr3t")
+	in := map[string]interface{}{"data": []byte("s3cr3t"), "list": []interface{}{"s3cr3t"}}
+	out := Redact(r, in)
+	if string(in["data"].([]byte)) != "s3cr3t" || in["list"].([]interface{})[0] != "s3cr3t" {
+		t.Fatalf("input was modified: %v", in)
+	}
+	if string(out["data"].([]byte)) != Mask || out["list"].([]interface{})[0] != Mask {
+		t.Fatalf("Redact() = %v", out)
+	}
+}
+
+func TestRedactWithoutSecrets(t *testing.T) {
+	in := record{Name: "plain", Count: 1}
+	var r *Redactor
+	if got := Redact(r, in); !reflect.DeepEqual(got, in) {
+		t.Fatalf("Redact() = %+v, want %+v", got, in)
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"zflow/app/bff/global"
+	"zflow/app/bff/memo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newMemoStore 按配置创建节点输出缓存，未配置后端时返回空
+func newMemoStore() (memo.Store, error) {
+	switch global.MemoBackend {
+	case "":
+		return nil, nil
+	case "memory":
+		return memo.NewMemoryStore(global.MemoMaxBytes), nil
+	case "file":
+		return memo.NewFileStore(global.MemoDir, global.MemoMaxBytes)
+	default:
+		return nil, fmt.Errorf("unknown node cache backend %s", global.MemoBackend)
+	}
+}
+
+// registerCacheRoutes 注册节点输出缓存的查询与清理接口，cache 为空时接口返回 404
+func registerCacheRoutes(router *gin.Engine, cache memo.Store) {
+	// 查询缓存使用情况
+	router.GET("/cache", func(c *gin.Context) {
+		if cache == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "node cache is disabled"})
+			return
+		}
+		c.JSON(http.StatusOK, cache.Stats())
+	})
+
+	// 清理缓存，可按 node_type 或 key 过滤，不带参数时清理全部
+	router.DELETE("/cache", func(c *gin.Context) {
+		if cache == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "node cache is disabled"})
+			return
+		}
+		purged, err := cache.Purge(memo.Filter{
+			NodeType: c.Query("node_type"),
+			Key:      c.Query("key"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"purged": purged})
+	})
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"zflow/app/bff/definition"
+	"zflow/app/bff/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// definitionRequest 创建或更新工作流定义的请求
+type definitionRequest struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Workflow    model.RawWorkflow `json:"workflow"`
+}
+
+// registerDefinitionRoutes 注册工作流定义的增删改查接口
+func registerDefinitionRoutes(router *gin.Engine, store definition.Store) {
+	// 创建定义，保存为版本 1
+	router.POST("/workflow_definitions", func(c *gin.Context) {
+		var req definitionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.ID == "" {
+			req.ID = uuid.New().String()
+		}
+		if err := checkDefinition(req, store); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		def, err := store.Create(definition.Definition{
+			ID:          req.ID,
+			Name:        req.Name,
+			Description: req.Description,
+			Workflow:    req.Workflow,
+		})
+		if err != nil {
+			c.JSON(definitionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, def)
+	})
+
+	// 列出所有定义
+	router.GET("/workflow_definitions", func(c *gin.Context) {
+		summaries, err := store.List()
+		if err != nil {
+			c.JSON(definitionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, summaries)
+	})
+
+	// 获取定义的最新版本
+	router.GET("/workflow_definitions/:id", func(c *gin.Context) {
+		def, err := store.Get(c.Param("id"), 0)
+		if err != nil {
+			c.JSON(definitionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, def)
+	})
+
+	// 更新定义，保存为新版本，已有版本不变
+	router.PUT("/workflow_definitions/:id", func(c *gin.Context) {
+		var req definitionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.ID = c.Param("id")
+		if err := checkDefinition(req, store); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		def, err := store.Update(definition.Definition{
+			ID:          req.ID,
+			Name:        req.Name,
+			Description: req.Description,
+			Workflow:    req.Workflow,
+		})
+		if err != nil {
+			c.JSON(definitionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, def)
+	})
+
+	// 删除定义的所有版本
+	router.DELETE("/workflow_definitions/:id", func(c *gin.Context) {
+		if err := store.Delete(c.Param("id")); err != nil {
+			c.JSON(definitionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// 列出定义的所有版本
+	router.GET("/workflow_definitions/:id/versions", func(c *gin.Context) {
+		defs, err := store.Versions(c.Param("id"))
+		if err != nil {
+			c.JSON(definitionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, defs)
+	})
+
+	// 获取定义的指定版本
+	router.GET("/workflow_definitions/:id/versions/:version", func(c *gin.Context) {
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil || version <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a positive integer"})
+			return
+		}
+		def, err := store.Get(c.Param("id"), version)
+		if err != nil {
+			c.JSON(definitionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, def)
+	})
+}
+
+// checkDefinition 校验定义 ID、工作流结构以及子工作流之间没有递归引用，节点类型在运行时才解析
+func checkDefinition(req definitionRequest, store definition.Store) error {
+	if err := definition.ValidateID(req.ID); err != nil {
+		return err
+	}
+	if req.Workflow.Nodes == nil {
+		return errors.New("workflow is required")
+	}
+	if _, err := model.NewWorkflow(req.ID, req.Workflow); err != nil {
+		return err
+	}
+	return model.CheckSubWorkflowCycles(req.ID, req.Workflow, definition.Source{Store: store})
+}
+
+// definitionErrorStatus 将存储错误映射为 HTTP 状态码
+func definitionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, definition.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, definition.ErrExists):
+		return http.StatusConflict
+	case errors.Is(err, definition.ErrInvalidID):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
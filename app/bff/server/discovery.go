@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"zflow/api/registry"
+	"zflow/app/bff/global"
+	"zflow/utils/health"
+	"zflow/utils/selector"
+)
+
+// discovery 按注册中心推送的变更维护本地实例表，并把可用的实例同步到负载均衡器
+type discovery struct {
+	mu        sync.Mutex
+	instances map[string]map[string]*registry.ServiceInstance // 服务名 -> 实例 ID -> 实例
+	local     map[string]registry.HealthStatus                // bff 自己探测的健康状态，key 为 服务名/实例 ID
+}
+
+// newDiscovery 创建 discovery，配置了 global.HealthInterval 时 bff 也主动探测实例
+func newDiscovery() *discovery {
+	d := &discovery{
+		instances: make(map[string]map[string]*registry.ServiceInstance),
+		local:     make(map[string]registry.HealthStatus),
+	}
+	if global.HealthInterval > 0 {
+		go d.checkHealth(health.NewChecker(health.Config{
+			Interval:          global.HealthInterval,
+			Timeout:           global.HealthTimeout,
+			FailureThreshold:  global.HealthFailureThreshold,
+			RecoveryThreshold: global.HealthRecoveryThreshold,
+		}))
+	}
+	return d
+}
+
+// watch 监听所有服务的变更；断开后从最后同步的版本续订
+func (d *discovery) watch(cli registry.RegistryClient) {
+	var revision int64
+	for {
+		stream, err := cli.Watch(context.Background(), &registry.Query{Revision: revision})
+		if err != nil {
+			log.Printf("监听服务失败: %v，稍后重试", err)
+			time.Sleep(time.Second * 3)
+			continue
+		}
+
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				log.Printf("接收服务变更失败: %v，从版本 %d 重新监听", err, revision)
+				break
+			}
+			revision = resp.Revision
+
+			// 新增或地址变化的实例重新获取类型
+			for _, inst := range d.apply(resp) {
+				go fetchServiceTypes(inst)
+			}
+		}
+		time.Sleep(time.Second * 3)
+	}
+}
+
+// apply 将一次推送应用到本地实例表并更新受影响的服务，返回需要重新获取类型的实例；
+// 已没有实例的服务从类型缓存中删除，引用其节点类型的工作流不再能通过解析
+func (d *discovery) apply(resp *registry.WatchResponse) []*registry.ServiceInstance {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	changed := make(map[string]bool)
+	if resp.Snapshot {
+		// 快照替换全部实例，已消失的服务也需要更新
+		for name := range d.instances {
+			changed[name] = true
+			delete(d.instances, name)
+		}
+	}
+	var fetch []*registry.ServiceInstance
+	for _, ev := range resp.Events {
+		inst := ev.Instance
+		changed[inst.Name] = true
+		if ev.Type == registry.EventType_REMOVED {
+			delete(d.instances[inst.Name], inst.Id)
+			delete(d.local, inst.Name+"/"+inst.Id)
+			if len(d.instances[inst.Name]) == 0 {
+				delete(d.instances, inst.Name)
+			}
+			continue
+		}
+		if d.instances[inst.Name] == nil {
+			d.instances[inst.Name] = make(map[string]*registry.ServiceInstance)
+		}
+		// 只有健康状态变化时不必重新获取类型
+		if old, ok := d.instances[inst.Name][inst.Id]; !ok || old.Addr != inst.Addr {
+			fetch = append(fetch, inst)
+		}
+		d.instances[inst.Name][inst.Id] = inst
+	}
+	for name := range changed {
+		d.rebalance(name)
+		if len(d.instances[name]) == 0 {
+			global.Cache.RemoveService(name)
+			log.Printf("服务 %s 已没有实例，删除其节点类型和连接类型", name)
+		}
+	}
+	return fetch
+}
+
+// rebalance 更新负载均衡器中某个服务的实例，排除注册中心或 bff 探测为不健康的实例，调用方需持有 d.mu
+func (d *discovery) rebalance(serviceName string) {
+	group := d.instances[serviceName]
+	list := make([]*selector.ServiceInstance, 0, len(group))
+	unhealthy := 0
+	for id, inst := range group {
+		if inst.Health == registry.HealthStatus_UNHEALTHY || d.local[serviceName+"/"+id] == registry.HealthStatus_UNHEALTHY {
+			unhealthy++
+			continue
+		}
+		list = append(list, &selector.ServiceInstance{
+			ID:   inst.Id,
+			Addr: inst.Addr,
+			Meta: inst.Meta,
+		})
+	}
+	// 固定顺序，轮询结果不受 map 遍历顺序影响
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	global.LoadBalance.SetInstances(serviceName, list)
+	log.Printf("服务 %s 的实例已更新到负载均衡器，共 %d 个实例，排除 %d 个不健康实例", serviceName, len(list), unhealthy)
+}
+
+// checkHealth bff 自己定时探测全部实例，用于注册中心未开启探测或与 bff 之间网络不同的情况
+func (d *discovery) checkHealth(checker *health.Checker) {
+	ticker := time.NewTicker(global.HealthInterval)
+	for range ticker.C {
+		targets := make(map[string]health.Target)
+		d.mu.Lock()
+		for name, group := range d.instances {
+			for id, inst := range group {
+				key := name + "/" + id
+				targets[key] = health.Target{Addr: inst.Addr, Status: d.local[key]}
+			}
+		}
+		d.mu.Unlock()
+		checker.Sync(targets)
+
+		ctx, cancel := context.WithTimeout(context.Background(), global.HealthInterval)
+		changes := checker.Check(ctx)
+		cancel()
+		if len(changes) == 0 {
+			continue
+		}
+
+		d.mu.Lock()
+		affected := make(map[string]bool)
+		for _, change := range changes {
+			d.local[change.Key] = change.Status
+			name := change.Key[:strings.IndexByte(change.Key, '/')]
+			affected[name] = true
+			log.Printf("bff 探测到实例健康状态变化: %s -> %s", change.Key, change.Status)
+		}
+		for name := range affected {
+			d.rebalance(name)
+		}
+		d.mu.Unlock()
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"zflow/app/bff/model"
+	"zflow/app/bff/runner"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// registerEventRoutes 注册运行事件流接口，客户端通过 since 或 Last-Event-ID 从指定序号之后重放
+func registerEventRoutes(router *gin.Engine, runs *runner.Manager) {
+	// Server-Sent Events
+	router.GET("/runs/:id/events", func(c *gin.Context) {
+		run, ok := runs.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+			return
+		}
+		since, err := eventSince(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		streamEvents(c.Request.Context(), run, since, func(e model.Event) error {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		})
+	})
+
+	// WebSocket，每条消息是一个 JSON 事件
+	router.GET("/runs/:id/events/ws", func(c *gin.Context) {
+		run, ok := runs.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+			return
+		}
+		since, err := eventSince(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 不校验 Origin，与其它接口保持一致
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+
+			// 客户端不发送消息，读取失败即表示连接已关闭
+			go func() {
+				defer cancel()
+				var discard []byte
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+			}()
+
+			streamEvents(ctx, run, since, func(e model.Event) error {
+				return websocket.JSON.Send(ws, e)
+			})
+		}}.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+// eventSince 解析重放起点：since 查询参数优先，其次 SSE 重连时的 Last-Event-ID
+func eventSince(c *gin.Context) (int64, error) {
+	v := c.Query("since")
+	if v == "" {
+		v = c.GetHeader("Last-Event-ID")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	since, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || since < 0 {
+		return 0, fmt.Errorf("since must be a non-negative integer")
+	}
+	return since, nil
+}
+
+// streamEvents 发送序号大于 since 的事件并持续等待新事件，
+// 运行结束且事件发送完毕、客户端断开或发送失败时返回
+func streamEvents(ctx context.Context, run *runner.Run, since int64, send func(model.Event) error) {
+	for {
+		events, wait, closed := run.Events(since)
+		for _, e := range events {
+			if err := send(e); err != nil {
+				return
+			}
+			since = e.Seq
+		}
+		if closed {
+			return
+		}
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
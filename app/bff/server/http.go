@@ -2,17 +2,20 @@ package server
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"zflow/api/registry"
+	"zflow/app/bff/definition"
 	"zflow/app/bff/global"
+	"zflow/app/bff/history"
 	"zflow/app/bff/model"
-	"zflow/utils/selector"
+	"zflow/app/bff/runner"
 
 	v1 "zflow/api/base"
-	registryCore "zflow/app/registry/core"
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
@@ -20,17 +23,41 @@ import (
 )
 
 func NewServer() *http.Server {
-	// 连接注册中心
-	conn, err := grpc.NewClient(registryCore.SERVICE_REGISTRY_ADDR, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// 连接注册中心，集群部署时节点不可用会切换到其它节点
+	conn, err := registry.Dial(registry.SERVICE_REGISTRY_ADDR)
 	if err != nil {
 		log.Fatalf("连接注册中心失败: %v", err)
 	}
-	defer conn.Close()
+	// 连接随进程存活，供 Watch 长连接使用
 
 	cli := registry.NewRegistryClient(conn)
 
 	// 监听所有服务
-	go watchAllServices(cli)
+	go newDiscovery().watch(cli)
+
+	// 运行记录存储与运行管理器
+	records, err := history.NewFileStore(global.HistoryDir)
+	if err != nil {
+		log.Fatalf("初始化运行记录存储失败: %v", err)
+	}
+	cache, err := newMemoStore()
+	if err != nil {
+		log.Fatalf("初始化节点输出缓存失败: %v", err)
+	}
+	secrets, err := newSecretStore()
+	if err != nil {
+		log.Fatalf("初始化密钥存储失败: %v", err)
+	}
+	runs := runner.NewManager(model.Limits{
+		MaxConcurrency: global.MaxConcurrency,
+		MaxPerService:  global.MaxPerService,
+	}, global.RunRetention, records, cache, newSecretProvider(secrets))
+
+	// 工作流定义存储
+	defs, err := definition.NewFileStore(global.DefinitionDir)
+	if err != nil {
+		log.Fatalf("初始化工作流定义存储失败: %v", err)
+	}
 
 	router := gin.Default()
 
@@ -44,107 +71,207 @@ func NewServer() *http.Server {
 		c.JSON(http.StatusOK, global.Cache.GetConnTypes())
 	})
 
-	// 执行工作流
+	// 工作流定义
+	registerDefinitionRoutes(router, defs)
+
+	// 校验等不执行工作流的接口
+	registerWorkflowRoutes(router, defs)
+
+	// 提交工作流运行：按 workflow_id 与 version 运行已保存的定义，或直接提交 workflow
 	router.POST("/workflows", func(c *gin.Context) {
-		var req struct {
-			UID      string            `json:"uid"`
-			Workflow model.RawWorkflow `json:"workflow"`
-		}
+		var req workflowRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		if req.UID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "uid is required"})
+
+		// 1、创建工作流
+		wf, raw, status, err := buildWorkflow(defs, req)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
-		if req.Workflow.Nodes == nil || req.Workflow.Connections == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "workflow is required"})
+
+		// 2、解析类型并校验，失败时返回全部诊断
+		if err := resolveAndValidate(defs, wf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "diagnostics": wf.Diagnose()})
 			return
 		}
 
-		// 1、创建工作流
-		wf, err := model.NewWorkflow(req.UID, req.Workflow)
+		// 3、合并声明的默认值与本次运行的变量
+		vars, err := wf.ResolveVars(req.Vars)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		// 2、注入节点和连接类型的操作
-		// if err := wf.InjectOperations(); err != nil {
-		// 	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		// 	return
-		// }
+		// 4、提交到后台执行，立即返回运行 ID
+		run := runs.Submit(wf, raw, vars)
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"run_id":  run.ID,
+			"status":  runner.StatusPending,
+			"version": run.Version,
+		})
+	})
+
+	// 查询运行记录，支持按 workflow_id、status、resumed_from 与创建时间范围 from/to（RFC3339）过滤
+	router.GET("/runs", func(c *gin.Context) {
+		filter := history.Filter{
+			WorkflowID:  c.Query("workflow_id"),
+			Status:      c.Query("status"),
+			ResumedFrom: c.Query("resumed_from"),
+		}
+		for key, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+			if v := c.Query(key); v != "" {
+				parsed, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be RFC3339 time"})
+					return
+				}
+				*t = parsed
+			}
+		}
+		if v := c.Query("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer"})
+				return
+			}
+			filter.Limit = limit
+		}
 
-		// 3、创建执行上下文
-		ctx := &model.ExecutionContext{
-			Workflow: wf,
-			Logger: func(msg string) {
-				fmt.Println(msg)
-			},
-			Vars: make(map[string]interface{}),
+		list, err := records.List(filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	// 获取运行的完整记录：定义快照、变量、时间、每个节点的输入输出与日志
+	router.GET("/runs/:id/record", func(c *gin.Context) {
+		rec, status, err := getRecord(runs, records, c.Param("id"))
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rec)
+	})
+
+	// 续跑失败的运行：复用成功节点的输出，重新执行失败的节点及其下游，可覆盖节点输入与变量
+	router.POST("/runs/:id/resume", func(c *gin.Context) {
+		var req struct {
+			Inputs map[string]map[string][]byte `json:"inputs"` // 节点 ID -> 端口名 -> 输入数据
+			Vars   map[string]interface{}       `json:"vars"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 		}
 
-		// 4、执行工作流
-		if err := wf.ExecuteWorkflow(ctx); err != nil {
+		// 1、读取原运行的记录
+		rec, status, err := getRecord(runs, records, c.Param("id"))
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 2、按定义快照重建工作流，解析类型并校验
+		wf, err := model.NewWorkflow(rec.WorkflowID, rec.Definition)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		wf.Version = rec.Version
+		if err := resolveAndValidate(defs, wf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "diagnostics": wf.Diagnose()})
+			return
+		}
 
-		// 5、收集工作流执行结果
-		result := wf.CollectWorkflowResults()
+		// 3、提交续跑
+		run, err := runs.Resume(rec, wf, req.Inputs, req.Vars)
+		if errors.Is(err, runner.ErrNotResumable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusAccepted, gin.H{
+			"run_id":       run.ID,
+			"status":       runner.StatusPending,
+			"resumed_from": run.ResumedFrom,
+		})
 	})
 
-	return &http.Server{
-		Addr:    ":8080",
-		Handler: router,
-	}
-}
+	// 运行事件流
+	registerEventRoutes(router, runs)
 
-// watchAllServices 监听所有服务
-func watchAllServices(cli registry.RegistryClient) {
-	stream, err := cli.Watch(context.Background(), &registry.Query{})
-	if err != nil {
-		log.Fatalf("监听服务失败: %v", err)
-	}
+	// 节点输出缓存
+	registerCacheRoutes(router, cache)
 
-	for {
-		srvList, err := stream.Recv()
-		if err != nil {
-			log.Printf("接收服务列表失败: %v", err)
+	// 密钥管理
+	registerSecretRoutes(router, secrets)
+
+	// 查询运行状态
+	router.GET("/runs/:id", func(c *gin.Context) {
+		run, ok := runs.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
 			return
 		}
+		c.JSON(http.StatusOK, run.Snapshot())
+	})
 
-		// 更新负载均衡器中的服务实例
-		updateLoadBalancer(srvList.Instances)
+	// 获取运行结果
+	router.GET("/runs/:id/result", func(c *gin.Context) {
+		run, ok := runs.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+			return
+		}
+		if !run.Finished() {
+			c.JSON(http.StatusConflict, gin.H{"error": "run is not finished", "status": run.Snapshot().Status})
+			return
+		}
+		c.JSON(http.StatusOK, run.Result())
+	})
 
-		// 处理每个服务实例
-		for _, inst := range srvList.Instances {
-			go fetchServiceTypes(inst)
+	// 取消运行
+	router.POST("/runs/:id/cancel", func(c *gin.Context) {
+		run, ok := runs.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+			return
 		}
+		run.Cancel()
+		c.JSON(http.StatusAccepted, gin.H{"run_id": run.ID})
+	})
+
+	return &http.Server{
+		Addr:    ":8080",
+		Handler: router,
 	}
 }
 
-// updateLoadBalancer 更新负载均衡器中的服务实例
-func updateLoadBalancer(instances []*registry.ServiceInstance) {
-	// 按服务名分组
-	serviceGroups := make(map[string][]*selector.ServiceInstance)
-	for _, inst := range instances {
-		serviceInstance := &selector.ServiceInstance{
-			ID:   inst.Id,
-			Addr: inst.Addr,
-			Meta: inst.Meta,
-		}
-		serviceGroups[inst.Name] = append(serviceGroups[inst.Name], serviceInstance)
+// getRecord 获取运行记录：运行仍在内存中时返回最新状态，否则从运行记录存储读取
+func getRecord(runs *runner.Manager, records history.Store, runID string) (history.Record, int, error) {
+	if run, ok := runs.Get(runID); ok {
+		return run.Record(), http.StatusOK, nil
 	}
-
-	// 更新负载均衡器
-	for serviceName, instances := range serviceGroups {
-		global.LoadBalance.SetInstances(serviceName, instances)
-		log.Printf("服务 %s 的实例已更新到负载均衡器，共 %d 个实例", serviceName, len(instances))
+	rec, err := records.Get(runID)
+	if errors.Is(err, history.ErrNotFound) {
+		return history.Record{}, http.StatusNotFound, errors.New("run not found")
+	}
+	if err != nil {
+		return history.Record{}, http.StatusInternalServerError, err
 	}
+	return rec, http.StatusOK, nil
 }
 
 // fetchServiceTypes 获取服务的节点类型和连接类型
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"zflow/app/bff/global"
+	"zflow/app/bff/secret"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newSecretStore 按配置打开加密的密钥文件，加密密钥优先取环境变量，其次密钥文件；未配置文件时返回空
+func newSecretStore() (secret.Store, error) {
+	if global.SecretFile == "" {
+		return nil, nil
+	}
+	var key []byte
+	if encoded := os.Getenv(global.SecretKeyEnv); encoded != "" {
+		var err error
+		key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != secret.KeySize {
+			return nil, fmt.Errorf("%s must be a base64 encoded %d-byte key", global.SecretKeyEnv, secret.KeySize)
+		}
+	} else {
+		var err error
+		if key, err = secret.LoadKey(global.SecretKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	return secret.NewFileStore(global.SecretFile, key)
+}
+
+// newSecretProvider 组合文件存储与环境变量，先查文件存储
+func newSecretProvider(store secret.Store) secret.Provider {
+	var chain secret.Chain
+	if store != nil {
+		chain = append(chain, store)
+	}
+	if global.SecretEnvPrefix != "" {
+		chain = append(chain, secret.EnvProvider{Prefix: global.SecretEnvPrefix})
+	}
+	return chain
+}
+
+// secretErrorStatus 密钥存储错误对应的 HTTP 状态码
+func secretErrorStatus(err error) int {
+	if errors.Is(err, secret.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// registerSecretRoutes 注册密钥管理接口，接口不会返回密钥值；store 为空时接口返回 404
+func registerSecretRoutes(router *gin.Engine, store secret.Store) {
+	disabled := func(c *gin.Context) bool {
+		if store == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "secret store is disabled"})
+			return true
+		}
+		return false
+	}
+
+	// 列出密钥名
+	router.GET("/secrets", func(c *gin.Context) {
+		if disabled(c) {
+			return
+		}
+		names, err := store.List()
+		if err != nil {
+			c.JSON(secretErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, names)
+	})
+
+	// 保存密钥，已存在时覆盖
+	router.PUT("/secrets/:name", func(c *gin.Context) {
+		if disabled(c) {
+			return
+		}
+		var req struct {
+			Value *string `json:"value"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		name := c.Param("name")
+		if !secret.ValidName(name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid secret name %q", name)})
+			return
+		}
+		if req.Value == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "value is required"})
+			return
+		}
+		if err := store.Put(name, *req.Value); err != nil {
+			c.JSON(secretErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// 删除密钥
+	router.DELETE("/secrets/:name", func(c *gin.Context) {
+		if disabled(c) {
+			return
+		}
+		if err := store.Delete(c.Param("name")); err != nil {
+			c.JSON(secretErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
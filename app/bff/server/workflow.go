@@ -0,0 +1,118 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"zflow/app/bff/definition"
+	"zflow/app/bff/global"
+	"zflow/app/bff/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// workflowRequest 提交、校验工作流的请求：按 workflow_id 与 version 引用已保存的定义，或直接提交 workflow
+type workflowRequest struct {
+	UID        string                 `json:"uid"`
+	Workflow   model.RawWorkflow      `json:"workflow"`
+	WorkflowID string                 `json:"workflow_id"`
+	Version    int                    `json:"version"`        // 0 表示最新版本
+	Vars       map[string]interface{} `json:"vars,omitempty"` // 覆盖工作流声明的变量，只用于提交运行
+}
+
+// buildWorkflow 按请求创建工作流，返回工作流和原始定义；失败时同时返回 HTTP 状态码
+func buildWorkflow(defs definition.Store, req workflowRequest) (*model.Workflow, model.RawWorkflow, int, error) {
+	if req.WorkflowID != "" {
+		def, err := defs.Get(req.WorkflowID, req.Version)
+		if err != nil {
+			return nil, model.RawWorkflow{}, definitionErrorStatus(err), err
+		}
+		wf, err := model.NewWorkflow(def.ID, def.Workflow)
+		if err != nil {
+			return nil, def.Workflow, http.StatusBadRequest, err
+		}
+		wf.Version = def.Version
+		return wf, def.Workflow, http.StatusOK, nil
+	}
+
+	if req.UID == "" {
+		return nil, req.Workflow, http.StatusBadRequest, errors.New("uid or workflow_id is required")
+	}
+	if req.Workflow.Nodes == nil {
+		return nil, req.Workflow, http.StatusBadRequest, errors.New("workflow is required")
+	}
+	wf, err := model.NewWorkflow(req.UID, req.Workflow)
+	if err != nil {
+		return nil, req.Workflow, http.StatusBadRequest, err
+	}
+	return wf, req.Workflow, http.StatusOK, nil
+}
+
+// newResolver 创建从服务目录解析类型、从定义存储加载子工作流的解析器
+func newResolver(defs definition.Store) *model.Resolver {
+	return model.NewResolver(global.Cache, definition.Source{Store: defs})
+}
+
+// resolveAndValidate 解析工作流的类型并校验，与提交运行的要求一致
+func resolveAndValidate(defs definition.Store, wf *model.Workflow) error {
+	if err := wf.Resolve(newResolver(defs)); err != nil {
+		return err
+	}
+	return wf.Validate()
+}
+
+// registerWorkflowRoutes 注册不执行工作流的辅助接口
+func registerWorkflowRoutes(router *gin.Engine, defs definition.Store) {
+	// 校验工作流，返回全部诊断，工作流有错误时 valid 为 false
+	router.POST("/workflows/validate", func(c *gin.Context) {
+		var req workflowRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		wf, _, status, err := buildWorkflow(defs, req)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 解析失败的类型会以诊断报告，这里忽略错误
+		_ = wf.Resolve(newResolver(defs))
+		diags := wf.Diagnose()
+		if diags == nil {
+			diags = []model.Diagnostic{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"valid":       !model.HasErrors(diags),
+			"diagnostics": diags,
+		})
+	})
+	// 生成执行计划：层级、关键路径以及每个节点将由哪个服务执行，不执行任何节点
+	router.POST("/workflows/plan", func(c *gin.Context) {
+		var req workflowRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 1、创建、解析并校验工作流，与提交运行一致
+		wf, _, status, err := buildWorkflow(defs, req)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if err := resolveAndValidate(defs, wf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "diagnostics": wf.Diagnose()})
+			return
+		}
+
+		// 2、生成计划
+		plan, err := wf.Plan()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+	})
+}
@@ -5,32 +5,100 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"strings"
+	"time"
 
 	v1 "zflow/api/registry"
 	"zflow/app/registry/core"
+	"zflow/utils/health"
 
 	"google.golang.org/grpc"
 )
 
 var (
-	port = flag.Int("port", 50051, "The server port")
+	port             = flag.Int("port", 50051, "The server port")
+	dataDir          = flag.String("data", "", "Directory for the registry wal and snapshots, empty keeps registrations in memory only")
+	snapshotInterval = flag.Duration("snapshot-interval", time.Minute, "Interval between registry snapshots")
+	grace            = flag.Duration("grace", 30*time.Second, "Minimum lease kept for instances restored after a restart or a leader change")
+	nodeID           = flag.String("id", "", "Node ID in cluster mode")
+	peers            = flag.String("peers", "", "Cluster nodes as id=addr pairs separated by commas, including this node; empty runs a single node")
+	electionTimeout  = flag.Duration("election-timeout", time.Second, "Minimum election timeout in cluster mode")
+	healthInterval   = flag.Duration("health-interval", 5*time.Second, "Interval between active gRPC health checks of instances, 0 disables them")
+	healthTimeout    = flag.Duration("health-timeout", time.Second, "Timeout of a single health check")
+	healthFailures   = flag.Int("health-failures", 3, "Consecutive failed health checks before an instance is marked unhealthy")
+	healthRecoveries = flag.Int("health-recoveries", 2, "Consecutive successful health checks before an unhealthy instance is marked healthy again")
 )
 
 func main() {
 	flag.Parse()
 
+	// 集群模式下监听本节点在 -peers 中的地址
+	addr := ":" + fmt.Sprintf("%d", *port)
+	cluster, err := parsePeers(*peers)
+	if err != nil {
+		log.Fatalf("invalid -peers: %v", err)
+	}
+	if len(cluster) > 0 {
+		self, ok := cluster[*nodeID]
+		if !ok {
+			log.Fatalf("-id %q is not in -peers", *nodeID)
+		}
+		addr = self
+	}
+
 	// 创建 gRPC 服务器
-	lis, err := net.Listen("tcp", ":"+fmt.Sprintf("%d", *port))
+	lis, err := net.Listen("tcp", addr)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
+	// 创建注册中心，配置了持久化目录时从中恢复
+	reg, err := core.NewRegistry(core.Options{
+		DataDir:          *dataDir,
+		SnapshotInterval: *snapshotInterval,
+		Grace:            *grace,
+		NodeID:           *nodeID,
+		Peers:            cluster,
+		ElectionTimeout:  *electionTimeout,
+		Health: health.Config{
+			Interval:          *healthInterval,
+			Timeout:           *healthTimeout,
+			FailureThreshold:  *healthFailures,
+			RecoveryThreshold: *healthRecoveries,
+		},
+	})
+	if err != nil {
+		log.Fatalf("failed to create registry: %v", err)
+	}
+
 	s := grpc.NewServer()
 	// 注册 registry 服务
-	v1.RegisterRegistryServer(s, core.NewRegistry())
+	v1.RegisterRegistryServer(s, reg)
+	if cluster := reg.Cluster(); cluster != nil {
+		v1.RegisterClusterServer(s, cluster)
+	}
 
 	log.Printf("Server listening at %v", lis.Addr())
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
 }
+
+// parsePeers 解析 id=addr,id=addr 形式的集群节点
+func parsePeers(s string) (map[string]string, error) {
+	peers := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		id, addr, ok := strings.Cut(item, "=")
+		if !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("want id=addr, got %q", item)
+		}
+		if _, dup := peers[id]; dup {
+			return nil, fmt.Errorf("duplicate node id %q", id)
+		}
+		peers[id] = addr
+	}
+	return peers, nil
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"zflow/utils/health"
+)
+
+// checkHealth 定时探测全部实例，健康状态变化作为变更提交，Watch 客户端收到 UPDATED 事件；
+// 集群模式下只由 leader 探测
+func (r *registry) checkHealth(checker *health.Checker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if r.raft != nil && !r.raft.isLeader() {
+			checker.Sync(nil)
+			continue
+		}
+
+		type instanceKey struct{ name, id string }
+		keys := make(map[string]instanceKey)
+		targets := make(map[string]health.Target)
+		r.mu.RLock()
+		for name, grp := range r.services {
+			for id, e := range grp {
+				key := name + "/" + id
+				keys[key] = instanceKey{name, id}
+				targets[key] = health.Target{Addr: e.inst.Addr, Status: e.inst.Health}
+			}
+		}
+		r.mu.RUnlock()
+		checker.Sync(targets)
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		for _, change := range checker.Check(ctx) {
+			k := keys[change.Key]
+			if _, exists := r.lookup(k.name, k.id); !exists {
+				// 探测期间实例已注销或过期
+				continue
+			}
+			if err := r.commit(ctx, &command{Op: opHealth, Name: k.name, ID: k.id, Health: change.Status}); err != nil {
+				// 下一轮探测时重试
+				log.Printf("更新健康状态失败: %s (ID: %s) - %v", k.name, k.id, err)
+				continue
+			}
+			log.Printf("服务健康状态变化: %s (ID: %s) -> %s", k.name, k.id, change.Status)
+		}
+		cancel()
+	}
+}
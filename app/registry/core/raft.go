@@ -0,0 +1,854 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "zflow/api/registry"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// 节点角色
+const (
+	roleFollower  = "follower"
+	roleCandidate = "candidate"
+	roleLeader    = "leader"
+)
+
+const (
+	raftStateFile    = "raft.json" // 持久化的任期与投票
+	raftLogFile      = "raft.log"  // 尚未压缩的复制日志，每行一条 JSON 编码的 LogEntry
+	maxAppendEntries = 256         // 单次复制的最多日志条数
+	maxLogEntries    = 1024        // 已应用日志至少保留的条数，落后更多的跟随者通过快照追赶
+)
+
+// errNotLeader 写请求到达的节点已不是 leader，客户端重试即可
+var errNotLeader = status.Error(codes.Unavailable, "registry node is not the leader")
+
+// raftState 需要持久化的选举状态，重启后不会在同一任期重复投票
+type raftState struct {
+	Term     int64  `json:"term"`
+	VotedFor string `json:"voted_for,omitempty"`
+}
+
+// peer 集群中的其它节点
+type peer struct {
+	id       string
+	cluster  v1.ClusterClient
+	registry v1.RegistryClient // 转发写请求
+	next     int64             // 下一条要发送的日志
+	match    int64             // 已确认复制的最后一条日志
+	wake     chan struct{}     // 有新日志时唤醒复制协程
+}
+
+// waiter 等待提议的日志被应用
+type waiter struct {
+	term int64
+	done chan error
+}
+
+// raftNode 集群节点：选举 leader，由 leader 把注册表变更复制到多数节点后再应用。
+// 已应用的变更由 registry 写入预写日志与快照；复制日志在确认复制或计入提交之前写入 raft.log 并落盘，
+// 重启后不会丢失已确认的日志
+type raftNode struct {
+	v1.UnimplementedClusterServer
+	id              string
+	reg             *registry
+	peers           map[string]*peer
+	dir             string   // 持久化选举状态与复制日志的目录
+	logFile         *os.File // 复制日志，只追加
+	electionTimeout time.Duration
+	heartbeat       time.Duration
+	grace           time.Duration
+
+	mu          sync.Mutex
+	role        string
+	term        int64
+	votedFor    string
+	leaderID    string
+	leaderSince time.Time
+	entries     []*v1.LogEntry // baseIndex 之后的日志
+	baseIndex   int64          // 已压缩的最后一条日志，之前的变更只存在于注册表快照中
+	baseTerm    int64
+	commitIndex int64
+	applied     int64
+	deadline    time.Time // 选举超时时刻
+	lastContact time.Time // 最后一次收到 leader 的消息
+	waiters     map[int64]*waiter
+}
+
+// newRaftNode 创建集群节点并启动选举与复制协程，注册表需已从持久化目录恢复
+func newRaftNode(reg *registry, opts Options) (*raftNode, error) {
+	if _, ok := opts.Peers[opts.NodeID]; !ok {
+		return nil, fmt.Errorf("node %q is not in the cluster peers", opts.NodeID)
+	}
+	// 任期、投票与已确认的日志必须落盘，否则重启后可能在同一任期重复投票或丢失已提交的日志
+	if opts.DataDir == "" {
+		return nil, fmt.Errorf("cluster mode requires a data dir")
+	}
+	if opts.ElectionTimeout <= 0 {
+		opts.ElectionTimeout = time.Second
+	}
+	n := &raftNode{
+		id:              opts.NodeID,
+		reg:             reg,
+		peers:           make(map[string]*peer),
+		dir:             opts.DataDir,
+		electionTimeout: opts.ElectionTimeout,
+		heartbeat:       opts.ElectionTimeout / 5,
+		grace:           opts.Grace,
+		role:            roleFollower,
+		waiters:         make(map[int64]*waiter),
+	}
+
+	if err := n.load(); err != nil {
+		return nil, err
+	}
+
+	for id, addr := range opts.Peers {
+		if id == n.id {
+			continue
+		}
+		// 节点恢复后尽快重连，避免心跳迟迟发不出去导致它发起选举
+		conn, err := grpc.NewClient(addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithConnectParams(grpc.ConnectParams{
+				Backoff:           backoff.Config{BaseDelay: 100 * time.Millisecond, Multiplier: 1.6, MaxDelay: n.heartbeat},
+				MinConnectTimeout: n.electionTimeout,
+			}),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to peer %s: %v", id, err)
+		}
+		n.peers[id] = &peer{
+			id:       id,
+			cluster:  v1.NewClusterClient(conn),
+			registry: v1.NewRegistryClient(conn),
+			wake:     make(chan struct{}, 1),
+		}
+	}
+
+	n.mu.Lock()
+	n.resetDeadline()
+	n.mu.Unlock()
+	go n.run()
+	for _, p := range n.peers {
+		go n.replicate(p)
+	}
+	log.Printf("集群节点 %s 已启动: 节点 %v, 已应用 %d (任期: %d)", n.id, peerIDs(opts.Peers), n.applied, n.term)
+	return n, nil
+}
+
+// load 从注册表已应用的位置开始，读取持久化的任期、投票与之后的复制日志，并打开日志文件用于追加
+func (n *raftNode) load() error {
+	n.reg.mu.RLock()
+	n.baseIndex, n.baseTerm = n.reg.seq, n.reg.seqTerm
+	n.reg.mu.RUnlock()
+	n.commitIndex, n.applied = n.baseIndex, n.baseIndex
+
+	data, err := os.ReadFile(filepath.Join(n.dir, raftStateFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read raft state: %v", err)
+	}
+	if err == nil {
+		var state raftState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("failed to decode raft state: %v", err)
+		}
+		n.term, n.votedFor = state.Term, state.VotedFor
+	}
+
+	path := filepath.Join(n.dir, raftLogFile)
+	n.entries, err = readRaftLog(path, n.baseIndex)
+	if err != nil {
+		return err
+	}
+	// 任期不能小于已有日志的任期
+	if last := n.termAt(n.lastIndex()); n.term < last {
+		n.term, n.votedFor = last, ""
+	}
+	n.logFile, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open raft log: %v", err)
+	}
+	return nil
+}
+
+// readRaftLog 读取序号大于 after 的复制日志。后写入的日志覆盖序号相同或更大的旧日志（冲突时以 leader 为准）；
+// 与 after 不连续的日志无法使用，丢弃后由 leader 重新复制；最后一行不完整时视为写入中断，忽略该行
+func readRaftLog(path string, after int64) ([]*v1.LogEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read raft log: %v", err)
+	}
+	defer f.Close()
+
+	var entries []*v1.LogEntry
+	var broken error
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if broken != nil {
+			return nil, broken
+		}
+		entry := &v1.LogEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			broken = fmt.Errorf("raft log line %d is corrupted: %v", line, err)
+			continue
+		}
+		if entry.Index <= after {
+			continue
+		}
+		last := after + int64(len(entries))
+		if entry.Index > last+1 {
+			log.Printf("丢弃不连续的复制日志: 序号 %d 之前缺少 %d", entry.Index, last+1)
+			entries = nil
+			continue
+		}
+		entries = append(entries[:entry.Index-after-1], entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read raft log: %v", err)
+	}
+	if broken != nil {
+		log.Printf("忽略复制日志末尾不完整的记录: %v", broken)
+	}
+	return entries, nil
+}
+
+// appendLog 把日志追加到 raft.log 并落盘，调用方需持有 n.mu
+func (n *raftNode) appendLog(entries []*v1.LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode raft log: %v", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if _, err := n.logFile.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write raft log: %v", err)
+	}
+	if err := n.logFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync raft log: %v", err)
+	}
+	return nil
+}
+
+// rewriteLog 压缩日志或安装快照后按内存中的日志重写 raft.log，先写临时文件再改名，调用方需持有 n.mu
+func (n *raftNode) rewriteLog() error {
+	path := filepath.Join(n.dir, raftLogFile)
+	tmp, err := os.CreateTemp(n.dir, ".raft-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to rewrite raft log: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	old := n.logFile
+	n.logFile = tmp
+	err = n.appendLog(n.entries)
+	n.logFile = old
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rewrite raft log: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open raft log: %v", err)
+	}
+	n.logFile.Close()
+	n.logFile = f
+	return nil
+}
+
+// Cluster 返回集群节点间通信的服务，单机运行时为 nil
+func (r *registry) Cluster() v1.ClusterServer {
+	if r.raft == nil {
+		return nil
+	}
+	return r.raft
+}
+
+// run 选举超时后发起预投票
+func (n *raftNode) run() {
+	ticker := time.NewTicker(n.heartbeat / 2)
+	for range ticker.C {
+		n.mu.Lock()
+		if n.role != roleLeader && time.Now().After(n.deadline) {
+			n.startPreVote()
+		}
+		n.mu.Unlock()
+	}
+}
+
+// startPreVote 以下一个任期询问其它节点是否会投票，多数同意后才真正发起选举，
+// 连不上 leader 的少数节点不会不断抬高任期，调用方需持有 n.mu
+func (n *raftNode) startPreVote() {
+	n.resetDeadline()
+	term := n.term
+	votes := 1
+	if n.quorum(votes) {
+		n.startElection()
+		return
+	}
+	req := &v1.VoteRequest{Term: term + 1, CandidateId: n.id, LastLogIndex: n.lastIndex(), LastLogTerm: n.termAt(n.lastIndex()), PreVote: true}
+	for _, p := range n.peers {
+		go func(p *peer) {
+			ctx, cancel := context.WithTimeout(context.Background(), n.electionTimeout/2)
+			resp, err := p.cluster.RequestVote(ctx, req)
+			cancel()
+			if err != nil {
+				return
+			}
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if resp.Term > n.term {
+				n.becomeFollower(resp.Term, "")
+				return
+			}
+			if n.role == roleLeader || n.term != term || !resp.Granted {
+				return
+			}
+			votes++
+			if n.quorum(votes) {
+				n.startElection()
+			}
+		}(p)
+	}
+}
+
+// startElection 成为候选者并向其它节点请求投票，调用方需持有 n.mu
+func (n *raftNode) startElection() {
+	n.role = roleCandidate
+	n.term++
+	n.votedFor = n.id
+	n.leaderID = ""
+	n.persist()
+	n.resetDeadline()
+	log.Printf("节点 %s 发起选举 (任期: %d)", n.id, n.term)
+
+	term := n.term
+	votes := 1
+	if n.quorum(votes) {
+		n.becomeLeader()
+		return
+	}
+	req := &v1.VoteRequest{Term: term, CandidateId: n.id, LastLogIndex: n.lastIndex(), LastLogTerm: n.termAt(n.lastIndex())}
+	for _, p := range n.peers {
+		go func(p *peer) {
+			ctx, cancel := context.WithTimeout(context.Background(), n.electionTimeout/2)
+			resp, err := p.cluster.RequestVote(ctx, req)
+			cancel()
+			if err != nil {
+				return
+			}
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if resp.Term > n.term {
+				n.becomeFollower(resp.Term, "")
+				return
+			}
+			if n.role != roleCandidate || n.term != term || !resp.Granted {
+				return
+			}
+			votes++
+			if n.quorum(votes) {
+				n.becomeLeader()
+			}
+		}(p)
+	}
+}
+
+// becomeLeader 当选 leader，写入一条空变更以提交之前任期的日志，调用方需持有 n.mu
+func (n *raftNode) becomeLeader() {
+	n.role = roleLeader
+	n.leaderID = n.id
+	n.leaderSince = time.Now()
+	for _, p := range n.peers {
+		p.next, p.match = n.lastIndex()+1, 0
+	}
+	log.Printf("节点 %s 当选为 leader (任期: %d)", n.id, n.term)
+	if err := n.appendLocal(&command{Op: opNoop}); err != nil {
+		log.Printf("写入复制日志失败: %v", err)
+	}
+}
+
+// becomeFollower 转为跟随者，term 更大时进入新任期，调用方需持有 n.mu
+func (n *raftNode) becomeFollower(term int64, leaderID string) {
+	if term > n.term {
+		n.term, n.votedFor = term, ""
+		n.persist()
+	}
+	if n.role == roleLeader {
+		log.Printf("节点 %s 不再是 leader (任期: %d)", n.id, n.term)
+	}
+	if n.leaderID != leaderID && leaderID != "" {
+		log.Printf("节点 %s 跟随 leader %s (任期: %d)", n.id, leaderID, n.term)
+	}
+	n.role = roleFollower
+	n.leaderID = leaderID
+	n.resetDeadline()
+	// 未应用的提议交给客户端重试
+	for index, w := range n.waiters {
+		w.done <- errNotLeader
+		delete(n.waiters, index)
+	}
+}
+
+// propose 由 leader 追加一条变更，复制到多数节点并应用后返回
+func (n *raftNode) propose(ctx context.Context, cmd *command) error {
+	n.mu.Lock()
+	if n.role != roleLeader {
+		n.mu.Unlock()
+		return errNotLeader
+	}
+	if err := n.appendLocal(cmd); err != nil {
+		n.mu.Unlock()
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	w := &waiter{term: n.term, done: make(chan error, 1)}
+	n.waiters[cmd.Seq] = w
+	n.mu.Unlock()
+
+	select {
+	case err := <-w.done:
+		return err
+	case <-ctx.Done():
+		n.mu.Lock()
+		for index, other := range n.waiters {
+			if other == w {
+				delete(n.waiters, index)
+			}
+		}
+		n.mu.Unlock()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// appendLocal 把变更落盘后追加到 leader 的日志并唤醒复制协程，调用方需持有 n.mu
+func (n *raftNode) appendLocal(cmd *command) error {
+	cmd.Seq, cmd.Term = n.lastIndex()+1, n.term
+	data, _ := json.Marshal(cmd)
+	entry := &v1.LogEntry{Index: cmd.Seq, Term: cmd.Term, Command: data}
+	// leader 自己计入多数，先落盘
+	if err := n.appendLog([]*v1.LogEntry{entry}); err != nil {
+		return err
+	}
+	n.entries = append(n.entries, entry)
+	// 只有一个节点时直接提交
+	n.advanceCommit()
+	for _, p := range n.peers {
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// replicate leader 向一个节点复制日志，没有新日志时定期发送心跳
+func (n *raftNode) replicate(p *peer) {
+	ticker := time.NewTicker(n.heartbeat)
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.wake:
+		}
+		n.mu.Lock()
+		if n.role != roleLeader {
+			n.mu.Unlock()
+			continue
+		}
+		var ok bool
+		if p.next <= n.baseIndex {
+			ok = n.sendSnapshot(p)
+		} else {
+			ok = n.sendEntries(p)
+		}
+		// 节点不可达时等下一次心跳再重试
+		more := ok && n.role == roleLeader && p.next <= n.lastIndex()
+		n.mu.Unlock()
+		if more {
+			select {
+			case p.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// sendEntries 发送 p.next 之后的日志，节点有响应时返回 true，调用方需持有 n.mu，发送期间释放
+func (n *raftNode) sendEntries(p *peer) bool {
+	term := n.term
+	prev := p.next - 1
+	end := min(n.lastIndex(), prev+maxAppendEntries)
+	req := &v1.AppendRequest{
+		Term:         term,
+		LeaderId:     n.id,
+		PrevLogIndex: prev,
+		PrevLogTerm:  n.termAt(prev),
+		Entries:      append([]*v1.LogEntry(nil), n.entries[prev-n.baseIndex:end-n.baseIndex]...),
+		LeaderCommit: n.commitIndex,
+	}
+
+	n.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), n.electionTimeout/2)
+	resp, err := p.cluster.AppendEntries(ctx, req)
+	cancel()
+	n.mu.Lock()
+
+	if err != nil {
+		return false
+	}
+	if resp.Term > n.term {
+		n.becomeFollower(resp.Term, "")
+		return true
+	}
+	if n.role != roleLeader || n.term != term {
+		return true
+	}
+	if resp.Success {
+		p.match = max(p.match, end)
+		p.next = p.match + 1
+		n.advanceCommit()
+		return true
+	}
+	p.next = max(1, min(prev, resp.LastIndex+1))
+	return true
+}
+
+// sendSnapshot 节点落后于保留的日志时发送注册表快照，节点有响应时返回 true，调用方需持有 n.mu，发送期间释放
+func (n *raftNode) sendSnapshot(p *peer) bool {
+	term := n.term
+	n.reg.mu.RLock()
+	snap := n.reg.snapshotLocked()
+	n.reg.mu.RUnlock()
+	data, err := json.Marshal(snap)
+	if err != nil {
+		log.Printf("编码注册表快照失败: %v", err)
+		return false
+	}
+	req := &v1.SnapshotRequest{Term: term, LeaderId: n.id, LastIndex: snap.Seq, LastTerm: snap.Term, Data: data}
+
+	n.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), n.electionTimeout)
+	resp, err := p.cluster.InstallSnapshot(ctx, req)
+	cancel()
+	n.mu.Lock()
+
+	if err != nil {
+		return false
+	}
+	if resp.Term > n.term {
+		n.becomeFollower(resp.Term, "")
+		return true
+	}
+	if n.role != roleLeader || n.term != term || !resp.Success {
+		return true
+	}
+	log.Printf("节点 %s 已安装快照 (序号: %d)", p.id, snap.Seq)
+	p.match = max(p.match, snap.Seq)
+	p.next = p.match + 1
+	return true
+}
+
+// advanceCommit leader 提交已复制到多数节点的当前任期日志，调用方需持有 n.mu
+func (n *raftNode) advanceCommit() {
+	for index := n.lastIndex(); index > n.commitIndex; index-- {
+		// 之前任期的日志随当前任期的日志一起提交
+		if n.termAt(index) != n.term {
+			break
+		}
+		count := 1
+		for _, p := range n.peers {
+			if p.match >= index {
+				count++
+			}
+		}
+		if n.quorum(count) {
+			n.commitIndex = index
+			n.applyCommitted()
+			return
+		}
+	}
+}
+
+// applyCommitted 按顺序应用已提交的日志并通知等待的提议，之后压缩日志，调用方需持有 n.mu
+func (n *raftNode) applyCommitted() {
+	for n.applied < n.commitIndex {
+		entry := n.entries[n.applied-n.baseIndex]
+		cmd := &command{}
+		if err := json.Unmarshal(entry.Command, cmd); err != nil {
+			log.Printf("解码日志 %d 失败: %v", entry.Index, err)
+		}
+		cmd.Seq, cmd.Term = entry.Index, entry.Term
+		n.reg.applyCommitted(cmd)
+		n.applied = entry.Index
+
+		if w, ok := n.waiters[entry.Index]; ok {
+			if w.term == entry.Term {
+				w.done <- nil
+			} else {
+				w.done <- errNotLeader
+			}
+			delete(n.waiters, entry.Index)
+		}
+	}
+
+	if len(n.entries) > 2*maxLogEntries {
+		keep := n.applied - maxLogEntries
+		if keep > n.baseIndex {
+			n.baseTerm = n.termAt(keep)
+			n.entries = append([]*v1.LogEntry(nil), n.entries[keep-n.baseIndex:]...)
+			n.baseIndex = keep
+			if err := n.rewriteLog(); err != nil {
+				log.Printf("压缩复制日志失败: %v", err)
+			}
+		}
+	}
+}
+
+// RequestVote 候选者的日志不比本节点旧且本任期未投给其它节点时投票；
+// 本节点最近仍能收到 leader 的消息时拒绝投票，不让连不上 leader 的节点打断集群
+func (n *raftNode) RequestVote(ctx context.Context, req *v1.VoteRequest) (*v1.VoteResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	lastIndex := n.lastIndex()
+	lastTerm := n.termAt(lastIndex)
+	upToDate := req.LastLogTerm > lastTerm || (req.LastLogTerm == lastTerm && req.LastLogIndex >= lastIndex)
+	hasLeader := n.role == roleLeader || (n.leaderID != "" && time.Since(n.lastContact) < n.electionTimeout)
+	if req.PreVote {
+		return &v1.VoteResponse{Term: n.term, Granted: req.Term > n.term && upToDate && !hasLeader}, nil
+	}
+	if hasLeader && req.Term > n.term {
+		return &v1.VoteResponse{Term: n.term}, nil
+	}
+
+	if req.Term > n.term {
+		n.becomeFollower(req.Term, "")
+	}
+	granted := false
+	if req.Term == n.term && (n.votedFor == "" || n.votedFor == req.CandidateId) && upToDate {
+		granted = true
+		n.votedFor = req.CandidateId
+		n.persist()
+		n.resetDeadline()
+	}
+	return &v1.VoteResponse{Term: n.term, Granted: granted}, nil
+}
+
+// AppendEntries 接收 leader 的日志，与本地冲突的日志以 leader 为准
+func (n *raftNode) AppendEntries(ctx context.Context, req *v1.AppendRequest) (*v1.AppendResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if req.Term < n.term {
+		return &v1.AppendResponse{Term: n.term, LastIndex: n.lastIndex()}, nil
+	}
+	if req.Term > n.term || n.role != roleFollower || n.leaderID != req.LeaderId {
+		n.becomeFollower(req.Term, req.LeaderId)
+	}
+	n.resetDeadline()
+	n.lastContact = time.Now()
+
+	// 前一条日志不匹配时，已提交的日志一定与 leader 一致，从提交位置之后重发
+	if req.PrevLogIndex > n.lastIndex() ||
+		(req.PrevLogIndex >= n.baseIndex && n.termAt(req.PrevLogIndex) != req.PrevLogTerm) {
+		return &v1.AppendResponse{Term: n.term, LastIndex: min(n.commitIndex, n.lastIndex())}, nil
+	}
+	// 新的日志落盘后才确认，leader 会把确认计入多数；落盘失败时撤销，由 leader 重试
+	start := -1
+	for _, entry := range req.Entries {
+		if entry.Index <= n.baseIndex {
+			continue
+		}
+		if entry.Index <= n.lastIndex() {
+			if n.termAt(entry.Index) == entry.Term {
+				continue
+			}
+			n.entries = n.entries[:entry.Index-n.baseIndex-1]
+		}
+		if start < 0 {
+			start = len(n.entries)
+		}
+		n.entries = append(n.entries, entry)
+	}
+	if start >= 0 {
+		if err := n.appendLog(n.entries[start:]); err != nil {
+			n.entries = n.entries[:start]
+			log.Printf("写入复制日志失败: %v", err)
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+	}
+	if last := req.PrevLogIndex + int64(len(req.Entries)); req.LeaderCommit > n.commitIndex {
+		n.commitIndex = max(n.commitIndex, min(req.LeaderCommit, last))
+		n.applyCommitted()
+	}
+	return &v1.AppendResponse{Term: n.term, Success: true, LastIndex: n.lastIndex()}, nil
+}
+
+// InstallSnapshot 用 leader 的注册表快照替换本地状态
+func (n *raftNode) InstallSnapshot(ctx context.Context, req *v1.SnapshotRequest) (*v1.AppendResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if req.Term < n.term {
+		return &v1.AppendResponse{Term: n.term, LastIndex: n.lastIndex()}, nil
+	}
+	if req.Term > n.term || n.role != roleFollower || n.leaderID != req.LeaderId {
+		n.becomeFollower(req.Term, req.LeaderId)
+	}
+	n.resetDeadline()
+	n.lastContact = time.Now()
+	if req.LastIndex <= n.applied {
+		return &v1.AppendResponse{Term: n.term, Success: true, LastIndex: n.lastIndex()}, nil
+	}
+
+	snap := &snapshot{}
+	if err := json.Unmarshal(req.Data, snap); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid registry snapshot: %v", err)
+	}
+	n.reg.install(snap)
+	n.entries = nil
+	n.baseIndex, n.baseTerm = req.LastIndex, req.LastTerm
+	n.commitIndex, n.applied = req.LastIndex, req.LastIndex
+	if err := n.rewriteLog(); err != nil {
+		log.Printf("清空复制日志失败: %v", err)
+	}
+	log.Printf("节点 %s 已从 leader %s 安装快照 (序号: %d)", n.id, req.LeaderId, req.LastIndex)
+	return &v1.AppendResponse{Term: n.term, Success: true, LastIndex: n.lastIndex()}, nil
+}
+
+// Status 查询节点的集群状态
+func (n *raftNode) Status(ctx context.Context, _ *emptypb.Empty) (*v1.ClusterStatus, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return &v1.ClusterStatus{
+		Id:           n.id,
+		Role:         n.role,
+		Term:         n.term,
+		LeaderId:     n.leaderID,
+		CommitIndex:  n.commitIndex,
+		AppliedIndex: n.applied,
+	}, nil
+}
+
+// leaderClient 本节点不是 leader 时返回 leader 的客户端，还没有选出 leader 时返回错误
+func (n *raftNode) leaderClient() (v1.RegistryClient, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.role == roleLeader {
+		return nil, nil
+	}
+	p, ok := n.peers[n.leaderID]
+	if !ok {
+		return nil, status.Error(codes.Unavailable, "no registry leader elected")
+	}
+	return p.registry, nil
+}
+
+// isLeader 本节点是否为 leader
+func (n *raftNode) isLeader() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.role == roleLeader
+}
+
+// canSweep 只有 leader 剔除过期实例，新 leader 当选后先等待 grace，
+// 给选举期间续租失败的服务重新续租的时间
+func (n *raftNode) canSweep() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.role == roleLeader && time.Since(n.leaderSince) >= n.grace
+}
+
+// lastIndex 最后一条日志的序号，调用方需持有 n.mu
+func (n *raftNode) lastIndex() int64 {
+	return n.baseIndex + int64(len(n.entries))
+}
+
+// termAt 日志所在的任期，日志已压缩或不存在时返回 -1，调用方需持有 n.mu
+func (n *raftNode) termAt(index int64) int64 {
+	switch {
+	case index == n.baseIndex:
+		return n.baseTerm
+	case index < n.baseIndex || index > n.lastIndex():
+		return -1
+	default:
+		return n.entries[index-n.baseIndex-1].Term
+	}
+}
+
+// quorum 票数或副本数是否超过半数
+func (n *raftNode) quorum(count int) bool {
+	return count*2 > len(n.peers)+1
+}
+
+// resetDeadline 重置选举超时，超时在 [1, 2) 倍 electionTimeout 之间随机，调用方需持有 n.mu
+func (n *raftNode) resetDeadline() {
+	n.deadline = time.Now().Add(n.electionTimeout + time.Duration(rand.Int63n(int64(n.electionTimeout))))
+}
+
+// persist 保存任期与投票，调用方需持有 n.mu
+func (n *raftNode) persist() {
+	data, _ := json.Marshal(raftState{Term: n.term, VotedFor: n.votedFor})
+	tmp := filepath.Join(n.dir, "."+raftStateFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		log.Printf("保存选举状态失败: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, filepath.Join(n.dir, raftStateFile)); err != nil {
+		log.Printf("保存选举状态失败: %v", err)
+	}
+}
+
+// install 用 leader 的快照替换注册表，Watch 客户端随后收到全量快照
+func (r *registry) install(snap *snapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.load(snap)
+	r.events = nil
+	close(r.notify)
+	r.notify = make(chan struct{})
+	if r.store != nil {
+		if err := r.store.save(snap); err != nil {
+			log.Printf("保存注册表快照失败: %v", err)
+		}
+	}
+}
+
+// peerIDs 按 ID 排序的集群节点，用于日志输出
+func peerIDs(peers map[string]string) []string {
+	ids := make([]string, 0, len(peers))
+	for id := range peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,397 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "zflow/api/registry"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// testNet 进程内的集群网络，节点之间直接调用对方的 Cluster 服务，可以断开单个节点
+type testNet struct {
+	mu    sync.Mutex
+	nodes map[string]*raftNode
+	down  map[string]bool
+}
+
+func (tn *testNet) setDown(id string, down bool) {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+	tn.down[id] = down
+}
+
+func (tn *testNet) isDown(id string) bool {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+	return tn.down[id]
+}
+
+// target 返回 from 到 to 的连接另一端，任一端断开时返回错误
+func (tn *testNet) target(from, to string) (*raftNode, error) {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+	if tn.down[from] || tn.down[to] {
+		return nil, status.Error(codes.Unavailable, "node is down")
+	}
+	return tn.nodes[to], nil
+}
+
+// testClient 实现 v1.ClusterClient，from 为发起调用的节点
+type testClient struct {
+	net      *testNet
+	from, to string
+}
+
+func (c testClient) RequestVote(ctx context.Context, in *v1.VoteRequest, _ ...grpc.CallOption) (*v1.VoteResponse, error) {
+	n, err := c.net.target(c.from, c.to)
+	if err != nil {
+		return nil, err
+	}
+	return n.RequestVote(ctx, in)
+}
+
+func (c testClient) AppendEntries(ctx context.Context, in *v1.AppendRequest, _ ...grpc.CallOption) (*v1.AppendResponse, error) {
+	n, err := c.net.target(c.from, c.to)
+	if err != nil {
+		return nil, err
+	}
+	return n.AppendEntries(ctx, in)
+}
+
+func (c testClient) InstallSnapshot(ctx context.Context, in *v1.SnapshotRequest, _ ...grpc.CallOption) (*v1.AppendResponse, error) {
+	n, err := c.net.target(c.from, c.to)
+	if err != nil {
+		return nil, err
+	}
+	return n.InstallSnapshot(ctx, in)
+}
+
+func (c testClient) Status(ctx context.Context, in *emptypb.Empty, _ ...grpc.CallOption) (*v1.ClusterStatus, error) {
+	n, err := c.net.target(c.from, c.to)
+	if err != nil {
+		return nil, err
+	}
+	return n.Status(ctx, in)
+}
+
+// newTestNode 创建不连接网络、不启动协程的集群节点，数据目录为临时目录
+func newTestNode(t *testing.T, id string, peers ...string) *raftNode {
+	t.Helper()
+	return openTestNode(t, newTestRegistry(), t.TempDir(), id, peers...)
+}
+
+// openTestNode 以 reg 与 dir 创建集群节点，用于模拟节点重启
+func openTestNode(t *testing.T, reg *registry, dir, id string, peers ...string) *raftNode {
+	t.Helper()
+	n := &raftNode{
+		id:              id,
+		reg:             reg,
+		peers:           make(map[string]*peer),
+		dir:             dir,
+		electionTimeout: 100 * time.Millisecond,
+		heartbeat:       20 * time.Millisecond,
+		role:            roleFollower,
+		waiters:         make(map[int64]*waiter),
+	}
+	if err := n.load(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { n.logFile.Close() })
+	n.reg.raft = n
+	for _, p := range peers {
+		n.peers[p] = &peer{id: p, wake: make(chan struct{}, 1)}
+	}
+	n.resetDeadline()
+	return n
+}
+
+// newTestCluster 启动由 ids 组成的进程内集群，测试结束时断开所有节点，避免删除数据目录时仍在写入
+func newTestCluster(t *testing.T, ids ...string) *testNet {
+	tn := &testNet{nodes: make(map[string]*raftNode), down: make(map[string]bool)}
+	for _, id := range ids {
+		var others []string
+		for _, other := range ids {
+			if other != id {
+				others = append(others, other)
+			}
+		}
+		n := newTestNode(t, id, others...)
+		for _, p := range n.peers {
+			p.cluster = testClient{net: tn, from: id, to: p.id}
+		}
+		tn.nodes[id] = n
+	}
+	t.Cleanup(func() {
+		for id := range tn.nodes {
+			tn.setDown(id, true)
+		}
+		time.Sleep(100 * time.Millisecond)
+	})
+	for _, n := range tn.nodes {
+		go n.run()
+		for _, p := range n.peers {
+			go n.replicate(p)
+		}
+	}
+	return tn
+}
+
+// waitLeader 等待在线节点中选出唯一的 leader，且其它在线节点都跟随它
+func waitLeader(t *testing.T, tn *testNet) *raftNode {
+	t.Helper()
+	var leader *raftNode
+	waitFor(t, "leader elected", func() bool {
+		leader = nil
+		statuses := make(map[string]*v1.ClusterStatus)
+		for id, n := range tn.nodes {
+			if tn.isDown(id) {
+				continue
+			}
+			s, _ := n.Status(context.Background(), nil)
+			statuses[id] = s
+			if s.Role == roleLeader {
+				if leader != nil {
+					return false
+				}
+				leader = n
+			}
+		}
+		if leader == nil {
+			return false
+		}
+		for _, s := range statuses {
+			if s.LeaderId != leader.id {
+				return false
+			}
+		}
+		return true
+	})
+	return leader
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func commitRegister(t *testing.T, n *raftNode, id string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := n.reg.commit(ctx, registerCmd(0, id, time.Now().Add(time.Minute))); err != nil {
+		t.Fatalf("commit(%s) error = %v", id, err)
+	}
+}
+
+// waitApplied 等待节点应用了实例 id
+func waitApplied(t *testing.T, n *raftNode, id string) {
+	t.Helper()
+	waitFor(t, fmt.Sprintf("%s to apply %s", n.id, id), func() bool {
+		_, ok := n.reg.lookup("svc", id)
+		return ok
+	})
+}
+
+func TestRaftReplicatesToAllNodes(t *testing.T) {
+	tn := newTestCluster(t, "n1", "n2", "n3")
+	leader := waitLeader(t, tn)
+
+	commitRegister(t, leader, "a")
+	// 提交返回时 leader 已经应用
+	if _, ok := leader.reg.lookup("svc", "a"); !ok {
+		t.Fatal("leader has not applied committed command")
+	}
+	for _, n := range tn.nodes {
+		waitApplied(t, n, "a")
+	}
+
+	// 跟随者拒绝提议，由调用方转发给 leader
+	for _, n := range tn.nodes {
+		if n != leader {
+			if err := n.propose(context.Background(), &command{Op: opNoop}); status.Code(err) != codes.Unavailable {
+				t.Fatalf("follower propose() error = %v, want Unavailable", err)
+			}
+			break
+		}
+	}
+}
+
+func TestRaftFailover(t *testing.T) {
+	tn := newTestCluster(t, "n1", "n2", "n3")
+	old := waitLeader(t, tn)
+	commitRegister(t, old, "a")
+	oldStatus, _ := old.Status(context.Background(), nil)
+
+	// leader 断开后剩下的两个节点选出新 leader，仍然可以提交
+	tn.setDown(old.id, true)
+	leader := waitLeader(t, tn)
+	if leader == old {
+		t.Fatal("disconnected node is still the only leader")
+	}
+	if s, _ := leader.Status(context.Background(), nil); s.Term <= oldStatus.Term {
+		t.Fatalf("new leader term = %d, want > %d", s.Term, oldStatus.Term)
+	}
+	commitRegister(t, leader, "b")
+
+	// 旧 leader 恢复后转为跟随者并追上日志
+	tn.setDown(old.id, false)
+	if got := waitLeader(t, tn); got != leader {
+		t.Fatalf("leader = %s after old leader rejoined, want %s", got.id, leader.id)
+	}
+	waitApplied(t, old, "b")
+	if _, ok := old.reg.lookup("svc", "a"); !ok {
+		t.Fatal("old leader lost committed instance a")
+	}
+}
+
+func TestRaftSnapshotCatchUp(t *testing.T) {
+	tn := newTestCluster(t, "n1", "n2", "n3")
+	leader := waitLeader(t, tn)
+	var lagging *raftNode
+	for _, n := range tn.nodes {
+		if n != leader {
+			lagging = n
+			break
+		}
+	}
+
+	// 落后的节点断开期间提交足够多的变更，leader 压缩掉它需要的日志
+	tn.setDown(lagging.id, true)
+	commitRegister(t, leader, "a")
+	for i := 0; i < 2*maxLogEntries+1; i++ {
+		if err := leader.reg.commit(context.Background(), &command{Op: opNoop}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commitRegister(t, leader, "b")
+	leader.mu.Lock()
+	compacted := leader.baseIndex > 0
+	leader.mu.Unlock()
+	if !compacted {
+		t.Fatal("leader log was not compacted")
+	}
+
+	tn.setDown(lagging.id, false)
+	waitApplied(t, lagging, "b")
+	if _, ok := lagging.reg.lookup("svc", "a"); !ok {
+		t.Fatal("snapshot did not include instance a")
+	}
+	leaderStatus, _ := leader.Status(context.Background(), nil)
+	waitFor(t, "lagging node to reach leader commit", func() bool {
+		s, _ := lagging.Status(context.Background(), nil)
+		return s.AppliedIndex >= leaderStatus.CommitIndex
+	})
+}
+
+func TestAppendEntriesReplacesConflictingEntries(t *testing.T) {
+	n := newTestNode(t, "n2", "n1")
+	entry := func(index, term int64) *v1.LogEntry {
+		return &v1.LogEntry{Index: index, Term: term, Command: []byte(`{"op":"noop"}`)}
+	}
+	// 旧 leader 在任期 1 写入 1~3，只有 1 被提交
+	resp, _ := n.AppendEntries(context.Background(), &v1.AppendRequest{Term: 1, LeaderId: "n1",
+		Entries: []*v1.LogEntry{entry(1, 1), entry(2, 1), entry(3, 1)}, LeaderCommit: 1})
+	if !resp.Success || n.applied != 1 {
+		t.Fatalf("append = %+v, applied = %d", resp, n.applied)
+	}
+
+	// 前一条日志任期不匹配时拒绝，并从提交位置之后重发
+	resp, _ = n.AppendEntries(context.Background(), &v1.AppendRequest{Term: 2, LeaderId: "n1", PrevLogIndex: 3, PrevLogTerm: 2})
+	if resp.Success || resp.LastIndex != 1 {
+		t.Fatalf("mismatched append = %+v, want rejected with last index 1", resp)
+	}
+
+	// 新 leader 的日志覆盖冲突的 2、3
+	resp, _ = n.AppendEntries(context.Background(), &v1.AppendRequest{Term: 2, LeaderId: "n1", PrevLogIndex: 1, PrevLogTerm: 1,
+		Entries: []*v1.LogEntry{entry(2, 2)}, LeaderCommit: 2})
+	if !resp.Success || n.lastIndex() != 2 || n.termAt(2) != 2 || n.applied != 2 {
+		t.Fatalf("append = %+v, last = %d, term(2) = %d, applied = %d", resp, n.lastIndex(), n.termAt(2), n.applied)
+	}
+
+	// 过期任期的请求被拒绝
+	if resp, _ = n.AppendEntries(context.Background(), &v1.AppendRequest{Term: 1, LeaderId: "n3"}); resp.Success || resp.Term != 2 {
+		t.Fatalf("stale append = %+v", resp)
+	}
+}
+
+func TestRequestVote(t *testing.T) {
+	n := newTestNode(t, "n1", "n2", "n3")
+	n.AppendEntries(context.Background(), &v1.AppendRequest{Term: 2, LeaderId: "n2",
+		Entries: []*v1.LogEntry{{Index: 1, Term: 2, Command: []byte(`{"op":"noop"}`)}}})
+
+	// 最近收到过 leader 的消息，拒绝投票
+	if resp, _ := n.RequestVote(context.Background(), &v1.VoteRequest{Term: 3, CandidateId: "n3", LastLogIndex: 1, LastLogTerm: 2}); resp.Granted {
+		t.Fatal("granted vote while leader is alive")
+	}
+	n.mu.Lock()
+	n.lastContact = time.Now().Add(-time.Minute)
+	n.mu.Unlock()
+
+	tests := []struct {
+		name string
+		req  *v1.VoteRequest
+		want bool
+	}{
+		{"预投票不改变任期", &v1.VoteRequest{Term: 3, CandidateId: "n3", LastLogIndex: 1, LastLogTerm: 2, PreVote: true}, true},
+		{"候选者日志较旧", &v1.VoteRequest{Term: 3, CandidateId: "n3", LastLogIndex: 5, LastLogTerm: 1}, false},
+		{"投票", &v1.VoteRequest{Term: 3, CandidateId: "n3", LastLogIndex: 1, LastLogTerm: 2}, true},
+		{"同一任期不投给其它候选者", &v1.VoteRequest{Term: 3, CandidateId: "n2", LastLogIndex: 1, LastLogTerm: 2}, false},
+		{"重复请求", &v1.VoteRequest{Term: 3, CandidateId: "n3", LastLogIndex: 1, LastLogTerm: 2}, true},
+	}
+	for _, tt := range tests {
+		resp, _ := n.RequestVote(context.Background(), tt.req)
+		if resp.Granted != tt.want {
+			t.Fatalf("%s: granted = %v, want %v", tt.name, resp.Granted, tt.want)
+		}
+	}
+	if n.term != 3 || n.votedFor != "n3" {
+		t.Fatalf("term = %d, votedFor = %s", n.term, n.votedFor)
+	}
+}
+
+func TestAppendEntriesSurvivesRestart(t *testing.T) {
+	reg, dir := newTestRegistry(), t.TempDir()
+	n := openTestNode(t, reg, dir, "n2", "n1")
+	entry := func(index, term int64) *v1.LogEntry {
+		return &v1.LogEntry{Index: index, Term: term, Command: []byte(`{"op":"noop"}`)}
+	}
+	// 确认但尚未提交的日志，其中 2、3 随后被新 leader 覆盖
+	n.AppendEntries(context.Background(), &v1.AppendRequest{Term: 1, LeaderId: "n1",
+		Entries: []*v1.LogEntry{entry(1, 1), entry(2, 1), entry(3, 1)}})
+	resp, _ := n.AppendEntries(context.Background(), &v1.AppendRequest{Term: 2, LeaderId: "n1", PrevLogIndex: 1, PrevLogTerm: 1,
+		Entries: []*v1.LogEntry{entry(2, 2)}})
+	if !resp.Success {
+		t.Fatalf("append = %+v", resp)
+	}
+	n.logFile.Close()
+
+	restarted := openTestNode(t, reg, dir, "n2", "n1")
+	if restarted.lastIndex() != 2 || restarted.termAt(1) != 1 || restarted.termAt(2) != 2 {
+		t.Fatalf("after restart: last = %d, term(1) = %d, term(2) = %d", restarted.lastIndex(), restarted.termAt(1), restarted.termAt(2))
+	}
+	if restarted.term != 2 || restarted.applied != 0 {
+		t.Fatalf("after restart: term = %d, applied = %d", restarted.term, restarted.applied)
+	}
+}
+
+func TestNewRaftNodeRequiresDataDir(t *testing.T) {
+	_, err := newRaftNode(newTestRegistry(), Options{NodeID: "n1", Peers: map[string]string{"n1": "127.0.0.1:0"}})
+	if err == nil || !strings.Contains(err.Error(), "data dir") {
+		t.Fatalf("newRaftNode() error = %v, want data dir required", err)
+	}
+}
@@ -2,23 +2,45 @@ package core
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"sync"
 	"time"
 
 	v1 "zflow/api/registry"
+	"zflow/utils/health"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// Options 注册中心配置
+type Options struct {
+	DataDir          string        // 持久化目录，为空时注册表只保存在内存中；集群模式下必须指定
+	SnapshotInterval time.Duration // 快照间隔，快照后清空预写日志
+	Grace            time.Duration // 重启后或新 leader 当选后实例至少保留这么久，等待服务继续续租
+
+	NodeID          string            // 集群模式下本节点的 ID
+	Peers           map[string]string // 集群全部节点 ID -> 地址（含本节点），为空时单机运行
+	ElectionTimeout time.Duration     // 选举超时的下限，实际超时在 [1, 2) 倍之间随机
+
+	Health health.Config // 主动健康检查，Interval 为 0 时不探测
+}
+
 // registry 注册中心
 type registry struct {
 	v1.UnimplementedRegistryServer
 	mu       sync.RWMutex
 	services map[string]map[string]*serviceEntry // name -> id -> entry
+	seq      int64                               // 最后应用的变更序号
+	seqTerm  int64                               // 最后应用的变更所在的选举任期，单机时为 0
+	store    *store                              // 为空时不持久化
+	raft     *raftNode                           // 为空时单机运行
+
+	revision int64            // 每次实例变更加一
+	events   []*v1.WatchEvent // 最近的变更事件，供 Watch 续订
+	notify   chan struct{}    // 发生变更时关闭并替换，唤醒 Watch
 }
 
 // serviceEntry 服务实例
@@ -27,10 +49,36 @@ type serviceEntry struct {
 	expire time.Time
 }
 
-// newRegistry 创建注册中心
-func NewRegistry() *registry {
+// NewRegistry 创建注册中心，配置了持久化目录时先从快照与预写日志恢复注册表
+func NewRegistry(opts Options) (*registry, error) {
 	r := &registry{
 		services: make(map[string]map[string]*serviceEntry),
+		notify:   make(chan struct{}),
+	}
+	if opts.DataDir != "" {
+		if err := r.restore(opts.DataDir, opts.Grace); err != nil {
+			return nil, err
+		}
+		if opts.SnapshotInterval <= 0 {
+			opts.SnapshotInterval = time.Minute
+		}
+		// 快照协程
+		go func() {
+			ticker := time.NewTicker(opts.SnapshotInterval)
+			for range ticker.C {
+				r.snapshot()
+			}
+		}()
+	}
+	if len(opts.Peers) > 0 {
+		raft, err := newRaftNode(r, opts)
+		if err != nil {
+			return nil, err
+		}
+		r.raft = raft
+	}
+	if opts.Health.Interval > 0 {
+		go r.checkHealth(health.NewChecker(opts.Health), opts.Health.Interval)
 	}
 	log.Printf("注册中心已启动")
 	// 清理协程
@@ -40,7 +88,7 @@ func NewRegistry() *registry {
 			r.sweep()
 		}
 	}()
-	return r
+	return r, nil
 }
 
 // Register 注册服务
@@ -48,41 +96,57 @@ func (r *registry) Register(ctx context.Context, in *v1.ServiceInstance) (*v1.Le
 	if in.TtlSec <= 0 {
 		in.TtlSec = 10
 	}
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	grp, ok := r.services[in.Name]
-	if !ok {
-		grp = make(map[string]*serviceEntry)
-		r.services[in.Name] = grp
+	// 健康状态由注册中心探测，不接受服务自己上报
+	in.Health = v1.HealthStatus_HEALTH_UNKNOWN
+	if leader, err := r.leader(); err != nil || leader != nil {
+		if err != nil {
+			return nil, err
+		}
+		return leader.Register(ctx, in)
+	}
+	expire := time.Now().Add(time.Duration(in.TtlSec) * time.Second)
+	if err := r.commit(ctx, &command{Op: opRegister, Name: in.Name, ID: in.Id, Instance: in, Expire: expire.UnixMilli()}); err != nil {
+		log.Printf("服务注册失败: %s (ID: %s) - %v", in.Name, in.Id, err)
+		return nil, err
 	}
-	grp[in.Id] = &serviceEntry{inst: in, expire: time.Now().Add(time.Duration(in.TtlSec) * time.Second)}
 	log.Printf("服务注册成功: %s (ID: %s, 地址: %s, TTL: %d秒)", in.Name, in.Id, in.Addr, in.TtlSec)
 	return lease(in), nil
 }
 
 // Deregister 注销服务
 func (r *registry) Deregister(ctx context.Context, l *v1.Lease) (*emptypb.Empty, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if grp, ok := r.services[l.Name]; ok {
-		if _, exists := grp[l.Id]; exists {
-			delete(grp, l.Id)
-			log.Printf("服务注销成功: %s (ID: %s)", l.Name, l.Id)
+	if leader, err := r.leader(); err != nil || leader != nil {
+		if err != nil {
+			return nil, err
 		}
+		return leader.Deregister(ctx, l)
+	}
+	if _, exists := r.lookup(l.Name, l.Id); exists {
+		if err := r.commit(ctx, &command{Op: opRemove, Name: l.Name, ID: l.Id}); err != nil {
+			log.Printf("服务注销失败: %s (ID: %s) - %v", l.Name, l.Id, err)
+			return nil, err
+		}
+		log.Printf("服务注销成功: %s (ID: %s)", l.Name, l.Id)
 	}
 	return &emptypb.Empty{}, nil
 }
 
 // KeepAlive 续租
 func (r *registry) KeepAlive(ctx context.Context, l *v1.Lease) (*v1.Lease, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if grp, ok := r.services[l.Name]; ok {
-		if e, ok := grp[l.Id]; ok {
-			e.expire = time.Now().Add(time.Duration(e.inst.TtlSec) * time.Second)
-			log.Printf("服务续租成功: %s (ID: %s, 过期时间: %s)", l.Name, l.Id, e.expire.Format(time.RFC3339))
-			return lease(e.inst), nil
+	if leader, err := r.leader(); err != nil || leader != nil {
+		if err != nil {
+			return nil, err
 		}
+		return leader.KeepAlive(ctx, l)
+	}
+	if inst, exists := r.lookup(l.Name, l.Id); exists {
+		expire := time.Now().Add(time.Duration(inst.TtlSec) * time.Second)
+		if err := r.commit(ctx, &command{Op: opRenew, Name: l.Name, ID: l.Id, Expire: expire.UnixMilli()}); err != nil {
+			log.Printf("服务续租失败: %s (ID: %s) - %v", l.Name, l.Id, err)
+			return nil, err
+		}
+		log.Printf("服务续租成功: %s (ID: %s, 过期时间: %s)", l.Name, l.Id, expire.Format(time.RFC3339))
+		return lease(inst), nil
 	}
 	log.Printf("服务续租失败: %s (ID: %s) - 实例未找到", l.Name, l.Id)
 	return nil, status.Error(codes.NotFound, "instance not found")
@@ -94,60 +158,156 @@ func (r *registry) Discover(ctx context.Context, q *v1.Query) (*v1.Services, err
 	defer r.mu.RUnlock()
 	instances := r.clone(q.Name)
 	log.Printf("服务发现: %s - 找到 %d 个实例", q.Name, len(instances))
-	return &v1.Services{Instances: instances}, nil
+	return &v1.Services{Instances: instances, Revision: r.revision}, nil
 }
 
-// Watch 监听服务
-func (r *registry) Watch(q *v1.Query, stream v1.Registry_WatchServer) error {
-	log.Printf("开始监听服务: %s", q.Name)
-	// 轮询推送
-	// TODO：可以优化为 notify chan
-	ticker := time.NewTicker(time.Second * 5)
-	last := ""
-	for {
-		select {
-		case <-ticker.C:
-			list := r.clone(q.Name)
-			cur, _ := json.Marshal(list)
-			if string(cur) != last { // 变更才推送
-				if err := stream.Send(&v1.Services{Instances: list}); err != nil {
-					log.Printf("服务监听推送失败: %s - %v", q.Name, err)
-					return err
-				}
-				log.Printf("服务监听推送: %s - %d 个实例", q.Name, len(list))
-				last = string(cur)
+// sweep 定时剔除过期实例，集群模式下只由 leader 剔除
+func (r *registry) sweep() {
+	if r.raft != nil && !r.raft.canSweep() {
+		return
+	}
+	now := time.Now()
+	type expiredEntry struct {
+		name, id string
+		expire   time.Time
+	}
+	var expired []expiredEntry
+	r.mu.RLock()
+	for _, grp := range r.services {
+		for _, e := range grp {
+			if e.expire.Before(now) {
+				expired = append(expired, expiredEntry{e.inst.Name, e.inst.Id, e.expire})
 			}
-		case <-stream.Context().Done():
-			log.Printf("服务监听结束: %s", q.Name)
-			return nil
 		}
 	}
-}
+	r.mu.RUnlock()
 
-// sweep 定时剔除过期实例
-func (r *registry) sweep() {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	now := time.Now()
 	expiredCount := 0
-	for n, grp := range r.services {
-		for id, e := range grp {
-			if e.expire.Before(now) {
-				delete(grp, id)
-				expiredCount++
-				log.Printf("清理过期服务: %s (ID: %s, 过期时间: %s)", n, id, e.expire.Format(time.RFC3339))
-			}
+	for _, e := range expired {
+		n, id := e.name, e.id
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		// 带上扫描时看到的到期时间，提交前实例已续租或重新注册时 apply 不再剔除
+		err := r.commit(ctx, &command{Op: opRemove, Name: n, ID: id, Expired: true, Expire: e.expire.UnixMilli()})
+		cancel()
+		if err != nil {
+			// 下一轮清理时重试
+			log.Printf("清理过期服务失败: %s (ID: %s) - %v", n, id, err)
+			continue
 		}
-		if len(grp) == 0 {
-			delete(r.services, n)
-			log.Printf("清理空服务组: %s", n)
+		if _, exists := r.lookup(n, id); exists {
+			log.Printf("服务已续租，不再清理: %s (ID: %s)", n, id)
+			continue
 		}
+		expiredCount++
+		log.Printf("清理过期服务: %s (ID: %s, 过期时间: %s)", n, id, e.expire.Format(time.RFC3339))
 	}
 	if expiredCount > 0 {
 		log.Printf("清理完成: 共清理 %d 个过期实例", expiredCount)
 	}
 }
 
+// lookup 查找实例
+func (r *registry) lookup(name, id string) (*v1.ServiceInstance, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.services[name][id]
+	if !ok {
+		return nil, false
+	}
+	return e.inst, true
+}
+
+// leader 集群模式下本节点不是 leader 时返回 leader 的客户端，由 leader 处理写请求；
+// 单机运行或本节点就是 leader 时返回 nil
+func (r *registry) leader() (v1.RegistryClient, error) {
+	if r.raft == nil {
+		return nil, nil
+	}
+	return r.raft.leaderClient()
+}
+
+// commit 提交一次变更：单机时写入预写日志后直接应用，集群模式下复制到多数节点后应用
+func (r *registry) commit(ctx context.Context, cmd *command) error {
+	if r.raft != nil {
+		return r.raft.propose(ctx, cmd)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cmd.Seq = r.seq + 1
+	if r.store != nil {
+		if err := r.store.append(cmd); err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+	}
+	r.apply(cmd)
+	return nil
+}
+
+// applyCommitted 应用集群中已提交的变更，预写日志写入失败时仍然应用，保持与其它节点一致
+func (r *registry) applyCommitted(cmd *command) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cmd.Seq <= r.seq {
+		return
+	}
+	if r.store != nil {
+		if err := r.store.append(cmd); err != nil {
+			log.Printf("写入预写日志失败: %v", err)
+		}
+	}
+	r.apply(cmd)
+}
+
+// apply 将变更应用到注册表并记录 Watch 事件，调用方需持有 r.mu 写锁
+func (r *registry) apply(cmd *command) {
+	r.seq, r.seqTerm = cmd.Seq, cmd.Term
+	switch cmd.Op {
+	case opRegister:
+		grp, ok := r.services[cmd.Name]
+		if !ok {
+			grp = make(map[string]*serviceEntry)
+			r.services[cmd.Name] = grp
+		}
+		inst := cmd.Instance
+		old, existed := grp[cmd.ID]
+		// 重复注册保留已探测的健康状态
+		if existed && old.inst.Health != inst.Health {
+			inst = proto.Clone(inst).(*v1.ServiceInstance)
+			inst.Health = old.inst.Health
+		}
+		grp[cmd.ID] = &serviceEntry{inst: inst, expire: time.UnixMilli(cmd.Expire)}
+		// 重复注册且实例信息不变时只相当于续租，不产生事件
+		if !existed {
+			r.record(v1.EventType_ADDED, inst)
+		} else if !proto.Equal(old.inst, inst) {
+			r.record(v1.EventType_UPDATED, inst)
+		}
+	case opRenew:
+		if e, ok := r.services[cmd.Name][cmd.ID]; ok {
+			e.expire = time.UnixMilli(cmd.Expire)
+		}
+	case opHealth:
+		// 实例会被 Watch 推送出去，不在原对象上修改
+		if e, ok := r.services[cmd.Name][cmd.ID]; ok && e.inst.Health != cmd.Health {
+			inst := proto.Clone(e.inst).(*v1.ServiceInstance)
+			inst.Health = cmd.Health
+			e.inst = inst
+			r.record(v1.EventType_UPDATED, inst)
+		}
+	case opRemove:
+		grp := r.services[cmd.Name]
+		e, ok := grp[cmd.ID]
+		if !ok || (cmd.Expired && e.expire.UnixMilli() > cmd.Expire) {
+			return
+		}
+		delete(grp, cmd.ID)
+		if len(grp) == 0 {
+			delete(r.services, cmd.Name)
+		}
+		r.record(v1.EventType_REMOVED, e.inst)
+	}
+}
+
 // 复制一份快照
 func (r *registry) clone(name string) []*v1.ServiceInstance {
 	var out []*v1.ServiceInstance
@@ -1,4 +0,0 @@
-package core
-
-// 注册中心地址
-var SERVICE_REGISTRY_ADDR string = "127.0.0.1:50051"
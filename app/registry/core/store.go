@@ -0,0 +1,250 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	v1 "zflow/api/registry"
+)
+
+// 持久化目录中的文件
+const (
+	walFile      = "wal.log"       // 预写日志，每行一条 JSON 编码的 command
+	snapshotFile = "snapshot.json" // 最近一次快照
+)
+
+// 注册表变更操作
+const (
+	opRegister = "register" // 注册或重新注册实例
+	opRenew    = "renew"    // 续租
+	opRemove   = "remove"   // 注销或过期剔除
+	opHealth   = "health"   // 主动健康检查的状态变化
+	opNoop     = "noop"     // 集群新 leader 当选时写入的空变更
+)
+
+// command 一次注册表变更，先写入预写日志再应用到内存
+type command struct {
+	Seq      int64               `json:"seq"`                // 变更序号，单调递增，集群模式下为复制日志的序号
+	Term     int64               `json:"term,omitempty"`     // 集群模式下写入日志时的选举任期
+	Op       string              `json:"op"`                 // register / renew / remove / health / noop
+	Name     string              `json:"name"`               // 服务名
+	ID       string              `json:"id"`                 // 实例 ID
+	Instance *v1.ServiceInstance `json:"instance,omitempty"` // op 为 register 时的实例
+	Expire   int64               `json:"expire,omitempty"`   // 租约到期时间，Unix 毫秒；过期剔除时为扫描时看到的到期时间
+	Expired  bool                `json:"expired,omitempty"`  // op 为 remove 时是否因过期剔除，此后已续租的实例不剔除
+	Health   v1.HealthStatus     `json:"health,omitempty"`   // op 为 health 时的新状态
+}
+
+// snapshot 注册表快照，Seq 之前的变更都已包含在内
+type snapshot struct {
+	Seq       int64           `json:"seq"`
+	Term      int64           `json:"term,omitempty"` // Seq 对应变更的选举任期
+	Revision  int64           `json:"revision"`
+	Instances []snapshotEntry `json:"instances"`
+}
+
+// snapshotEntry 快照中的实例与租约到期时间
+type snapshotEntry struct {
+	Instance *v1.ServiceInstance `json:"instance"`
+	Expire   int64               `json:"expire"` // Unix 毫秒
+}
+
+// store 注册表的预写日志与快照，方法由 registry 在持有 r.mu 时调用
+type store struct {
+	dir     string
+	wal     *os.File
+	pending int // 上次快照后写入的日志条数
+}
+
+// openStore 打开持久化目录，返回其中的快照与快照之后的变更
+func openStore(dir string) (*store, *snapshot, []*command, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create registry data dir: %v", err)
+	}
+	s := &store{dir: dir}
+
+	snap := &snapshot{}
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil, fmt.Errorf("failed to read registry snapshot: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, snap); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to decode registry snapshot: %v", err)
+		}
+	}
+
+	cmds, err := readWAL(filepath.Join(dir, walFile), snap.Seq)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	s.wal, err = os.OpenFile(filepath.Join(dir, walFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open registry wal: %v", err)
+	}
+	s.pending = len(cmds)
+	return s, snap, cmds, nil
+}
+
+// readWAL 读取序号大于 after 的变更；最后一行不完整时视为写入中断，忽略该行
+func readWAL(path string, after int64) ([]*command, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry wal: %v", err)
+	}
+	defer f.Close()
+
+	var cmds []*command
+	var broken error
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if broken != nil {
+			// 损坏的行之后还有内容，不是写入中断
+			return nil, broken
+		}
+		cmd := &command{}
+		if err := json.Unmarshal(scanner.Bytes(), cmd); err != nil {
+			broken = fmt.Errorf("registry wal line %d is corrupted: %v", line, err)
+			continue
+		}
+		if cmd.Seq > after {
+			cmds = append(cmds, cmd)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read registry wal: %v", err)
+	}
+	if broken != nil {
+		log.Printf("忽略预写日志末尾不完整的记录: %v", broken)
+	}
+	return cmds, nil
+}
+
+// append 写入一条变更并落盘
+func (s *store) append(cmd *command) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to encode registry command: %v", err)
+	}
+	if _, err := s.wal.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write registry wal: %v", err)
+	}
+	if err := s.wal.Sync(); err != nil {
+		return fmt.Errorf("failed to sync registry wal: %v", err)
+	}
+	s.pending++
+	return nil
+}
+
+// save 先写临时文件再改名保存快照，然后清空预写日志。
+// 改名后、清空前中断时，重放会按序号跳过快照已包含的变更
+func (s *store) save(snap *snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode registry snapshot: %v", err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".snapshot-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write registry snapshot: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write registry snapshot: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, snapshotFile)); err != nil {
+		return fmt.Errorf("failed to write registry snapshot: %v", err)
+	}
+
+	if err := s.wal.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate registry wal: %v", err)
+	}
+	s.pending = 0
+	return nil
+}
+
+// restore 从持久化目录恢复注册表：载入快照、重放之后的变更，
+// 再把恢复的租约至少延长到 grace 之后，给服务在注册中心重启后续租的时间
+func (r *registry) restore(dir string, grace time.Duration) error {
+	s, snap, cmds, err := openStore(dir)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.load(snap)
+	for _, cmd := range cmds {
+		r.apply(cmd)
+	}
+
+	minExpire := time.Now().Add(grace)
+	count := 0
+	for _, grp := range r.services {
+		for _, e := range grp {
+			if e.expire.Before(minExpire) {
+				e.expire = minExpire
+			}
+			count++
+		}
+	}
+	r.store = s
+	log.Printf("注册表已恢复: %d 个实例, 重放 %d 条变更 (版本: %d)", count, len(cmds), r.revision)
+
+	// 恢复后立即快照，清空已重放的日志
+	return s.save(r.snapshotLocked())
+}
+
+// load 用快照替换注册表，调用方需持有 r.mu 写锁
+func (r *registry) load(snap *snapshot) {
+	r.seq, r.seqTerm, r.revision = snap.Seq, snap.Term, snap.Revision
+	r.services = make(map[string]map[string]*serviceEntry)
+	for _, entry := range snap.Instances {
+		inst := entry.Instance
+		if r.services[inst.Name] == nil {
+			r.services[inst.Name] = make(map[string]*serviceEntry)
+		}
+		r.services[inst.Name][inst.Id] = &serviceEntry{inst: inst, expire: time.UnixMilli(entry.Expire)}
+	}
+}
+
+// snapshot 有新的变更时保存快照
+func (r *registry) snapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.store.pending == 0 {
+		return
+	}
+	if err := r.store.save(r.snapshotLocked()); err != nil {
+		log.Printf("保存注册表快照失败: %v", err)
+		return
+	}
+	log.Printf("注册表快照已保存 (序号: %d, 版本: %d)", r.seq, r.revision)
+}
+
+// snapshotLocked 生成当前注册表的快照，调用方需持有 r.mu
+func (r *registry) snapshotLocked() *snapshot {
+	snap := &snapshot{Seq: r.seq, Term: r.seqTerm, Revision: r.revision, Instances: []snapshotEntry{}}
+	for _, grp := range r.services {
+		for _, e := range grp {
+			snap.Instances = append(snap.Instances, snapshotEntry{Instance: e.inst, Expire: e.expire.UnixMilli()})
+		}
+	}
+	return snap
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "zflow/api/registry"
+)
+
+// newTestRegistry 创建不启动后台协程的注册中心
+func newTestRegistry() *registry {
+	return &registry{
+		services: make(map[string]map[string]*serviceEntry),
+		notify:   make(chan struct{}),
+	}
+}
+
+func registerCmd(seq int64, id string, expire time.Time) *command {
+	inst := &v1.ServiceInstance{Name: "svc", Id: id, Addr: "127.0.0.1:9090", TtlSec: 10}
+	return &command{Seq: seq, Op: opRegister, Name: "svc", ID: id, Instance: inst, Expire: expire.UnixMilli()}
+}
+
+func writeWAL(t *testing.T, dir string, cmds ...*command) *store {
+	t.Helper()
+	s, _, _, err := openStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range cmds {
+		if err := s.append(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestReadWALTornTail(t *testing.T) {
+	dir := t.TempDir()
+	expire := time.Now().Add(time.Minute)
+	s := writeWAL(t, dir, registerCmd(1, "a", expire), registerCmd(2, "b", expire))
+	// 模拟写入中断：最后一行不完整
+	if _, err := s.wal.WriteString(`{"seq":3,"op":"regi`); err != nil {
+		t.Fatal(err)
+	}
+	s.wal.Close()
+
+	cmds, err := readWAL(filepath.Join(dir, walFile), 0)
+	if err != nil {
+		t.Fatalf("readWAL() error = %v", err)
+	}
+	if len(cmds) != 2 || cmds[0].ID != "a" || cmds[1].ID != "b" {
+		t.Fatalf("readWAL() = %+v", cmds)
+	}
+}
+
+func TestReadWALCorruptedMiddle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, walFile)
+	data := `{"seq":1,"op":"noop"}` + "\n" + `not json` + "\n" + `{"seq":2,"op":"noop"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readWAL(path, 0); err == nil {
+		t.Fatal("readWAL() error = nil, want corrupted line error")
+	}
+}
+
+func TestRestoreReplaysWALAfterSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	expire := time.Now().Add(time.Minute)
+	s := writeWAL(t, dir, registerCmd(1, "a", expire), registerCmd(2, "b", expire))
+
+	// 快照包含前两条变更，之后的变更只在预写日志中
+	r := newTestRegistry()
+	for _, cmd := range []*command{registerCmd(1, "a", expire), registerCmd(2, "b", expire)} {
+		r.apply(cmd)
+	}
+	if err := s.save(r.snapshotLocked()); err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range []*command{{Seq: 3, Op: opRemove, Name: "svc", ID: "a"}, registerCmd(4, "c", expire)} {
+		if err := s.append(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.wal.Close()
+
+	restored := newTestRegistry()
+	if err := restored.restore(dir, 0); err != nil {
+		t.Fatalf("restore() error = %v", err)
+	}
+	defer restored.store.wal.Close()
+	if _, ok := restored.lookup("svc", "a"); ok {
+		t.Error("instance a should have been removed by replay")
+	}
+	for _, id := range []string{"b", "c"} {
+		if _, ok := restored.lookup("svc", id); !ok {
+			t.Errorf("instance %s not restored", id)
+		}
+	}
+	if restored.seq != 4 || restored.revision != 4 {
+		t.Errorf("seq = %d, revision = %d, want 4, 4", restored.seq, restored.revision)
+	}
+	// 恢复后立即快照并清空日志
+	if info, err := os.Stat(filepath.Join(dir, walFile)); err != nil || info.Size() != 0 {
+		t.Errorf("wal not truncated after restore: %v %v", info, err)
+	}
+}
+
+func TestRestoreSkipsCommandsInSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	expire := time.Now().Add(time.Minute)
+	// 保存快照后、清空日志前中断：日志中的变更已包含在快照里
+	s := writeWAL(t, dir, registerCmd(1, "a", expire), &command{Seq: 2, Op: opRemove, Name: "svc", ID: "a"})
+	r := newTestRegistry()
+	r.apply(registerCmd(1, "a", expire))
+	r.apply(&command{Seq: 2, Op: opRemove, Name: "svc", ID: "a"})
+	data := r.snapshotLocked()
+	s.wal.Close()
+	if err := (&store{dir: dir, wal: mustOpen(t, filepath.Join(dir, "other.log"))}).save(data); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newTestRegistry()
+	if err := restored.restore(dir, 0); err != nil {
+		t.Fatalf("restore() error = %v", err)
+	}
+	defer restored.store.wal.Close()
+	if _, ok := restored.lookup("svc", "a"); ok {
+		t.Error("instance a should stay removed")
+	}
+	if restored.revision != 2 {
+		t.Errorf("revision = %d, want 2", restored.revision)
+	}
+}
+
+func TestRestoreExtendsLeasesToGrace(t *testing.T) {
+	dir := t.TempDir()
+	s := writeWAL(t, dir, registerCmd(1, "a", time.Now().Add(-time.Second)))
+	s.wal.Close()
+
+	r := newTestRegistry()
+	if err := r.restore(dir, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	defer r.store.wal.Close()
+	if e := r.services["svc"]["a"]; e == nil || time.Until(e.expire) < 50*time.Second {
+		t.Fatalf("lease not extended to grace: %+v", e)
+	}
+}
+
+func TestApplyExpiredRemoveSkipsRenewedInstance(t *testing.T) {
+	r := newTestRegistry()
+	observed := time.Now().Add(-time.Second)
+	r.apply(registerCmd(1, "a", observed))
+
+	// 清理协程扫描到过期后、提交剔除前，实例续租
+	r.apply(&command{Seq: 2, Op: opRenew, Name: "svc", ID: "a", Expire: time.Now().Add(time.Minute).UnixMilli()})
+	revision := r.revision
+	r.apply(&command{Seq: 3, Op: opRemove, Name: "svc", ID: "a", Expired: true, Expire: observed.UnixMilli()})
+	if _, ok := r.lookup("svc", "a"); !ok {
+		t.Fatal("renewed instance was removed")
+	}
+	if r.revision != revision {
+		t.Fatalf("revision = %d, want %d: spurious event", r.revision, revision)
+	}
+
+	// 没有续租时按扫描结果剔除
+	r.apply(registerCmd(4, "b", observed))
+	r.apply(&command{Seq: 5, Op: opRemove, Name: "svc", ID: "b", Expired: true, Expire: observed.UnixMilli()})
+	if _, ok := r.lookup("svc", "b"); ok {
+		t.Fatal("expired instance was not removed")
+	}
+	if last := r.events[len(r.events)-1]; last.Type != v1.EventType_REMOVED || last.Instance.Id != "b" {
+		t.Fatalf("last event = %v, want REMOVED b", last)
+	}
+}
+
+func mustOpen(t *testing.T, path string) *os.File {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
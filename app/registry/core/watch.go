@@ -0,0 +1,85 @@
+package core
+
+import (
+	"log"
+	"sort"
+
+	v1 "zflow/api/registry"
+)
+
+// maxEvents 保留的变更事件数，续订的版本早于保留范围时改为推送全量快照
+const maxEvents = 4096
+
+// record 记录一次实例变更并唤醒所有 Watch，调用方需持有 r.mu 写锁
+func (r *registry) record(typ v1.EventType, inst *v1.ServiceInstance) {
+	r.revision++
+	r.events = append(r.events, &v1.WatchEvent{Type: typ, Instance: inst, Revision: r.revision})
+	if len(r.events) > maxEvents {
+		r.events = append([]*v1.WatchEvent(nil), r.events[len(r.events)-maxEvents:]...)
+	}
+	close(r.notify)
+	r.notify = make(chan struct{})
+}
+
+// changes 返回 revision 之后与 name 相关的变更以及下一次变更的通知。
+// revision 为 0、超前于当前版本或早于保留的事件时返回全量快照；没有相关变更时 Events 为空
+func (r *registry) changes(name string, revision int64) (*v1.WatchResponse, <-chan struct{}) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// 保留的事件覆盖 (oldest, r.revision]
+	oldest := r.revision
+	if len(r.events) > 0 {
+		oldest = r.events[0].Revision - 1
+	}
+	if revision <= 0 || revision > r.revision || revision < oldest {
+		instances := r.clone(name)
+		sort.Slice(instances, func(i, j int) bool {
+			if instances[i].Name != instances[j].Name {
+				return instances[i].Name < instances[j].Name
+			}
+			return instances[i].Id < instances[j].Id
+		})
+		resp := &v1.WatchResponse{Snapshot: true, Revision: r.revision}
+		for _, inst := range instances {
+			resp.Events = append(resp.Events, &v1.WatchEvent{Type: v1.EventType_ADDED, Instance: inst, Revision: r.revision})
+		}
+		return resp, r.notify
+	}
+
+	resp := &v1.WatchResponse{Revision: r.revision}
+	// 事件版本连续，revision 之后的事件从下标 revision-oldest 开始
+	for _, ev := range r.events[revision-oldest:] {
+		if name == "" || ev.Instance.Name == name {
+			resp.Events = append(resp.Events, ev)
+		}
+	}
+	return resp, r.notify
+}
+
+// Watch 监听服务：先推送快照或续订的变更，之后每次注册、注销、过期都立即推送
+func (r *registry) Watch(q *v1.Query, stream v1.Registry_WatchServer) error {
+	log.Printf("开始监听服务: %s (版本: %d)", q.Name, q.Revision)
+	revision := q.Revision
+	for {
+		resp, notify := r.changes(q.Name, revision)
+		if resp.Snapshot || len(resp.Events) > 0 {
+			if err := stream.Send(resp); err != nil {
+				log.Printf("服务监听推送失败: %s - %v", q.Name, err)
+				return err
+			}
+			if resp.Snapshot {
+				log.Printf("服务监听推送快照: %s - %d 个实例 (版本: %d)", q.Name, len(resp.Events), resp.Revision)
+			} else {
+				log.Printf("服务监听推送变更: %s - %d 个事件 (版本: %d)", q.Name, len(resp.Events), resp.Revision)
+			}
+		}
+		revision = resp.Revision
+		select {
+		case <-notify:
+		case <-stream.Context().Done():
+			log.Printf("服务监听结束: %s", q.Name)
+			return nil
+		}
+	}
+}
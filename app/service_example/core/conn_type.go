@@ -4,7 +4,9 @@ import "zflow/app/zflow/model"
 
 // ConnTypes 定义常用的连接类型
 var ConnTypes = map[string]*model.ConnectionType{
-	"data_flow": &DataFlowConn,
+	"data_flow":    &DataFlowConn,
+	"control_flow": &ControlFlowConn,
+	"file_flow":    &FileFlowConn,
 }
 
 // DataFlowConn 数据流连接 - 用于传递普通数据
@@ -15,3 +17,21 @@ var DataFlowConn = model.ConnectionType{
 	Color:            "#4CAF50", // 绿色
 	AllowedPortTypes: []string{"connection"},
 }
+
+// ControlFlowConn 控制流连接 - 不传递数据，只决定目标节点是否执行
+var ControlFlowConn = model.ConnectionType{
+	UID:              "2",
+	Name:             model.ControlConnectionName,
+	Description:      "控制流连接，源节点输出该端口时目标节点才会执行",
+	Color:            "#FF0000", // 红色
+	AllowedPortTypes: []string{"connection"},
+}
+
+// FileFlowConn 文件流连接 - 用于传递文件，大文件以 blob 引用传递
+var FileFlowConn = model.ConnectionType{
+	UID:              "3",
+	Name:             "file_flow",
+	Description:      "文件流连接，用于传递文件，大文件以 blob 引用传递",
+	Color:            "#2196F3", // 蓝色
+	AllowedPortTypes: []string{"file"},
+}
@@ -11,28 +11,44 @@ var (
 	AddNodeTypeUID  = "add"
 	MulNodeTypeUID  = "mul"
 	EchoNodeTypeUID = "echo"
+	IfNodeTypeUID   = "if"
+	RepeatNodeUID   = "repeat"
+	SizeNodeUID     = "size"
 )
 
 // NodeTypes 定义节点类型
 var NodeTypes = map[string]*model.NodeType{
-	"add":  &AddNodeType,
-	"mul":  &MulNodeType,
-	"echo": &EchoNodeType,
+	"add":    &AddNodeType,
+	"mul":    &MulNodeType,
+	"echo":   &EchoNodeType,
+	"if":     &IfNodeType,
+	"repeat": &RepeatNodeType,
+	"size":   &SizeNodeType,
 }
 
+// intType 整数端口的数据类型
+var intType = model.DataType{Kind: model.DataKindInt}
+
+// textFileType 文本文件端口的数据类型
+var textFileType = model.DataType{Kind: model.DataKindFile, MIME: "text/plain"}
+
+// pureCache 纯计算节点的缓存策略，实现变化时修改版本
+var pureCache = model.CachePolicy{Version: "1"}
+
 // AddNodeType 加法节点
 var AddNodeType = model.NodeType{
 	UID:       fmt.Sprintf("%s.add", ServiceName),
 	Category:  "math",
 	Note:      "两个数字相加，输出结果",
 	Operation: AddOperationInst, // 这里你可以填入具体的加法 Operation 实例
+	Cache:     &pureCache,
 	Properties: map[string][]model.Port{
 		"inputs": {
-			{Name: "a", Label: "加数A", PortType: "connection"},
-			{Name: "b", Label: "加数B", PortType: "connection"},
+			{Name: "a", Label: "加数A", PortType: "connection", DataType: &intType},
+			{Name: "b", Label: "加数B", PortType: "connection", DataType: &intType},
 		},
 		"outputs": {
-			{Name: "sum", Label: "和", PortType: "connection"},
+			{Name: "sum", Label: "和", PortType: "connection", DataType: &intType},
 		},
 	},
 }
@@ -43,13 +59,14 @@ var MulNodeType = model.NodeType{
 	Category:  "math",
 	Note:      "两个数字相乘，输出结果",
 	Operation: MulOperationInst, // 这里你可以填入具体的乘法 Operation 实例
+	Cache:     &pureCache,
 	Properties: map[string][]model.Port{
 		"inputs": {
-			{Name: "a", Label: "乘数A", PortType: "connection"},
-			{Name: "b", Label: "乘数B", PortType: "connection"},
+			{Name: "a", Label: "乘数A", PortType: "connection", DataType: &intType},
+			{Name: "b", Label: "乘数B", PortType: "connection", DataType: &intType},
 		},
 		"outputs": {
-			{Name: "product", Label: "积", PortType: "connection"},
+			{Name: "product", Label: "积", PortType: "connection", DataType: &intType},
 		},
 	},
 }
@@ -69,3 +86,53 @@ var EchoNodeType = model.NodeType{
 		},
 	},
 }
+
+// IfNodeType 条件分支节点
+var IfNodeType = model.NodeType{
+	UID:       fmt.Sprintf("%s.if", ServiceName),
+	Category:  "control",
+	Note:      "根据条件选择分支，只有被选中的输出端口对应的下游会执行",
+	Operation: IfOperationInst,
+	Properties: map[string][]model.Port{
+		"inputs": {
+			{Name: "cond", Label: "条件", PortType: "connection"},
+		},
+		"outputs": {
+			{Name: "true", Label: "条件成立", PortType: "connection"},
+			{Name: "false", Label: "条件不成立", PortType: "connection"},
+		},
+	},
+}
+
+// RepeatNodeType 重复文本节点，输出可能很大，以 blob 引用传递
+var RepeatNodeType = model.NodeType{
+	UID:       fmt.Sprintf("%s.repeat", ServiceName),
+	Category:  "file",
+	Note:      "将文本重复指定次数，输出文本文件",
+	Operation: RepeatOperationInst,
+	Properties: map[string][]model.Port{
+		"inputs": {
+			{Name: "text", Label: "文本", PortType: "connection"},
+			{Name: "times", Label: "次数", PortType: "connection", DataType: &intType},
+		},
+		"outputs": {
+			{Name: "file", Label: "文件", PortType: "file", DataType: &textFileType},
+		},
+	},
+}
+
+// SizeNodeType 文件大小节点
+var SizeNodeType = model.NodeType{
+	UID:       fmt.Sprintf("%s.size", ServiceName),
+	Category:  "file",
+	Note:      "统计文件的字节数",
+	Operation: SizeOperationInst,
+	Properties: map[string][]model.Port{
+		"inputs": {
+			{Name: "file", Label: "文件", PortType: "file", DataType: &model.DataType{Kind: model.DataKindFile}},
+		},
+		"outputs": {
+			{Name: "size", Label: "字节数", PortType: "connection", DataType: &intType},
+		},
+	},
+}
@@ -1,9 +1,13 @@
 package core
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"strings"
 
 	"zflow/app/zflow/model"
+	"zflow/utils/blob"
 )
 
 // AddOperation 实现
@@ -69,3 +73,79 @@ func (op *EchoOperation) Execute(ctx model.Context, inputs map[string][]byte, va
 }
 
 var EchoOperationInst = &EchoOperation{}
+
+// IfOperation 实现
+// 条件分支，输入 cond，条件成立时输出 true 端口，否则输出 false 端口
+type IfOperation struct{}
+
+func (op *IfOperation) Execute(ctx model.Context, inputs map[string][]byte, vars map[string]interface{}) (map[string][]byte, error) {
+	cond, ok := inputs["cond"]
+	if !ok {
+		return nil, fmt.Errorf("条件节点缺少 cond 输入")
+	}
+	value := strings.TrimSpace(string(cond))
+	branch := "true"
+	switch strings.ToLower(value) {
+	case "", "0", "false", "no":
+		branch = "false"
+	}
+	ctx.Log(fmt.Sprintf("If: %s -> %s", value, branch))
+	return map[string][]byte{branch: cond}, nil
+}
+
+var IfOperationInst = &IfOperation{}
+
+// RepeatOperation 实现
+// 重复文本，输入 text、times，输出 file；超过内联上限的输出由服务以 blob 引用返回
+type RepeatOperation struct{}
+
+func (op *RepeatOperation) Execute(ctx model.Context, inputs map[string][]byte, vars map[string]interface{}) (map[string][]byte, error) {
+	text, ok := inputs["text"]
+	if !ok {
+		return nil, fmt.Errorf("重复节点缺少 text 输入")
+	}
+	var times int
+	if _, err := fmt.Sscanf(string(inputs["times"]), "%d", &times); err != nil || times < 0 {
+		return nil, fmt.Errorf("重复节点输入 times 解析失败: %v", err)
+	}
+
+	// 分批生成并上报进度
+	const batches = 10
+	var buf bytes.Buffer
+	buf.Grow(len(text) * times)
+	for i := 1; i <= batches; i++ {
+		if err := ctx.Context().Err(); err != nil {
+			return nil, err
+		}
+		n := times*i/batches - times*(i-1)/batches
+		buf.Write(bytes.Repeat(text, n))
+		ctx.Progress(float64(i*100/batches), fmt.Sprintf("已生成 %d 字节", buf.Len()))
+	}
+	ctx.Log(fmt.Sprintf("Repeat: %d x %d 字节", times, len(text)))
+	return map[string][]byte{"file": buf.Bytes()}, nil
+}
+
+var RepeatOperationInst = &RepeatOperation{}
+
+// SizeOperation 实现
+// 统计文件大小，输入 file 可以是内联数据或 blob 引用，输出 size
+type SizeOperation struct{}
+
+func (op *SizeOperation) Execute(ctx model.Context, inputs map[string][]byte, vars map[string]interface{}) (map[string][]byte, error) {
+	data, ok := inputs["file"]
+	if !ok {
+		return nil, fmt.Errorf("文件大小节点缺少 file 输入")
+	}
+	r, err := blob.Open(ctx.Context(), data)
+	if err != nil {
+		return nil, fmt.Errorf("文件大小节点读取文件失败: %v", err)
+	}
+	defer r.Close()
+	size, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return nil, fmt.Errorf("文件大小节点读取文件失败: %v", err)
+	}
+	return map[string][]byte{"size": []byte(fmt.Sprintf("%d", size))}, nil
+}
+
+var SizeOperationInst = &SizeOperation{}
@@ -7,7 +7,6 @@ import (
 	"time"
 	v1 "zflow/api/base"
 	"zflow/api/registry"
-	registryCore "zflow/app/registry/core"
 
 	"zflow/utils/cache"
 
@@ -20,7 +19,7 @@ var my_cache = cache.NewCache()
 
 func main() {
 	// 连接注册中心
-	conn, err := grpc.NewClient(string(registryCore.SERVICE_REGISTRY_ADDR), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := registry.Dial(registry.SERVICE_REGISTRY_ADDR)
 	if err != nil {
 		log.Fatalf("连接注册中心失败: %v", err)
 	}
@@ -55,15 +54,17 @@ func watchAllServices(cli registry.RegistryClient) {
 	}
 
 	for {
-		srvList, err := stream.Recv()
+		resp, err := stream.Recv()
 		if err != nil {
-			log.Printf("接收服务列表失败: %v", err)
+			log.Printf("接收服务变更失败: %v", err)
 			return
 		}
 
-		// 处理每个服务实例
-		for _, inst := range srvList.Instances {
-			go fetchServiceTypes(inst)
+		// 处理新增或变更的服务实例
+		for _, ev := range resp.Events {
+			if ev.Type != registry.EventType_REMOVED {
+				go fetchServiceTypes(ev.Instance)
+			}
 		}
 	}
 }
@@ -6,15 +6,11 @@ import (
 	"time"
 
 	"zflow/api/registry"
-	registryCore "zflow/app/registry/core"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
 	// 连接注册中心
-	conn, err := grpc.NewClient(string(registryCore.SERVICE_REGISTRY_ADDR), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := registry.Dial(registry.SERVICE_REGISTRY_ADDR)
 	if err != nil {
 		log.Fatalf("连接注册中心失败: %v", err)
 	}
@@ -76,14 +72,14 @@ func testWatch(cli registry.RegistryClient) {
 
 	go func() {
 		for {
-			srvList, err := stream.Recv()
+			resp, err := stream.Recv()
 			if err != nil {
-				log.Printf("接收服务列表失败: %v", err)
+				log.Printf("接收服务变更失败: %v", err)
 				return
 			}
-			log.Printf("服务列表更新: %d 个实例", len(srvList.Instances))
-			for _, inst := range srvList.Instances {
-				log.Printf("- 实例ID: %s, 地址: %s", inst.Id, inst.Addr)
+			log.Printf("服务变更: %d 个事件 (版本: %d, 快照: %v)", len(resp.Events), resp.Revision, resp.Snapshot)
+			for _, ev := range resp.Events {
+				log.Printf("- %s 实例ID: %s, 地址: %s", ev.Type, ev.Instance.Id, ev.Instance.Addr)
 			}
 		}
 	}()
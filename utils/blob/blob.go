@@ -0,0 +1,116 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	v1 "zflow/api/base"
+)
+
+// InlineLimit file 端口的数据超过该字节数时以 blob 引用传递，不再内联在 RunNode 消息中
+var InlineLimit = 1 << 20
+
+// ChunkSize 流式读写 blob 时每块的字节数
+const ChunkSize = 64 << 10
+
+// ErrNotFound blob 不存在
+var ErrNotFound = errors.New("blob not found")
+
+// idPattern blob ID 为内容的 sha256 十六进制摘要，校验后可直接用作文件名
+var idPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Ref blob 引用，节点之间只传递引用，数据保存在 Addr 对应的服务实例上
+type Ref struct {
+	ID   string `json:"blob_id"`
+	Size int64  `json:"size"`
+	MIME string `json:"mime,omitempty"`
+	Addr string `json:"addr,omitempty"`
+}
+
+// Store blob 存储，以内容的 sha256 摘要寻址，相同内容只保存一份
+type Store interface {
+	// Put 保存 r 的全部内容，返回引用，Addr 由调用方填充
+	Put(r io.Reader, mime string) (Ref, error)
+	// Open 打开 blob，不存在时返回 ErrNotFound
+	Open(id string) (io.ReadCloser, Ref, error)
+	// Stat 返回 blob 的引用，不存在时返回 ErrNotFound
+	Stat(id string) (Ref, error)
+}
+
+// ValidID 判断是否为合法的 blob ID
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// refHeader 编码后的引用以该头部开始，后接引用的 JSON。
+// JSON 与文本不会以 NUL 开始，只有 Encode 生成的数据会被识别为引用，内容恰好像引用的用户数据原样传递
+const refHeader = "\x00zflow.blob\x00"
+
+// Encode 将引用编码为端口数据，工作流中以端口数据的形式保存与传递
+func (r Ref) Encode() []byte {
+	data, _ := json.Marshal(r)
+	return append([]byte(refHeader), data...)
+}
+
+// Decode 从端口数据中解析 Encode 编码的引用，数据不是引用时返回 false
+func Decode(data []byte) (Ref, bool) {
+	body, ok := bytes.CutPrefix(data, []byte(refHeader))
+	if !ok || len(body) > 1024 {
+		return Ref{}, false
+	}
+	var ref Ref
+	if err := json.Unmarshal(body, &ref); err != nil || !ValidID(ref.ID) {
+		return Ref{}, false
+	}
+	return ref, true
+}
+
+// Text 返回端口数据的可读形式，用于运行结果：引用显示为引用的 JSON，其它数据原样转为字符串
+func Text(data []byte) string {
+	if _, ok := Decode(data); ok {
+		return string(data[len(refHeader):])
+	}
+	return string(data)
+}
+
+// Proto 转换为 v1.BlobRef
+func (r Ref) Proto() *v1.BlobRef {
+	return &v1.BlobRef{Id: r.ID, Size: r.Size, Mime: r.MIME, Addr: r.Addr}
+}
+
+// FromProto 从 v1.BlobRef 转换
+func FromProto(ref *v1.BlobRef) Ref {
+	return Ref{ID: ref.GetId(), Size: ref.GetSize(), MIME: ref.GetMime(), Addr: ref.GetAddr()}
+}
+
+// Open 供 Operation 读取 file 端口的数据：数据是引用时从 context 中的存储打开，否则直接读取内联数据
+func Open(ctx context.Context, data []byte) (io.ReadCloser, error) {
+	ref, ok := Decode(data)
+	if !ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	store := FromContext(ctx)
+	if store == nil {
+		return nil, fmt.Errorf("blob %s: no blob store in context", ref.ID)
+	}
+	r, _, err := store.Open(ref.ID)
+	return r, err
+}
+
+type storeKey struct{}
+
+// WithStore 将 blob 存储放入 context，供 Operation 读写 file 端口引用的数据
+func WithStore(ctx context.Context, store Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// FromContext 取出 context 中的 blob 存储，没有时返回空
+func FromContext(ctx context.Context) Store {
+	store, _ := ctx.Value(storeKey{}).(Store)
+	return store
+}
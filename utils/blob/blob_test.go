@@ -0,0 +1,31 @@
+package blob
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	ref := Ref{ID: strings.Repeat("a", 64), Size: 3, MIME: "text/plain", Addr: "127.0.0.1:9090"}
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"编码的引用", ref.Encode(), true},
+		{"形似引用的用户 JSON", []byte(`{"blob_id": "` + ref.ID + `", "size": 3}`), false},
+		{"普通文本", []byte("hello"), false},
+		{"头部后不是合法引用", []byte(refHeader + `{"blob_id": "x"}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Decode(tt.data)
+			if ok != tt.want || ok && got != ref {
+				t.Fatalf("Decode() = %+v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+	if got, want := Text(ref.Encode()), `{"blob_id":"`+ref.ID+`","size":3,"mime":"text/plain","addr":"127.0.0.1:9090"}`; got != want {
+		t.Fatalf("Text() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileStore 基于本地目录的 blob 存储，数据保存为 <root>/<id前两位>/<id>，
+// 元数据保存为同目录下的 <id>.json。多个服务共用同一目录时可以直接读取彼此的 blob。
+type FileStore struct {
+	root string
+}
+
+// NewFileStore 打开文件存储，目录不存在时自动创建
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob dir %s: %v", root, err)
+	}
+	return &FileStore{root: root}, nil
+}
+
+// Put 实现 Store 接口，边写入边计算摘要，写完后改名为摘要，避免留下写了一半的 blob
+func (s *FileStore) Put(r io.Reader, mime string) (Ref, error) {
+	tmp, err := os.CreateTemp(s.root, "upload-*.tmp")
+	if err != nil {
+		return Ref{}, fmt.Errorf("failed to create blob: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Ref{}, fmt.Errorf("failed to write blob: %v", err)
+	}
+	ref := Ref{ID: hex.EncodeToString(h.Sum(nil)), Size: size, MIME: mime}
+
+	// 相同内容已存在时直接复用
+	if _, err := s.Stat(ref.ID); err == nil {
+		return ref, nil
+	}
+
+	path := s.path(ref.ID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Ref{}, fmt.Errorf("failed to create blob dir: %v", err)
+	}
+	meta, err := json.Marshal(ref)
+	if err != nil {
+		return Ref{}, fmt.Errorf("failed to encode blob %s: %v", ref.ID, err)
+	}
+	if err := os.WriteFile(path+".json", meta, 0o644); err != nil {
+		return Ref{}, fmt.Errorf("failed to write blob %s: %v", ref.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return Ref{}, fmt.Errorf("failed to write blob %s: %v", ref.ID, err)
+	}
+	return ref, nil
+}
+
+// Open 实现 Store 接口
+func (s *FileStore) Open(id string) (io.ReadCloser, Ref, error) {
+	ref, err := s.Stat(id)
+	if err != nil {
+		return nil, Ref{}, err
+	}
+	f, err := os.Open(s.path(id))
+	if err != nil {
+		return nil, Ref{}, fmt.Errorf("failed to open blob %s: %v", id, err)
+	}
+	return f, ref, nil
+}
+
+// Stat 实现 Store 接口，数据文件写入完成后才算存在
+func (s *FileStore) Stat(id string) (Ref, error) {
+	if !ValidID(id) {
+		return Ref{}, fmt.Errorf("blob %s: %w", id, ErrNotFound)
+	}
+	path := s.path(id)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return Ref{}, fmt.Errorf("blob %s: %w", id, ErrNotFound)
+	}
+	data, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return Ref{}, fmt.Errorf("failed to read blob %s: %v", id, err)
+	}
+	var ref Ref
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return Ref{}, fmt.Errorf("failed to decode blob %s: %v", id, err)
+	}
+	return ref, nil
+}
+
+// path blob 数据文件路径
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.root, id[:2], id)
+}
@@ -0,0 +1,152 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	v1 "zflow/api/base"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chunkReader 将数据块流适配为 io.Reader
+type chunkReader struct {
+	recv func() (*v1.BlobChunk, error)
+	buf  []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk.GetData()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Fetch 确保引用的 blob 保存在本地存储中：本地没有时通过 ReadBlob 从保存它的服务实例拉取
+func Fetch(ctx context.Context, store Store, ref Ref, dial func(addr string) (v1.BaseServiceClient, error)) error {
+	if _, err := store.Stat(ref.ID); err == nil {
+		return nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if ref.Addr == "" {
+		return fmt.Errorf("blob %s: %w and has no source address", ref.ID, ErrNotFound)
+	}
+
+	cli, err := dial(ref.Addr)
+	if err != nil {
+		return fmt.Errorf("connect to %s failed: %v", ref.Addr, err)
+	}
+	stream, err := cli.ReadBlob(ctx, &v1.ReadBlobRequest{Id: ref.ID})
+	if err != nil {
+		return fmt.Errorf("read blob %s from %s failed: %v", ref.ID, ref.Addr, err)
+	}
+	got, err := store.Put(&chunkReader{recv: stream.Recv}, ref.MIME)
+	if err != nil {
+		return fmt.Errorf("read blob %s from %s failed: %v", ref.ID, ref.Addr, err)
+	}
+	if got.ID != ref.ID {
+		return fmt.Errorf("blob %s from %s has mismatched digest %s", ref.ID, ref.Addr, got.ID)
+	}
+	return nil
+}
+
+// Upload 通过 WriteBlob 将 r 的全部内容上传到服务实例，返回的引用带有实例保存后的 ID 与地址
+func Upload(ctx context.Context, cli v1.BaseServiceClient, r io.Reader, mime string) (Ref, error) {
+	stream, err := cli.WriteBlob(ctx)
+	if err != nil {
+		return Ref{}, err
+	}
+	buf := make([]byte, ChunkSize)
+	first := true
+	for {
+		n, err := r.Read(buf)
+		if n > 0 || first {
+			chunk := &v1.BlobChunk{Data: append([]byte(nil), buf[:n]...)}
+			if first {
+				chunk.Mime = mime
+				first = false
+			}
+			if err := stream.Send(chunk); err != nil {
+				// 发送失败的原因由 CloseAndRecv 返回
+				break
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			stream.CloseSend()
+			return Ref{}, err
+		}
+	}
+	ref, err := stream.CloseAndRecv()
+	if err != nil {
+		return Ref{}, err
+	}
+	return FromProto(ref), nil
+}
+
+// ServeRead 实现 ReadBlob：按块发送本地存储中的 blob
+func ServeRead(store Store, req *v1.ReadBlobRequest, stream grpc.ServerStreamingServer[v1.BlobChunk]) error {
+	if store == nil {
+		return status.Error(codes.Unimplemented, "blob store is not configured")
+	}
+	f, ref, err := store.Open(req.GetId())
+	if errors.Is(err, ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer f.Close()
+
+	buf := make([]byte, ChunkSize)
+	first := true
+	for {
+		n, err := f.Read(buf)
+		if n > 0 || (first && err == io.EOF) {
+			chunk := &v1.BlobChunk{Data: buf[:n]}
+			if first {
+				chunk.Mime = ref.MIME
+				first = false
+			}
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+// ServeWrite 实现 WriteBlob：接收数据块写入本地存储，第一块携带 MIME 类型
+func ServeWrite(store Store, stream grpc.ClientStreamingServer[v1.BlobChunk, v1.BlobRef]) (Ref, error) {
+	if store == nil {
+		return Ref{}, status.Error(codes.Unimplemented, "blob store is not configured")
+	}
+	first, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		return Ref{}, err
+	}
+	r := &chunkReader{recv: stream.Recv, buf: first.GetData()}
+	ref, err := store.Put(r, first.GetMime())
+	if err != nil {
+		return Ref{}, status.Error(codes.Internal, err.Error())
+	}
+	return ref, nil
+}
@@ -43,6 +43,15 @@ func (c *Cache) AddConnType(service string, connType *v1.ConnectionType) {
 	c.connTypes[service][connType.Uid] = connType
 }
 
+// RemoveService 删除服务的全部节点类型和连接类型，服务的实例全部下线时调用
+func (c *Cache) RemoveService(service string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.nodeTypes, service)
+	delete(c.connTypes, service)
+}
+
 // GetNodeTypes 获取所有节点类型
 func (c *Cache) GetNodeTypes() map[string]map[string]*v1.NodeType {
 	c.mu.RLock()
@@ -72,3 +81,31 @@ func (c *Cache) GetConnTypes() map[string]map[string]*v1.ConnectionType {
 	}
 	return result
 }
+
+// FindNodeType 按 UID 查找节点类型，返回 service -> NodeType
+func (c *Cache) FindNodeType(uid string) map[string]*v1.NodeType {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]*v1.NodeType)
+	for service, types := range c.nodeTypes {
+		if nodeType, ok := types[uid]; ok {
+			result[service] = nodeType
+		}
+	}
+	return result
+}
+
+// FindConnType 按 UID 查找连接类型，返回 service -> ConnectionType
+func (c *Cache) FindConnType(uid string) map[string]*v1.ConnectionType {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]*v1.ConnectionType)
+	for service, types := range c.connTypes {
+		if connType, ok := types[uid]; ok {
+			result[service] = connType
+		}
+	}
+	return result
+}
@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"testing"
+
+	v1 "zflow/api/base"
+)
+
+func TestRemoveService(t *testing.T) {
+	c := NewCache()
+	c.AddNodeType("a", &v1.NodeType{Uid: "echo"})
+	c.AddNodeType("b", &v1.NodeType{Uid: "echo"})
+	c.AddConnType("a", &v1.ConnectionType{Uid: "flow"})
+
+	c.RemoveService("a")
+	if got := c.FindNodeType("echo"); len(got) != 1 || got["b"] == nil {
+		t.Fatalf("FindNodeType(echo) = %v, want only service b", got)
+	}
+	if got := c.FindConnType("flow"); len(got) != 0 {
+		t.Fatalf("FindConnType(flow) = %v, want none", got)
+	}
+	if _, ok := c.GetNodeTypes()["a"]; ok {
+		t.Fatal("removed service still listed")
+	}
+}
@@ -0,0 +1,174 @@
+package health
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"zflow/api/registry"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Config 主动健康检查配置
+type Config struct {
+	Interval          time.Duration // 探测间隔，为 0 时不探测
+	Timeout           time.Duration // 单次探测超时
+	FailureThreshold  int           // 连续失败多少次标记为不健康
+	RecoveryThreshold int           // 不健康的实例连续成功多少次恢复为健康
+}
+
+// Target 探测目标
+type Target struct {
+	Addr   string
+	Status registry.HealthStatus // 目标当前的健康状态，状态变化以此为准
+}
+
+// Change 一次探测后健康状态发生变化的目标
+type Change struct {
+	Key    string
+	Status registry.HealthStatus
+}
+
+// Checker 按标准 gRPC 健康检查协议探测一组目标，连续失败或连续成功达到阈值时报告状态变化
+type Checker struct {
+	cfg Config
+
+	mu      sync.Mutex
+	targets map[string]*target
+}
+
+// target 目标的连接与连续探测结果
+type target struct {
+	addr      string
+	status    registry.HealthStatus
+	failures  int
+	successes int
+	conn      *grpc.ClientConn
+	cli       healthpb.HealthClient
+}
+
+// NewChecker 创建探测器，未设置的超时与阈值使用默认值
+func NewChecker(cfg Config) *Checker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Second
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 3
+	}
+	if cfg.RecoveryThreshold <= 0 {
+		cfg.RecoveryThreshold = 2
+	}
+	return &Checker{cfg: cfg, targets: make(map[string]*target)}
+}
+
+// Sync 更新探测目标：新增目标建立连接，地址变化的目标重新连接，不在 targets 中的目标关闭连接
+func (c *Checker) Sync(targets map[string]Target) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, t := range c.targets {
+		if next, ok := targets[key]; !ok || next.Addr != t.addr {
+			t.conn.Close()
+			delete(c.targets, key)
+		}
+	}
+	for key, next := range targets {
+		if t, ok := c.targets[key]; ok {
+			t.status = next.Status
+			continue
+		}
+		conn, err := grpc.NewClient(next.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Printf("连接健康检查目标 %s 失败: %v", next.Addr, err)
+			continue
+		}
+		c.targets[key] = &target{addr: next.Addr, status: next.Status, conn: conn, cli: healthpb.NewHealthClient(conn)}
+	}
+}
+
+// Check 并发探测全部目标，返回按 key 排序的状态变化
+func (c *Checker) Check(ctx context.Context) []Change {
+	c.mu.Lock()
+	probes := make(map[string]healthpb.HealthClient, len(c.targets))
+	for key, t := range c.targets {
+		probes[key] = t.cli
+	}
+	c.mu.Unlock()
+
+	results := make(map[string]bool, len(probes))
+	var wg sync.WaitGroup
+	var resultMu sync.Mutex
+	for key, cli := range probes {
+		wg.Add(1)
+		go func(key string, cli healthpb.HealthClient) {
+			defer wg.Done()
+			ok := c.probe(ctx, cli)
+			resultMu.Lock()
+			results[key] = ok
+			resultMu.Unlock()
+		}(key, cli)
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var changes []Change
+	for key, ok := range results {
+		t, exists := c.targets[key]
+		if !exists {
+			continue
+		}
+		if next, changed := c.observe(t, ok); changed {
+			changes = append(changes, Change{Key: key, Status: next})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// Close 关闭全部连接
+func (c *Checker) Close() {
+	c.Sync(nil)
+}
+
+// probe 探测一次，没有实现健康检查服务的目标视为健康，卡死或无法连接的目标在超时后视为失败
+func (c *Checker) probe(ctx context.Context, cli healthpb.HealthClient) bool {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	resp, err := cli.Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return true
+	}
+	return err == nil && resp.Status == healthpb.HealthCheckResponse_SERVING
+}
+
+// observe 记录一次探测结果，返回新的状态以及状态是否变化，调用方需持有 c.mu。
+// 未探测过的目标第一次成功即为健康，不健康的目标需连续成功 RecoveryThreshold 次
+func (c *Checker) observe(t *target, ok bool) (registry.HealthStatus, bool) {
+	if ok {
+		t.failures = 0
+		t.successes++
+		need := 1
+		if t.status == registry.HealthStatus_UNHEALTHY {
+			need = c.cfg.RecoveryThreshold
+		}
+		if t.status != registry.HealthStatus_HEALTHY && t.successes >= need {
+			t.status = registry.HealthStatus_HEALTHY
+			return t.status, true
+		}
+		return t.status, false
+	}
+	t.successes = 0
+	t.failures++
+	if t.status != registry.HealthStatus_UNHEALTHY && t.failures >= c.cfg.FailureThreshold {
+		t.status = registry.HealthStatus_UNHEALTHY
+		return t.status, true
+	}
+	return t.status, false
+}
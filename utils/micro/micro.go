@@ -11,15 +11,20 @@ import (
 
 	"zflow/api/registry"
 	"zflow/app/zflow/model"
+	"zflow/utils/blob"
 	"zflow/utils/service"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	v1 "zflow/api/base"
 )
 
+// BlobDir 服务保存 blob 的本地目录，同一主机上的服务共用该目录时无需互相拉取
+var BlobDir = "data/blobs"
+
 // Micro 微服务
 type Micro struct {
 	registryServiceAddr string
@@ -39,6 +44,13 @@ func NewMicro(registryServiceAddr, serviceName, serviceAddr string, nodeTypes ma
 		ConnTypes: connTypes,
 	}
 
+	// 创建 blob 存储，失败时服务仍可运行，只是不支持 blob 引用
+	if blobs, err := blob.NewFileStore(BlobDir); err != nil {
+		log.Printf("创建 blob 存储失败: %v", err)
+	} else {
+		baseService.Blobs = blobs
+	}
+
 	return &Micro{
 		registryServiceAddr: registryServiceAddr,
 		baseService:         baseService,
@@ -55,6 +67,11 @@ func (m *Micro) Run() {
 	s := grpc.NewServer()
 	v1.RegisterBaseServiceServer(s, m.baseService)
 
+	// 标准 gRPC 健康检查服务，供注册中心与 bff 主动探测
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(m.baseService.Name, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+
 	// 启动服务注册
 	go m.registerService()
 
@@ -74,6 +91,9 @@ func (m *Micro) Run() {
 	<-quit
 	log.Println("Shutting down server...")
 
+	// 先报告不可用，探测方不再把请求发过来
+	healthServer.Shutdown()
+
 	// 注销服务
 	if err := m.unregisterService(); err != nil {
 		log.Printf("Error unregistering service: %v", err)
@@ -91,11 +111,8 @@ func (m *Micro) Run() {
 
 // registerService 注册服务到注册中心
 func (m *Micro) registerService() {
-	// 创建 gRPC 连接
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	conn, err := grpc.DialContext(ctx, m.registryServiceAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// 创建 gRPC 连接，registryServiceAddr 可以是逗号分隔的多个注册中心节点
+	conn, err := registry.Dial(m.registryServiceAddr)
 	if err != nil {
 		log.Printf("failed to connect to registry: %v", err)
 		return
@@ -114,11 +131,17 @@ func (m *Micro) registerService() {
 		TtlSec: 10,
 	}
 
-	// 注册服务
-	lease, err := m.registryClient.Register(context.Background(), m.serviceInstance)
-	if err != nil {
-		log.Printf("注册失败: %v", err)
-		return
+	// 注册服务，注册中心暂不可用（例如集群正在选举）时重试
+	var lease *registry.Lease
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		lease, err = m.registryClient.Register(ctx, m.serviceInstance)
+		cancel()
+		if err == nil {
+			break
+		}
+		log.Printf("注册失败: %v，稍后重试", err)
+		time.Sleep(3 * time.Second)
 	}
 
 	// 心跳协程
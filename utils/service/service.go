@@ -1,11 +1,19 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
 	v1 "zflow/api/base"
 	"zflow/app/zflow/model"
+	"zflow/utils/blob"
 	"zflow/utils/tool"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 // BaseService 基础服务
@@ -15,6 +23,11 @@ type BaseService struct {
 	Addr      string
 	NodeTypes map[string]*model.NodeType
 	ConnTypes map[string]*model.ConnectionType
+	// Blobs 保存 file 端口数据的 blob 存储，为空时不支持 blob 引用
+	Blobs blob.Store
+
+	mu    sync.Mutex
+	peers map[string]*grpc.ClientConn // 拉取 blob 的实例地址 -> 连接
 }
 
 // GetNodeTypes 获取节点类型
@@ -43,6 +56,52 @@ func (s *BaseService) GetConnTypes(ctx context.Context, req *v1.GetConnTypesRequ
 
 // RunNode 运行节点
 func (s *BaseService) RunNode(ctx context.Context, req *v1.RunNodeRequest) (*v1.RunNodeResponse, error) {
+	execCtx := &model.ExecutionContext{
+		Logger: func(msg string) {
+			log.Printf("[%s] %s", req.NodeId, msg)
+		},
+		Ctx: ctx,
+	}
+	return s.runNode(execCtx, req), nil
+}
+
+// RunNodeStream 运行节点，Operation 输出的日志与进度在执行过程中发送给调用方，最后发送结果
+func (s *BaseService) RunNodeStream(req *v1.RunNodeRequest, stream grpc.ServerStreamingServer[v1.RunNodeEvent]) error {
+	// Operation 可能在多个 goroutine 中输出日志，发送需要串行
+	var mu sync.Mutex
+	var sendErr error
+	send := func(ev *v1.RunNodeEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if sendErr == nil {
+			sendErr = stream.Send(ev)
+		}
+	}
+
+	execCtx := &model.ExecutionContext{
+		Logger: func(msg string) {
+			log.Printf("[%s] %s", req.NodeId, msg)
+		},
+		OnEvent: func(e model.Event) {
+			switch e.Type {
+			case model.EventLog:
+				send(&v1.RunNodeEvent{Type: "log", Message: e.Message})
+			case model.EventNodeProgress:
+				send(&v1.RunNodeEvent{Type: "progress", Message: e.Message, Percent: *e.Percent})
+			}
+		},
+		Ctx: stream.Context(),
+	}
+	resp := s.runNode(execCtx, req)
+	send(&v1.RunNodeEvent{Type: "result", Result: resp})
+
+	mu.Lock()
+	defer mu.Unlock()
+	return sendErr
+}
+
+// runNode 在 execCtx 中执行节点，失败也以响应的 State 与 Error 返回
+func (s *BaseService) runNode(execCtx *model.ExecutionContext, req *v1.RunNodeRequest) *v1.RunNodeResponse {
 	// 根据节点ID找到对应的节点类型
 	var nodeType *model.NodeType
 	for _, nt := range s.NodeTypes {
@@ -56,33 +115,149 @@ func (s *BaseService) RunNode(ctx context.Context, req *v1.RunNodeRequest) (*v1.
 		return &v1.RunNodeResponse{
 			State: "failed",
 			Error: "未找到节点类型",
-		}, nil
+		}
 	}
 
-	// 创建执行上下文
-	execCtx := &model.ExecutionContext{
-		Logger: func(msg string) {
-			log.Printf("[%s] %s", req.NodeId, msg)
-		},
-		Vars: make(map[string]interface{}),
+	// 以引用传递的输入先拉取到本地存储，Operation 通过 blob.FromContext 读取
+	inputs, err := s.resolveBlobInputs(execCtx.Ctx, req)
+	if err != nil {
+		return &v1.RunNodeResponse{
+			State: "failed",
+			Error: err.Error(),
+		}
+	}
+	if s.Blobs != nil {
+		execCtx.Ctx = blob.WithStore(execCtx.Ctx, s.Blobs)
 	}
 
 	// 将请求中的变量复制到上下文
+	execCtx.Vars = make(map[string]interface{}, len(req.Vars))
 	for k, v := range req.Vars {
 		execCtx.Vars[k] = v
 	}
 
 	// 执行节点操作
-	outputs, err := nodeType.Operation.Execute(execCtx, req.Inputs, execCtx.Vars)
+	outputs, err := nodeType.Operation.Execute(execCtx, inputs, execCtx.Vars)
 	if err != nil {
 		return &v1.RunNodeResponse{
 			State: "failed",
 			Error: err.Error(),
-		}, nil
+		}
 	}
 
-	return &v1.RunNodeResponse{
-		Outputs: outputs,
-		State:   "success",
-	}, nil
+	// 引用与超过内联上限的 file 端口输出以 blob 引用返回
+	resp, err := s.blobOutputs(nodeType, outputs)
+	if err != nil {
+		return &v1.RunNodeResponse{
+			State: "failed",
+			Error: err.Error(),
+		}
+	}
+	resp.State = "success"
+	return resp
+}
+
+// ReadBlob 按块读取本服务保存的 blob
+func (s *BaseService) ReadBlob(req *v1.ReadBlobRequest, stream grpc.ServerStreamingServer[v1.BlobChunk]) error {
+	return blob.ServeRead(s.Blobs, req, stream)
+}
+
+// WriteBlob 接收上传的 blob，返回的引用指向本服务
+func (s *BaseService) WriteBlob(stream grpc.ClientStreamingServer[v1.BlobChunk, v1.BlobRef]) error {
+	ref, err := blob.ServeWrite(s.Blobs, stream)
+	if err != nil {
+		return err
+	}
+	ref.Addr = s.Addr
+	return stream.SendAndClose(ref.Proto())
+}
+
+// resolveBlobInputs 合并内联输入与引用输入，引用的 blob 不在本地时从保存它的实例拉取
+func (s *BaseService) resolveBlobInputs(ctx context.Context, req *v1.RunNodeRequest) (map[string][]byte, error) {
+	if len(req.BlobInputs) == 0 {
+		return req.Inputs, nil
+	}
+	if s.Blobs == nil {
+		return nil, fmt.Errorf("服务 %s 没有配置 blob 存储，无法读取引用输入", s.Name)
+	}
+
+	inputs := make(map[string][]byte, len(req.Inputs)+len(req.BlobInputs))
+	for k, v := range req.Inputs {
+		inputs[k] = v
+	}
+	for port, pbRef := range req.BlobInputs {
+		ref := blob.FromProto(pbRef)
+		if err := blob.Fetch(ctx, s.Blobs, ref, s.peer); err != nil {
+			return nil, fmt.Errorf("输入端口 %s: %v", port, err)
+		}
+		ref.Addr = s.Addr
+		inputs[port] = ref.Encode()
+	}
+	return inputs, nil
+}
+
+// blobOutputs 将 Operation 的输出拆分为内联输出与引用输出
+func (s *BaseService) blobOutputs(nodeType *model.NodeType, outputs map[string][]byte) (*v1.RunNodeResponse, error) {
+	resp := &v1.RunNodeResponse{Outputs: make(map[string][]byte, len(outputs))}
+	for port, data := range outputs {
+		ref, isRef := blob.Decode(data)
+		if !isRef && s.Blobs != nil && len(data) > blob.InlineLimit {
+			if p, ok := filePort(nodeType, port); ok {
+				var err error
+				if ref, err = s.Blobs.Put(bytes.NewReader(data), concreteMIME(p.MIME())); err != nil {
+					return nil, fmt.Errorf("输出端口 %s: %v", port, err)
+				}
+				isRef = true
+			}
+		}
+		if !isRef {
+			resp.Outputs[port] = data
+			continue
+		}
+		if ref.Addr == "" {
+			ref.Addr = s.Addr
+		}
+		if resp.BlobOutputs == nil {
+			resp.BlobOutputs = make(map[string]*v1.BlobRef)
+		}
+		resp.BlobOutputs[port] = ref.Proto()
+	}
+	return resp, nil
+}
+
+// peer 返回到其他服务实例的客户端，连接按地址复用
+func (s *BaseService) peer(addr string) (v1.BaseServiceClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if conn, ok := s.peers[addr]; ok {
+		return v1.NewBaseServiceClient(conn), nil
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	if s.peers == nil {
+		s.peers = make(map[string]*grpc.ClientConn)
+	}
+	s.peers[addr] = conn
+	return v1.NewBaseServiceClient(conn), nil
+}
+
+// filePort 返回声明为 file 端口（端口类型或数据类型为 file）的输出端口
+func filePort(nodeType *model.NodeType, port string) (model.Port, bool) {
+	for _, p := range nodeType.Properties["outputs"] {
+		if p.Name == port && p.IsFile() {
+			return p, true
+		}
+	}
+	return model.Port{}, false
+}
+
+// concreteMIME 端口声明的 MIME 含通配符时不能作为数据的 MIME
+func concreteMIME(mime string) string {
+	if strings.Contains(mime, "*") {
+		return ""
+	}
+	return mime
 }
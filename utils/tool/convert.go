@@ -18,16 +18,40 @@ func ConvertNodeType(nt model.NodeType) *v1.NodeType {
 				Label:    port.Label,
 				PortType: port.PortType,
 			}
+			if port.DataType != nil {
+				portList.Ports[i].DataType = &v1.DataType{
+					Kind:   port.DataType.Kind,
+					Schema: port.DataType.Schema,
+					Mime:   port.DataType.MIME,
+				}
+			}
 		}
 		properties[k] = portList
 	}
 
-	return &v1.NodeType{
+	nodeType := &v1.NodeType{
 		Uid:        nt.UID,
 		Category:   nt.Category,
 		Note:       nt.Note,
 		Properties: properties,
 	}
+	if nt.Retry != nil {
+		nodeType.Retry = &v1.RetryPolicy{
+			MaxAttempts:   int32(nt.Retry.MaxAttempts),
+			BackoffBaseMs: nt.Retry.BackoffBaseMs,
+			BackoffMaxMs:  nt.Retry.BackoffMaxMs,
+			Jitter:        nt.Retry.Jitter,
+			RetryOn:       nt.Retry.RetryOn,
+		}
+	}
+	if nt.Cache != nil {
+		nodeType.Cache = &v1.CachePolicy{
+			Version: nt.Cache.Version,
+			Vars:    nt.Cache.Vars,
+			TtlMs:   nt.Cache.TTLMs,
+		}
+	}
+	return nodeType
 }
 
 // ConvertConnType 将 model.ConnectionType 转换为 v1.ConnectionType